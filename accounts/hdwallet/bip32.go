@@ -0,0 +1,104 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"math/big"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/common/math"
+)
+
+//BIP-32 derivation of the secp256k1 private keys,
+//https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+
+const hardenedOffset = 0x80000000
+
+var ErrInvalidKey = errors.New("invalid derived key, use the next index")
+
+//extendedKey is a private key and its chain code
+type extendedKey struct {
+	key       []byte //32 bytes
+	chainCode []byte //32 bytes
+}
+
+//newMasterKey returns the master key of a seed
+func newMasterKey(seed []byte) (*extendedKey, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed)
+	sum := mac.Sum(nil)
+	if !validKey(sum[:32]) {
+		return nil, ErrInvalidKey
+	}
+	return &extendedKey{key: sum[:32], chainCode: sum[32:]}, nil
+}
+
+func validKey(key []byte) bool {
+	k := new(big.Int).SetBytes(key)
+	return k.Sign() > 0 && k.Cmp(crypto.S256().Params().N) < 0
+}
+
+//child derives the child key of index, hardened from hardenedOffset
+func (k *extendedKey) child(index uint32) (*extendedKey, error) {
+	data := make([]byte, 0, 37)
+	if index >= hardenedOffset {
+		data = append(data, 0)
+		data = append(data, k.key...)
+	} else {
+		data = append(data, compressedPubKey(k.key)...)
+	}
+	var i [4]byte
+	binary.BigEndian.PutUint32(i[:], index)
+	data = append(data, i[:]...)
+
+	mac := hmac.New(sha512.New, k.chainCode)
+	mac.Write(data)
+	sum := mac.Sum(nil)
+	if !validKey(sum[:32]) {
+		return nil, ErrInvalidKey
+	}
+
+	n := crypto.S256().Params().N
+	childKey := new(big.Int).SetBytes(sum[:32])
+	childKey.Add(childKey, new(big.Int).SetBytes(k.key))
+	childKey.Mod(childKey, n)
+	if childKey.Sign() == 0 {
+		return nil, ErrInvalidKey
+	}
+	return &extendedKey{key: math.PaddedBigBytes(childKey, 32), chainCode: sum[32:]}, nil
+}
+
+//derive derives the key of a path from the master key
+func (k *extendedKey) derive(path accounts.DerivationPath) (*extendedKey, error) {
+	key := k
+	for _, index := range path {
+		var err error
+		if key, err = key.child(index); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+func (k *extendedKey) privateKey() (*ecdsa.PrivateKey, error) {
+	return crypto.ToECDSA(k.key)
+}
+
+//compressedPubKey returns the SEC1 compressed public key of a private key
+func compressedPubKey(key []byte) []byte {
+	x, y := crypto.S256().ScalarBaseMult(key)
+	res := make([]byte, 33)
+	res[0] = 2 + byte(y.Bit(0))
+	copy(res[1:], math.PaddedBigBytes(x, 32))
+	return res
+}
+
+func (k *extendedKey) zero() {
+	for i := range k.key {
+		k.key[i] = 0
+	}
+}
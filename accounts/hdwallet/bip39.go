@@ -0,0 +1,129 @@
+package hdwallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+//BIP-39 mnemonics, https://github.com/bitcoin/bips/blob/master/bip-0039.mediawiki
+
+const (
+	//DefaultEntropyBits gives mnemonics of 24 words
+	DefaultEntropyBits = 256
+
+	seedIterations = 2048
+	seedLength     = 64
+)
+
+var (
+	ErrEntropyLength    = errors.New("the entropy should be 128 to 256 bits, by steps of 32")
+	ErrInvalidMnemonic  = errors.New("invalid mnemonic")
+	ErrMnemonicChecksum = errors.New("invalid mnemonic checksum")
+)
+
+var wordIndex = func() map[string]int {
+	index := make(map[string]int, len(englishWords))
+	for i, word := range englishWords {
+		index[word] = i
+	}
+	return index
+}()
+
+//NewEntropy returns bits of random entropy
+func NewEntropy(bits int) ([]byte, error) {
+	if err := checkEntropyBits(bits); err != nil {
+		return nil, err
+	}
+	entropy := make([]byte, bits/8)
+	if _, err := rand.Read(entropy); err != nil {
+		return nil, err
+	}
+	return entropy, nil
+}
+
+func checkEntropyBits(bits int) error {
+	if bits < 128 || bits > 256 || bits%32 != 0 {
+		return ErrEntropyLength
+	}
+	return nil
+}
+
+//NewMnemonic encodes the entropy as a mnemonic: the entropy and a checksum of
+//one bit per 32 bits of entropy, as 11 bits words
+func NewMnemonic(entropy []byte) (string, error) {
+	bits := len(entropy) * 8
+	if err := checkEntropyBits(bits); err != nil {
+		return "", err
+	}
+	checksumBits := uint(bits / 32)
+	hash := sha256.Sum256(entropy)
+
+	data := new(big.Int).SetBytes(entropy)
+	data.Lsh(data, checksumBits)
+	data.Or(data, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	count := (bits + int(checksumBits)) / 11
+	words := make([]string, count)
+	mask := big.NewInt(2047)
+	word := new(big.Int)
+	for i := count - 1; i >= 0; i-- {
+		word.And(data, mask)
+		data.Rsh(data, 11)
+		words[i] = englishWords[word.Int64()]
+	}
+	return strings.Join(words, " "), nil
+}
+
+//MnemonicToEntropy decodes a mnemonic, verifying its checksum
+func MnemonicToEntropy(mnemonic string) ([]byte, error) {
+	words := strings.Fields(mnemonic)
+	count := len(words)
+	if count < 12 || count > 24 || count%3 != 0 {
+		return nil, ErrInvalidMnemonic
+	}
+
+	data := new(big.Int)
+	for _, w := range words {
+		i, ok := wordIndex[strings.ToLower(w)]
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown word %q", ErrInvalidMnemonic, w)
+		}
+		data.Lsh(data, 11)
+		data.Or(data, big.NewInt(int64(i)))
+	}
+
+	checksumBits := uint(count / 3)
+	checksum := new(big.Int).And(data, big.NewInt(int64(1<<checksumBits-1)))
+	data.Rsh(data, checksumBits)
+
+	entropy := make([]byte, (count*11-int(checksumBits))/8)
+	b := data.Bytes()
+	copy(entropy[len(entropy)-len(b):], b)
+
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum.Int64() {
+		return nil, ErrMnemonicChecksum
+	}
+	return entropy, nil
+}
+
+//ValidateMnemonic returns an error when the mnemonic has unknown words or an
+//invalid checksum
+func ValidateMnemonic(mnemonic string) error {
+	_, err := MnemonicToEntropy(mnemonic)
+	return err
+}
+
+//NewSeed returns the seed of a mnemonic, protected by an optional password
+//that is not the passphrase encrypting the wallet file
+func NewSeed(mnemonic, password string) []byte {
+	normalized := strings.Join(strings.Fields(strings.ToLower(mnemonic)), " ")
+	return pbkdf2.Key([]byte(normalized), []byte("mnemonic"+password), seedIterations, seedLength, sha512.New)
+}
@@ -0,0 +1,86 @@
+package hdwallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts"
+)
+
+//Test vectors of https://github.com/trezor/python-mnemonic/blob/master/vectors.json
+func TestMnemonic(t *testing.T) {
+	tests := []struct {
+		entropy, mnemonic, seed string
+	}{
+		{
+			"00000000000000000000000000000000",
+			"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about",
+			"c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04",
+		},
+		{
+			"7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f7f",
+			"legal winner thank year wave sausage worth useful legal winner thank yellow",
+			"2e8905819b8723fe2c1d161860e5ee1830318dbf49a83bd451cfb8440c28bd6fa457fe1296106559a3c80937a1c1069be3a3a5bd381ee6260e8d9739fce1f607",
+		},
+	}
+	for _, test := range tests {
+		entropy, _ := hex.DecodeString(test.entropy)
+		mnemonic, err := NewMnemonic(entropy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if mnemonic != test.mnemonic {
+			t.Fatalf("expected mnemonic %q, got %q", test.mnemonic, mnemonic)
+		}
+		decoded, err := MnemonicToEntropy(mnemonic)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(decoded, entropy) {
+			t.Fatalf("expected entropy %s, got %x", test.entropy, decoded)
+		}
+		if seed := hex.EncodeToString(NewSeed(mnemonic, "TREZOR")); seed != test.seed {
+			t.Fatalf("expected seed %s, got %s", test.seed, seed)
+		}
+	}
+
+	if err := ValidateMnemonic("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon"); err != ErrMnemonicChecksum {
+		t.Fatalf("expected a checksum error, got %v", err)
+	}
+	if err := ValidateMnemonic("abandon abandon abandon"); err != ErrInvalidMnemonic {
+		t.Fatalf("expected an invalid mnemonic error, got %v", err)
+	}
+}
+
+//Test vector 1 of https://github.com/bitcoin/bips/blob/master/bip-0032.mediawiki
+func TestDerive(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+	master, err := newMasterKey(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path, key string
+	}{
+		{"m", "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35"},
+		{"m/0'", "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea"},
+		{"m/0'/1", "3c6cb8d0f6a264c91ea8b5030fadaa8e538b020f0a387421a12de9319dc93368"},
+	}
+	for _, test := range tests {
+		var path accounts.DerivationPath
+		if test.path != "m" {
+			if path, err = accounts.ParseDerivationPath(test.path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		key, err := master.derive(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if k := hex.EncodeToString(key.key); k != test.key {
+			t.Fatalf("%s: expected key %s, got %s", test.path, test.key, k)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package hdwallet
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/common/event"
+)
+
+//Hub is the accounts.Backend of the HD wallets of a directory, one file per
+//wallet
+type Hub struct {
+	dir              string
+	scryptN, scryptP int
+
+	wallets    []*Wallet //sorted by URL
+	updateFeed event.Feed
+
+	mu sync.RWMutex
+}
+
+//NewHub loads the wallets of dir, encrypting the new ones with the standard
+//scrypt parameters
+func NewHub(dir string) (*Hub, error) {
+	return NewHubWithScrypt(dir, keystore.StandardScryptN, keystore.StandardScryptP)
+}
+
+//NewHubWithScrypt loads the wallets of dir, encrypting the new ones with the
+//given scrypt parameters
+func NewHubWithScrypt(dir string, scryptN, scryptP int) (*Hub, error) {
+	h := &Hub{dir: dir, scryptN: scryptN, scryptP: scryptP}
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		w, err := loadWallet(path)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+		h.wallets = append(h.wallets, w)
+	}
+	sort.Slice(h.wallets, func(i, j int) bool { return h.wallets[i].url.Cmp(h.wallets[j].url) < 0 })
+	return h, nil
+}
+
+//Wallets implements accounts.Backend
+func (h *Hub) Wallets() []accounts.Wallet {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	res := make([]accounts.Wallet, len(h.wallets))
+	for i, w := range h.wallets {
+		res[i] = w
+	}
+	return res
+}
+
+//Subscribe implements accounts.Backend, notifying the wallets created by
+//NewWallet and Restore
+func (h *Hub) Subscribe(sink chan<- accounts.WalletEvent) event.Subscription {
+	return h.updateFeed.Subscribe(sink)
+}
+
+//NewWallet creates a wallet from a new mnemonic of bits of entropy, encrypted
+//with passphrase. The mnemonic is the only backup of the wallet.
+func (h *Hub) NewWallet(passphrase string, bits int) (*Wallet, string, error) {
+	entropy, err := NewEntropy(bits)
+	if err != nil {
+		return nil, "", err
+	}
+	defer zeroBytes(entropy)
+	mnemonic, err := NewMnemonic(entropy)
+	if err != nil {
+		return nil, "", err
+	}
+	w, err := h.Restore(mnemonic, passphrase)
+	if err != nil {
+		return nil, "", err
+	}
+	return w, mnemonic, nil
+}
+
+//Restore creates the wallet of a mnemonic, encrypted with passphrase. The
+//account of DefaultBaseDerivationPath is derived and pinned, and the wallet
+//is left open.
+func (h *Hub) Restore(mnemonic, passphrase string) (*Wallet, error) {
+	if err := ValidateMnemonic(mnemonic); err != nil {
+		return nil, err
+	}
+	seed := NewSeed(mnemonic, "")
+	defer zeroBytes(seed)
+	master, err := newMasterKey(seed)
+	if err != nil {
+		return nil, err
+	}
+	c, err := keystore.EncryptDataV3(seed, []byte(passphrase), h.scryptN, h.scryptP)
+	if err != nil {
+		return nil, err
+	}
+	w := &Wallet{
+		file:   walletJSON{Version: fileVersion, Crypto: c},
+		paths:  make(map[common.Address]accounts.DerivationPath),
+		master: master,
+	}
+	key, err := w.privateKey(master, accounts.DefaultBaseDerivationPath)
+	if err != nil {
+		return nil, err
+	}
+	first := crypto.PubkeyToAddress(key.PublicKey)
+	zeroKey(key)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	path := filepath.Join(h.dir, "hd--"+strings.ToLower(first.Hex()[2:])+".json")
+	if _, err := os.Stat(path); err == nil {
+		return nil, fmt.Errorf("the wallet of %s already exists", first.Hex())
+	}
+	w.url = accounts.URL{Scheme: Scheme, Path: path}
+	if _, err := w.Derive(accounts.DefaultBaseDerivationPath, true); err != nil {
+		return nil, err
+	}
+
+	n := sort.Search(len(h.wallets), func(i int) bool { return h.wallets[i].url.Cmp(w.url) >= 0 })
+	h.wallets = append(h.wallets[:n], append([]*Wallet{w}, h.wallets[n:]...)...)
+	h.updateFeed.Send(accounts.WalletEvent{Wallet: w, Kind: accounts.WalletArrived})
+	return w, nil
+}
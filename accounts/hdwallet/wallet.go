@@ -0,0 +1,285 @@
+package hdwallet
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//Scheme is the URL scheme of the HD wallets, whose path is the wallet file
+const Scheme = "hd"
+
+const fileVersion = 1
+
+var ErrWalletClosed = errors.New("hd wallet closed")
+
+//walletJSON is the wallet file: the encrypted seed and the pinned accounts
+type walletJSON struct {
+	Version  int                 `json:"version"`
+	Crypto   keystore.CryptoJSON `json:"crypto"`
+	Accounts []accountJSON       `json:"accounts"`
+}
+
+type accountJSON struct {
+	Address common.Address `json:"address"`
+	Path    string         `json:"path"`
+}
+
+//Wallet is a software wallet deriving its accounts from a BIP-39 seed,
+//encrypted in its file like the keystore keys. Open decrypts the seed, which
+//is required by SignHash, SignTx and Derive.
+type Wallet struct {
+	url   accounts.URL
+	file  walletJSON
+	paths map[common.Address]accounts.DerivationPath
+
+	master *extendedKey //nil while closed
+
+	mu sync.RWMutex
+}
+
+func loadWallet(path string) (*Wallet, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	w := &Wallet{
+		url:   accounts.URL{Scheme: Scheme, Path: path},
+		paths: make(map[common.Address]accounts.DerivationPath),
+	}
+	if err := json.Unmarshal(data, &w.file); err != nil {
+		return nil, err
+	}
+	for _, a := range w.file.Accounts {
+		p, err := accounts.ParseDerivationPath(a.Path)
+		if err != nil {
+			return nil, err
+		}
+		w.paths[a.Address] = p
+	}
+	return w, nil
+}
+
+//save writes the wallet file, only readable by the user like the keys
+func (w *Wallet) save() error {
+	data, err := json.MarshalIndent(w.file, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(w.url.Path), 0700); err != nil {
+		return err
+	}
+	tmp := w.url.Path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, w.url.Path)
+}
+
+//URL implements accounts.Wallet
+func (w *Wallet) URL() accounts.URL {
+	return w.url
+}
+
+//Status implements accounts.Wallet, returning whether the seed is decrypted
+func (w *Wallet) Status() (string, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.master != nil {
+		return "Open", nil
+	}
+	return "Closed", nil
+}
+
+//Open implements accounts.Wallet, decrypting the seed with passphrase
+func (w *Wallet) Open(passphrase string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.master != nil {
+		return nil
+	}
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return err
+	}
+	w.master = master
+	return nil
+}
+
+func (w *Wallet) decrypt(passphrase string) (*extendedKey, error) {
+	seed, err := keystore.DecryptDataV3(w.file.Crypto, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroBytes(seed)
+	return newMasterKey(seed)
+}
+
+//Close implements accounts.Wallet, forgetting the decrypted seed
+func (w *Wallet) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.master != nil {
+		w.master.zero()
+		w.master = nil
+	}
+	return nil
+}
+
+//Accounts implements accounts.Wallet, returning the pinned accounts
+func (w *Wallet) Accounts() []accounts.Account {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	res := make([]accounts.Account, len(w.file.Accounts))
+	for i, a := range w.file.Accounts {
+		res[i] = accounts.Account{Address: a.Address, URL: w.url}
+	}
+	return res
+}
+
+//Contains implements accounts.Wallet
+func (w *Wallet) Contains(account accounts.Account) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	_, ok := w.paths[account.Address]
+	return ok && (account.URL == (accounts.URL{}) || account.URL == w.url)
+}
+
+//Derive implements accounts.Wallet, deriving the account of path from the
+//open wallet. Pinned accounts are saved in the wallet file.
+func (w *Wallet) Derive(path accounts.DerivationPath, pin bool) (accounts.Account, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.master == nil {
+		return accounts.Account{}, ErrWalletClosed
+	}
+	key, err := w.privateKey(w.master, path)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	account := accounts.Account{Address: crypto.PubkeyToAddress(key.PublicKey), URL: w.url}
+	if _, ok := w.paths[account.Address]; pin && !ok {
+		w.paths[account.Address] = path
+		w.file.Accounts = append(w.file.Accounts, accountJSON{Address: account.Address, Path: path.String()})
+		if err := w.save(); err != nil {
+			return accounts.Account{}, err
+		}
+	}
+	return account, nil
+}
+
+func (w *Wallet) privateKey(master *extendedKey, path accounts.DerivationPath) (*ecdsa.PrivateKey, error) {
+	key, err := master.derive(path)
+	if err != nil {
+		return nil, err
+	}
+	defer key.zero()
+	return key.privateKey()
+}
+
+//accountKey returns the private key of a pinned account
+func (w *Wallet) accountKey(master *extendedKey, account accounts.Account) (*ecdsa.PrivateKey, error) {
+	if account.URL != (accounts.URL{}) && account.URL != w.url {
+		return nil, accounts.ErrUnknownAccount
+	}
+	path, ok := w.paths[account.Address]
+	if !ok {
+		return nil, accounts.ErrUnknownAccount
+	}
+	return w.privateKey(master, path)
+}
+
+//openKey returns the private key of account from the open wallet
+func (w *Wallet) openKey(account accounts.Account) (*ecdsa.PrivateKey, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	if w.master == nil {
+		return nil, accounts.NewAuthNeededError("password to open the hd wallet")
+	}
+	return w.accountKey(w.master, account)
+}
+
+//passphraseKey returns the private key of account, decrypting the seed with
+//passphrase without opening the wallet
+func (w *Wallet) passphraseKey(account accounts.Account, passphrase string) (*ecdsa.PrivateKey, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	master, err := w.decrypt(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer master.zero()
+	return w.accountKey(master, account)
+}
+
+//SignHash implements accounts.Wallet
+func (w *Wallet) SignHash(account accounts.Account, hash []byte) ([]byte, error) {
+	key, err := w.openKey(account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+//SignTx implements accounts.Wallet
+func (w *Wallet) SignTx(account accounts.Account, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.openKey(account)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return types.SignTx(tx, types.MakeSigner(chainID), key)
+}
+
+//SignHashWithPassphrase implements accounts.Wallet
+func (w *Wallet) SignHashWithPassphrase(account accounts.Account, passphrase string, hash []byte) ([]byte, error) {
+	key, err := w.passphraseKey(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return crypto.Sign(hash, key)
+}
+
+//SignTxWithPassphrase implements accounts.Wallet
+func (w *Wallet) SignTxWithPassphrase(account accounts.Account, passphrase string, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	key, err := w.passphraseKey(account, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key)
+	return types.SignTx(tx, types.MakeSigner(chainID), key)
+}
+
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+func zeroKey(k *ecdsa.PrivateKey) {
+	b := k.D.Bits()
+	for i := range b {
+		b[i] = 0
+	}
+}
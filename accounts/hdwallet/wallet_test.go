@@ -0,0 +1,167 @@
+package hdwallet
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+)
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+//testAddress is the account of testMnemonic at DefaultBaseDerivationPath
+var testAddress = common.HexToAddress("0x9858EfFD232B4033E47d90003D41EC34EcaEda94")
+
+func newTestHub(t *testing.T) (*Hub, string) {
+	dir, err := ioutil.TempDir("", "paradigm-hdwallet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub, err := NewHubWithScrypt(dir, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return hub, dir
+}
+
+func TestHubPersistence(t *testing.T) {
+	hub, dir := newTestHub(t)
+	defer os.RemoveAll(dir)
+
+	events := make(chan accounts.WalletEvent, 1)
+	sub := hub.Subscribe(events)
+	defer sub.Unsubscribe()
+
+	w, err := hub.Restore(testMnemonic, "pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev := <-events; ev.Wallet != w || ev.Kind != accounts.WalletArrived {
+		t.Fatalf("event %+v", ev)
+	}
+	if status, _ := w.Status(); status != "Open" {
+		t.Fatalf("restored wallet %s", status)
+	}
+	if accs := w.Accounts(); len(accs) != 1 || accs[0].Address != testAddress {
+		t.Fatalf("accounts %v, expected %s", accs, testAddress.Hex())
+	}
+	if _, err := hub.Restore(testMnemonic, "other"); err == nil {
+		t.Fatal("restored a wallet twice")
+	}
+
+	//only the pinned accounts are saved
+	second := append(accounts.DerivationPath{}, accounts.DefaultBaseDerivationPath...)
+	second[len(second)-1] = 1
+	third := append(accounts.DerivationPath{}, accounts.DefaultBaseDerivationPath...)
+	third[len(third)-1] = 2
+	pinned, err := w.Derive(second, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	unpinned, err := w.Derive(third, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !w.Contains(pinned) || w.Contains(unpinned) {
+		t.Fatal("pinned the wrong accounts")
+	}
+	//deriving an account twice pins it once
+	if _, err := w.Derive(second, true); err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := NewHubWithScrypt(dir, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallets := reloaded.Wallets()
+	if len(wallets) != 1 || wallets[0].URL() != w.URL() {
+		t.Fatalf("reloaded wallets %v", wallets)
+	}
+	accs := wallets[0].Accounts()
+	if len(accs) != 2 || accs[0].Address != testAddress || accs[1].Address != pinned.Address {
+		t.Fatalf("reloaded accounts %v", accs)
+	}
+	if status, _ := wallets[0].Status(); status != "Closed" {
+		t.Fatalf("reloaded wallet %s", status)
+	}
+	fi, err := os.Stat(w.URL().Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("wallet file mode %v", fi.Mode().Perm())
+	}
+}
+
+func TestWalletOpenAndSign(t *testing.T) {
+	hub, dir := newTestHub(t)
+	defer os.RemoveAll(dir)
+	if _, err := hub.Restore(testMnemonic, "pwd"); err != nil {
+		t.Fatal(err)
+	}
+	reloaded, err := NewHubWithScrypt(dir, keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := reloaded.Wallets()[0]
+	account := accounts.Account{Address: testAddress}
+	hash := crypto.Keccak256([]byte("message"))
+
+	//closed
+	if _, err := w.SignHash(account, hash); err == nil {
+		t.Fatal("signed with a closed wallet")
+	} else if _, ok := err.(*accounts.AuthNeededError); !ok {
+		t.Fatalf("closed wallet: %v", err)
+	}
+	if _, err := w.Derive(accounts.DefaultBaseDerivationPath, false); err != ErrWalletClosed {
+		t.Fatalf("derived from a closed wallet: %v", err)
+	}
+	sig, err := w.SignHashWithPassphrase(account, "pwd", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkSigner(t, hash, sig, testAddress)
+	if _, err := w.SignHashWithPassphrase(account, "wrong", hash); err == nil {
+		t.Fatal("signed with a wrong passphrase")
+	}
+
+	if err := w.Open("wrong"); err == nil {
+		t.Fatal("opened with a wrong passphrase")
+	}
+	if err := w.Open("pwd"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := w.Status(); status != "Open" {
+		t.Fatalf("opened wallet %s", status)
+	}
+	if sig, err = w.SignHash(account, hash); err != nil {
+		t.Fatal(err)
+	}
+	checkSigner(t, hash, sig, testAddress)
+	if _, err := w.SignHash(accounts.Account{Address: common.HexToAddress("0x01")}, hash); err != accounts.ErrUnknownAccount {
+		t.Fatalf("signed with an unknown account: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.SignHash(account, hash); err == nil {
+		t.Fatal("signed after closing")
+	}
+}
+
+func checkSigner(t *testing.T, hash, sig []byte, expected common.Address) {
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if addr := crypto.PubkeyToAddress(*pub); addr != expected {
+		t.Fatalf("signed by %s, expected %s", addr.Hex(), expected.Hex())
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/paradigm-network/paradigm/common"
 	"github.com/pborman/uuid"
 	"crypto/ecdsa"
+	crand "crypto/rand"
 	"os"
 	"path/filepath"
 	"io/ioutil"
@@ -41,27 +42,27 @@ type Key struct {
 
 type encryptedKeyJSONV1 struct {
 	Address string     `json:"address"`
-	Crypto  cryptoJSON `json:"crypto"`
+	Crypto  CryptoJSON `json:"crypto"`
 	Id      string     `json:"id"`
 	Version string     `json:"version"`
 }
 
-type cryptoJSON struct {
+type CryptoJSON struct {
 	Cipher       string                 `json:"cipher"`
 	CipherText   string                 `json:"ciphertext"`
-	CipherParams cipherparamsJSON       `json:"cipherparams"`
+	CipherParams CipherparamsJSON       `json:"cipherparams"`
 	KDF          string                 `json:"kdf"`
 	KDFParams    map[string]interface{} `json:"kdfparams"`
 	MAC          string                 `json:"mac"`
 }
 
-type cipherparamsJSON struct {
+type CipherparamsJSON struct {
 	IV string `json:"iv"`
 }
 
 type encryptedKeyJSONV3 struct {
 	Address string     `json:"address"`
-	Crypto  cryptoJSON `json:"crypto"`
+	Crypto  CryptoJSON `json:"crypto"`
 	Id      string     `json:"id"`
 	Version int        `json:"version"`
 }
@@ -109,6 +110,11 @@ func newKey(rand io.Reader) (*Key, error) {
 	return newKeyFromECDSA(privateKeyECDSA), nil
 }
 
+// NewKey generates a new key with a random id.
+func NewKey() (*Key, error) {
+	return newKey(crand.Reader)
+}
+
 func newKeyFromECDSA(privateKeyECDSA *ecdsa.PrivateKey) *Key {
 	id := uuid.NewRandom()
 	key := &Key{
@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"errors"
 	"crypto/ecdsa"
+	crand "crypto/rand"
 	"runtime"
 	"github.com/paradigm-network/paradigm/common/crypto"
 	"math/big"
@@ -21,6 +22,8 @@ var (
 	ErrLocked  = accounts.NewAuthNeededError("password or unlock")
 	ErrNoMatch = errors.New("no key for given address or file")
 	ErrDecrypt = errors.New("could not decrypt key with given passphrase")
+
+	ErrAccountAlreadyExists = errors.New("account already exists")
 )
 
 // KeyStoreScheme is the protocol scheme prefixing account and wallet URLs.
@@ -55,6 +58,15 @@ func NewKeyStore(keydir string) *KeyStore {
 	return ks
 }
 
+// NewKeyStoreWithScrypt creates a keystore for the given directory, encrypting
+// the new keys with the given scrypt parameters.
+func NewKeyStoreWithScrypt(keydir string, scryptN, scryptP int) *KeyStore {
+	keydir, _ = filepath.Abs(keydir)
+	ks := &KeyStore{storage: &keyStorePassphrase{keydir, scryptN, scryptP}}
+	ks.init(keydir)
+	return ks
+}
+
 func (ks *KeyStore) init(keydir string) {
 	// Lock the mutex since the account cache might call back with events
 	ks.mu.Lock()
@@ -111,11 +123,8 @@ func (ks *KeyStore) SignTx(a accounts.Account, tx *types.Transaction, chainID *b
 	if !found {
 		return nil, ErrLocked
 	}
-	// Depending on the presence of the chain ID, sign with EIP155 or homestead
-	if chainID != nil {
-		return types.SignTx(tx, types.NewBasicSigner(), unlockedKey.PrivateKey)
-	}
-	return types.SignTx(tx, types.NewBasicSigner(), unlockedKey.PrivateKey)
+	// Depending on the presence of the chain ID, sign with EIP155 or without replay protection
+	return types.SignTx(tx, types.MakeSigner(chainID), unlockedKey.PrivateKey)
 }
 
 // SignHashWithPassphrase signs hash if the private key matching the given address
@@ -157,11 +166,8 @@ func (ks *KeyStore) SignTxWithPassphrase(a accounts.Account, passphrase string,
 	}
 	defer zeroKey(key.PrivateKey)
 
-	// Depending on the presence of the chain ID, sign with EIP155 or homestead
-	if chainID != nil {
-		return types.SignTx(tx, types.NewBasicSigner(), key.PrivateKey)
-	}
-	return types.SignTx(tx, types.NewBasicSigner(), key.PrivateKey)
+	// Depending on the presence of the chain ID, sign with EIP155 or without replay protection
+	return types.SignTx(tx, types.MakeSigner(chainID), key.PrivateKey)
 }
 // Accounts returns all key files present in the directory.
 func (ks *KeyStore) Accounts() []accounts.Account {
@@ -240,4 +246,72 @@ func (ks *KeyStore) expire(addr common.Address, u *unlocked, timeout time.Durati
 		}
 		ks.mu.Unlock()
 	}
-}
\ No newline at end of file
+}
+
+// NewAccount generates a new key and stores it into the key directory,
+// encrypting it with the passphrase.
+func (ks *KeyStore) NewAccount(passphrase string) (accounts.Account, error) {
+	_, account, err := storeNewKey(ks.storage, crand.Reader, passphrase)
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	// Add the account to the cache immediately rather
+	// than waiting for file system notifications to pick it up.
+	ks.cache.add(account)
+	return account, nil
+}
+
+// Export exports as a JSON key, encrypted with newPassphrase.
+func (ks *KeyStore) Export(a accounts.Account, passphrase, newPassphrase string) (keyJSON []byte, err error) {
+	_, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer zeroKey(key.PrivateKey)
+	var N, P int
+	if store, ok := ks.storage.(*keyStorePassphrase); ok {
+		N, P = store.scryptN, store.scryptP
+	} else {
+		N, P = StandardScryptN, StandardScryptP
+	}
+	return EncryptKey(key, newPassphrase, N, P)
+}
+
+// Import stores the given encrypted JSON key into the key directory.
+func (ks *KeyStore) Import(keyJSON []byte, passphrase, newPassphrase string) (accounts.Account, error) {
+	key, err := DecryptKey(keyJSON, passphrase)
+	if key != nil && key.PrivateKey != nil {
+		defer zeroKey(key.PrivateKey)
+	}
+	if err != nil {
+		return accounts.Account{}, err
+	}
+	return ks.importKey(key, newPassphrase)
+}
+
+// ImportECDSA stores the given key into the key directory, encrypting it with the passphrase.
+func (ks *KeyStore) ImportECDSA(priv *ecdsa.PrivateKey, passphrase string) (accounts.Account, error) {
+	return ks.importKey(newKeyFromECDSA(priv), passphrase)
+}
+
+func (ks *KeyStore) importKey(key *Key, passphrase string) (accounts.Account, error) {
+	if ks.cache.hasAddress(key.Address) {
+		return accounts.Account{}, ErrAccountAlreadyExists
+	}
+	a := accounts.Account{Address: key.Address, URL: accounts.URL{Scheme: KeyStoreScheme, Path: ks.storage.JoinPath(keyFileName(key.Address))}}
+	if err := ks.storage.StoreKey(a.URL.Path, key, passphrase); err != nil {
+		return accounts.Account{}, err
+	}
+	ks.cache.add(a)
+	return a, nil
+}
+
+// Update changes the passphrase of an existing account.
+func (ks *KeyStore) Update(a accounts.Account, passphrase, newPassphrase string) error {
+	a, key, err := ks.getDecryptedKey(a, passphrase)
+	if err != nil {
+		return err
+	}
+	defer zeroKey(key.PrivateKey)
+	return ks.storage.StoreKey(a.URL.Path, key, newPassphrase)
+}
@@ -0,0 +1,173 @@
+package keystore
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/common/crypto"
+)
+
+func tmpKeyStore(t *testing.T) (string, *KeyStore) {
+	dir, err := ioutil.TempDir("", "paradigm-keystore")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir, NewKeyStoreWithScrypt(dir, veryLightScryptN, veryLightScryptP)
+}
+
+func TestNewKeyStoreWithScrypt(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := ioutil.ReadFile(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the key is encrypted with the scrypt parameters of the keystore
+	var k struct {
+		Crypto CryptoJSON `json:"crypto"`
+	}
+	if err := json.Unmarshal(keyJSON, &k); err != nil {
+		t.Fatal(err)
+	}
+	if n := ensureInt(k.Crypto.KDFParams["n"]); n != veryLightScryptN {
+		t.Fatalf("scrypt n %d, expected %d", n, veryLightScryptN)
+	}
+	if p := ensureInt(k.Crypto.KDFParams["p"]); p != veryLightScryptP {
+		t.Fatalf("scrypt p %d, expected %d", p, veryLightScryptP)
+	}
+}
+
+func TestNewAccount(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.URL.Scheme != KeyStoreScheme {
+		t.Fatalf("account URL %s", a.URL)
+	}
+	fi, err := os.Stat(a.URL.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("key file mode %v", fi.Mode().Perm())
+	}
+	if accs := ks.Accounts(); len(accs) != 1 || accs[0] != a {
+		t.Fatalf("accounts %v, expected %v", accs, a)
+	}
+	if _, err := ks.Find(accounts.Account{Address: a.Address}); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(a, "wrong"); err != ErrDecrypt {
+		t.Fatalf("unlocked with a wrong passphrase: %v", err)
+	}
+	if err := ks.Unlock(a, "pwd"); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestExportImport(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+	otherDir, other := tmpKeyStore(t)
+	defer os.RemoveAll(otherDir)
+
+	a, err := ks.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ks.Export(a, "wrong", "export"); err != ErrDecrypt {
+		t.Fatalf("exported with a wrong passphrase: %v", err)
+	}
+	keyJSON, err := ks.Export(a, "pwd", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	//the export is encrypted with its own passphrase
+	if _, err := DecryptKey(keyJSON, "pwd"); err == nil {
+		t.Fatal("export decrypted with the keystore passphrase")
+	}
+
+	if _, err := other.Import(keyJSON, "wrong", "new"); err == nil {
+		t.Fatal("imported with a wrong passphrase")
+	}
+	imported, err := other.Import(keyJSON, "export", "new")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if imported.Address != a.Address {
+		t.Fatalf("imported %s, expected %s", imported.Address.Hex(), a.Address.Hex())
+	}
+	if err := other.Unlock(imported, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := other.Import(keyJSON, "export", "new"); err != ErrAccountAlreadyExists {
+		t.Fatalf("imported an account twice: %v", err)
+	}
+}
+
+func TestImportECDSA(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := ks.ImportECDSA(key, "pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Address != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Fatalf("imported %s, expected %s", a.Address.Hex(), crypto.PubkeyToAddress(key.PublicKey).Hex())
+	}
+	if _, err := ks.ImportECDSA(key, "pwd"); err != ErrAccountAlreadyExists {
+		t.Fatalf("imported a key twice: %v", err)
+	}
+
+	hash := crypto.Keccak256([]byte("message"))
+	sig, err := ks.SignHashWithPassphrase(a, "pwd", hash)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := crypto.SigToPub(hash, sig)
+	if err != nil || crypto.PubkeyToAddress(*pub) != a.Address {
+		t.Fatalf("signature of another key: %v", err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	dir, ks := tmpKeyStore(t)
+	defer os.RemoveAll(dir)
+
+	a, err := ks.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Update(a, "wrong", "new"); err != ErrDecrypt {
+		t.Fatalf("updated with a wrong passphrase: %v", err)
+	}
+	if err := ks.Update(a, "pwd", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(a, "pwd"); err != ErrDecrypt {
+		t.Fatalf("unlocked with the old passphrase: %v", err)
+	}
+	if err := ks.Unlock(a, "new"); err != nil {
+		t.Fatal(err)
+	}
+	if accs := ks.Accounts(); len(accs) != 1 {
+		t.Fatalf("%d accounts after the update", len(accs))
+	}
+}
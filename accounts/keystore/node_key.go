@@ -0,0 +1,70 @@
+package keystore
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/pborman/uuid"
+)
+
+//encryptedNodeKeyJSON is the key file of an ed25519 node identity. The
+//secp256k1 identities keep the format of the account keys.
+type encryptedNodeKeyJSON struct {
+	KeyType string     `json:"keyType"`
+	Crypto  CryptoJSON `json:"crypto"`
+	Id      string     `json:"id"`
+	Version int        `json:"version"`
+}
+
+//EncryptNodeKey encrypts a node identity key of either type into a json blob
+func EncryptNodeKey(key crypto.PrivKey, auth string, scryptN, scryptP int) ([]byte, error) {
+	switch k := key.(type) {
+	case crypto.Secp256k1PrivKey:
+		return EncryptKey(newKeyFromECDSA(k.PrivateKey), auth, scryptN, scryptP)
+	case crypto.Ed25519PrivKey:
+		c, err := EncryptDataV3(k.Seed(), []byte(auth), scryptN, scryptP)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(encryptedNodeKeyJSON{
+			KeyType: crypto.Ed25519.String(),
+			Crypto:  c,
+			Id:      uuid.NewRandom().String(),
+			Version: version,
+		})
+	}
+	return nil, fmt.Errorf("unsupported node key %T", key)
+}
+
+//DecryptNodeKey decrypts a node identity key of either type
+func DecryptNodeKey(keyjson []byte, auth string) (crypto.PrivKey, error) {
+	var t struct {
+		KeyType string `json:"keyType"`
+	}
+	if err := json.Unmarshal(keyjson, &t); err != nil {
+		return nil, err
+	}
+	if t.KeyType == "" || t.KeyType == crypto.Secp256k1.String() {
+		key, err := DecryptKey(keyjson, auth)
+		if err != nil {
+			return nil, err
+		}
+		return crypto.Secp256k1PrivKey{PrivateKey: key.PrivateKey}, nil
+	}
+	if t.KeyType != crypto.Ed25519.String() {
+		return nil, fmt.Errorf("unsupported node key type %q", t.KeyType)
+	}
+	var k encryptedNodeKeyJSON
+	if err := json.Unmarshal(keyjson, &k); err != nil {
+		return nil, err
+	}
+	if k.Version != version {
+		return nil, fmt.Errorf("Version not supported: %v", k.Version)
+	}
+	seed, err := DecryptDataV3(k.Crypto, auth)
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Ed25519FromSeed(seed)
+}
@@ -0,0 +1,83 @@
+package keystore
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common/crypto"
+)
+
+func TestNodeKeyRoundTrip(t *testing.T) {
+	ecdsaKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	edKey, err := crypto.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := crypto.Keccak256([]byte("comet"))
+
+	for _, key := range []crypto.PrivKey{crypto.Secp256k1PrivKey{PrivateKey: ecdsaKey}, edKey} {
+		keyJSON, err := EncryptNodeKey(key, "pwd", veryLightScryptN, veryLightScryptP)
+		if err != nil {
+			t.Fatalf("%s: %s", key.Type(), err)
+		}
+		if _, err := DecryptNodeKey(keyJSON, "wrong"); err != ErrDecrypt {
+			t.Fatalf("%s: decrypted with a wrong passphrase: %v", key.Type(), err)
+		}
+		decrypted, err := DecryptNodeKey(keyJSON, "pwd")
+		if err != nil {
+			t.Fatalf("%s: %s", key.Type(), err)
+		}
+		if decrypted.Type() != key.Type() || !bytes.Equal(decrypted.PubKey(), key.PubKey()) {
+			t.Fatalf("%s: decrypted a %s key %x, expected %x", key.Type(), decrypted.Type(), decrypted.PubKey(), key.PubKey())
+		}
+		sig, err := decrypted.Sign(hash)
+		if err != nil {
+			t.Fatalf("%s: %s", key.Type(), err)
+		}
+		if ok, err := crypto.VerifySignature(key.PubKey(), hash, sig); !ok || err != nil {
+			t.Fatalf("%s: signature of the decrypted key rejected: %v", key.Type(), err)
+		}
+	}
+
+	//the secp256k1 keys keep the format of the account keys
+	keyJSON, err := EncryptNodeKey(crypto.Secp256k1PrivKey{PrivateKey: ecdsaKey}, "pwd", veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	key, err := DecryptKey(keyJSON, "pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key.Address != crypto.PubkeyToAddress(ecdsaKey.PublicKey) {
+		t.Fatalf("account key of %s, expected %s", key.Address.Hex(), crypto.PubkeyToAddress(ecdsaKey.PublicKey).Hex())
+	}
+}
+
+func TestDecryptNodeKeyType(t *testing.T) {
+	key, err := crypto.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := EncryptNodeKey(key, "pwd", veryLightScryptN, veryLightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var k map[string]interface{}
+	if err := json.Unmarshal(keyJSON, &k); err != nil {
+		t.Fatal(err)
+	}
+	if k["keyType"] != crypto.Ed25519.String() {
+		t.Fatalf("key type %v", k["keyType"])
+	}
+	k["keyType"] = "rsa"
+	if keyJSON, err = json.Marshal(k); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecryptNodeKey(keyJSON, "pwd"); err == nil {
+		t.Fatal("decrypted a key of an unknown type")
+	}
+}
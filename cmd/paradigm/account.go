@@ -0,0 +1,300 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	PasswordFileFlag = cli.StringFlag{
+		Name:  "password",
+		Usage: "File containing the passphrases, one per line. They are prompted for when it is not given",
+	}
+	LightKDFFlag = cli.BoolFlag{
+		Name:  "lightkdf",
+		Usage: "Encrypt the keys with a lighter scrypt, faster but weaker",
+	}
+)
+
+var accountFlags = []cli.Flag{KeyStorePathFlag, PasswordFileFlag, LightKDFFlag}
+
+//accountCommand manages the accounts of the keystore served by the node, in
+//the keystore directory under keystore_path. Secrets are never echoed:
+//passphrases are read from a file or from the terminal with echo off, and
+//private keys are only read from files.
+var accountCommand = cli.Command{
+	Name:  "account",
+	Usage: "Manage the accounts of the keystore",
+	Subcommands: []cli.Command{
+		{
+			Name:   "new",
+			Usage:  "Create a new account",
+			Action: accountNew,
+			Flags:  accountFlags,
+		},
+		{
+			Name:   "list",
+			Usage:  "List the accounts",
+			Action: accountList,
+			Flags:  accountFlags,
+		},
+		{
+			Name:      "import",
+			Usage:     "Import a private key, hex encoded or as an encrypted JSON key",
+			ArgsUsage: "<keyfile>",
+			Action:    accountImport,
+			Flags:     accountFlags,
+			Description: `The key file holds either a hex encoded private key or an encrypted JSON
+key. A JSON key is decrypted with the first passphrase of the password file
+and encrypted with the second one.`,
+		},
+		{
+			Name:      "export",
+			Usage:     "Export an account as an encrypted JSON key",
+			ArgsUsage: "<address>",
+			Action:    accountExport,
+			Flags:     append(accountFlags, OutputFlag),
+			Description: `The key is decrypted with the first passphrase of the password file and
+encrypted with the second one, or the first one when there is no second one.`,
+		},
+		{
+			Name:      "update",
+			Usage:     "Change the passphrase of an account",
+			ArgsUsage: "<address>",
+			Action:    accountUpdate,
+			Flags:     accountFlags,
+			Description: `The key is decrypted with the first passphrase of the password file and
+encrypted with the second one.`,
+		},
+		hdCommand,
+	},
+}
+
+func accountNew(c *cli.Context) error {
+	ks := accountKeyStore(c)
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase("Passphrase of the new account: ", true, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	account, err := ks.NewAccount(pwd)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to create account: %s", err), 1)
+	}
+	fmt.Printf("Address: {%x}\n", account.Address)
+	fmt.Printf("Key file: %s\n", account.URL.Path)
+	return nil
+}
+
+func accountList(c *cli.Context) error {
+	for i, account := range accountKeyStore(c).Accounts() {
+		fmt.Printf("Account #%d: {%x} %s://%s\n", i, account.Address, account.URL.Scheme, account.URL.Path)
+	}
+	return nil
+}
+
+func accountImport(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("the key file is required", 1)
+	}
+	data, err := ioutil.ReadFile(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	ks := accountKeyStore(c)
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var account accounts.Account
+	if json.Valid(data) {
+		pwd, err := getPassphrase("Passphrase of the key: ", false, 0, passwords)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		newPwd, err := getPassphrase("Passphrase of the imported account: ", true, 1, passwords)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		account, err = ks.Import(data, pwd, newPwd)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("failed to import key: %s", err), 1)
+		}
+	} else {
+		key, err := crypto.HexToECDSA(strings.TrimPrefix(strings.TrimSpace(string(data)), "0x"))
+		if err != nil {
+			return cli.NewExitError("the key file holds neither a hex key nor a JSON key", 1)
+		}
+		pwd, err := getPassphrase("Passphrase of the imported account: ", true, 0, passwords)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		account, err = ks.ImportECDSA(key, pwd)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("failed to import key: %s", err), 1)
+		}
+	}
+	fmt.Printf("Address: {%x}\n", account.Address)
+	return nil
+}
+
+func accountExport(c *cli.Context) error {
+	ks := accountKeyStore(c)
+	account, err := findAccount(c, ks)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase("Passphrase of the account: ", false, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	newPwd := pwd
+	if passwords == nil || len(passwords) > 1 {
+		newPwd, err = getPassphrase("Passphrase of the exported key: ", true, 1, passwords)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+	keyJSON, err := ks.Export(account, pwd, newPwd)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to export key: %s", err), 1)
+	}
+
+	path := c.String(OutputFlag.Name)
+	if path == "" {
+		fmt.Println(string(keyJSON))
+		return nil
+	}
+	if err := ioutil.WriteFile(path, keyJSON, 0600); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
+
+func accountUpdate(c *cli.Context) error {
+	ks := accountKeyStore(c)
+	account, err := findAccount(c, ks)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase("Current passphrase: ", false, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	newPwd, err := getPassphrase("New passphrase: ", true, 1, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := ks.Update(account, pwd, newPwd); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to update account: %s", err), 1)
+	}
+	return nil
+}
+
+//accountKeyStore opens the keystore the proxy serves the accounts from
+func accountKeyStore(c *cli.Context) *keystore.KeyStore {
+	dir := filepath.Join(c.String(KeyStorePathFlag.Name), "keystore")
+	os.MkdirAll(dir, 0700)
+	if c.Bool(LightKDFFlag.Name) {
+		return keystore.NewKeyStoreWithScrypt(dir, keystore.LightScryptN, keystore.LightScryptP)
+	}
+	return keystore.NewKeyStore(dir)
+}
+
+func findAccount(c *cli.Context, ks *keystore.KeyStore) (accounts.Account, error) {
+	if c.NArg() != 1 || !common.IsHexAddress(c.Args().First()) {
+		return accounts.Account{}, fmt.Errorf("an account address is required")
+	}
+	return ks.Find(accounts.Account{Address: common.HexToAddress(c.Args().First())})
+}
+
+//readPasswordFile returns the lines of the password file, nil without one
+func readPasswordFile(c *cli.Context) ([]string, error) {
+	path := c.String(PasswordFileFlag.Name)
+	if path == "" {
+		return nil, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the password file: %s", err)
+	}
+	lines := strings.Split(strings.TrimRight(string(data), "\r\n"), "\n")
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines, nil
+}
+
+//getPassphrase returns the index-th line of the password file, or prompts for
+//the passphrase when there is no password file. New passphrases are prompted
+//for twice.
+func getPassphrase(prompt string, confirm bool, index int, passwords []string) (string, error) {
+	if passwords != nil {
+		if index >= len(passwords) {
+			return "", fmt.Errorf("the password file should hold %d passphrases", index+1)
+		}
+		return passwords[index], nil
+	}
+	pwd, err := promptPassphrase(prompt)
+	if err != nil {
+		return "", err
+	}
+	if confirm {
+		again, err := promptPassphrase("Repeat the passphrase: ")
+		if err != nil {
+			return "", err
+		}
+		if pwd != again {
+			return "", fmt.Errorf("the passphrases do not match")
+		}
+	}
+	return pwd, nil
+}
+
+//stdin is shared by the prompts, a reader per prompt would lose the lines
+//buffered by the previous ones
+var stdin = bufio.NewReader(os.Stdin)
+
+//promptPassphrase reads a passphrase from the terminal without echoing it, or
+//a line from the standard input when it is not a terminal
+func promptPassphrase(prompt string) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !terminal.IsTerminal(fd) {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read the passphrase: %s", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	pwd, err := terminal.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the passphrase: %s", err)
+	}
+	return string(pwd), nil
+}
@@ -0,0 +1,429 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/network/http/base/rpc"
+	"github.com/paradigm-network/paradigm/proxy"
+	"github.com/paradigm-network/paradigm/types"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	JSONOutputFlag = cli.BoolFlag{
+		Name:  "json",
+		Usage: "Print JSON instead of human-readable output",
+	}
+	FromFlag = cli.StringFlag{
+		Name:  "from",
+		Usage: "Address of the keystore account sending the transaction",
+	}
+	ToFlag = cli.StringFlag{
+		Name:  "to",
+		Usage: "Address of the recipient, none to create a contract",
+	}
+	ValueFlag = cli.StringFlag{
+		Name:  "value",
+		Usage: "Wei sent, in decimal or 0x prefixed hex",
+		Value: "0",
+	}
+	GasFlag = cli.Uint64Flag{
+		Name:  "gas",
+		Usage: "Gas limit of the transaction",
+		Value: 90000,
+	}
+	GasPriceFlag = cli.StringFlag{
+		Name:  "gas_price",
+		Usage: "Gas price in wei",
+		Value: "0",
+	}
+	DataFlag = cli.StringFlag{
+		Name:  "data",
+		Usage: "0x prefixed hex data of the transaction",
+	}
+	NonceFlag = cli.StringFlag{
+		Name:  "nonce",
+		Usage: "Nonce of the transaction. Defaults to the pending nonce of the sender",
+	}
+)
+
+//clientFlags are the flags of the commands talking to a running node: the
+//proxy API on seq_address and the JSON-RPC server of the node on rpc_addr
+var clientFlags = []cli.Flag{
+	SequentiaAddress,
+	RpcAddr,
+	JSONOutputFlag,
+}
+
+//clientCommands are run one-shot or from the console of attach
+var clientCommands = []cli.Command{
+	{
+		Name:      "balance",
+		Usage:     "Print the balance of an account",
+		ArgsUsage: "<address>",
+		Action:    clientBalance,
+		Flags:     clientFlags,
+	},
+	{
+		Name:      "nonce",
+		Usage:     "Print the nonce of an account, committed and pending",
+		ArgsUsage: "<address>",
+		Action:    clientNonce,
+		Flags:     clientFlags,
+	},
+	{
+		Name:   "send",
+		Usage:  "Sign a transaction with a keystore account and send it",
+		Action: clientSend,
+		Flags: append([]cli.Flag{
+			FromFlag,
+			ToFlag,
+			ValueFlag,
+			GasFlag,
+			GasPriceFlag,
+			DataFlag,
+			NonceFlag,
+			KeyStorePathFlag,
+			PasswordFileFlag,
+		}, clientFlags...),
+	},
+	{
+		Name:      "tx",
+		Usage:     "Print a transaction and its receipt",
+		ArgsUsage: "<hash>",
+		Action:    clientTx,
+		Flags:     clientFlags,
+	},
+	{
+		Name:      "block",
+		Usage:     "Print a block",
+		ArgsUsage: "<index>",
+		Action:    clientBlock,
+		Flags:     clientFlags,
+	},
+	{
+		Name:   "stats",
+		Usage:  "Print the stats of the node",
+		Action: clientStats,
+		Flags:  clientFlags,
+	},
+}
+
+//proxyRPC returns the client of the JSON-RPC endpoint of the proxy
+func proxyRPC(c *cli.Context) *rpc.Client {
+	return rpc.NewClient(fmt.Sprintf("http://%s/rpc", c.String(SequentiaAddress.Name)))
+}
+
+//nodeRPC returns the client of the JSON-RPC server of the node
+func nodeRPC(c *cli.Context) *rpc.Client {
+	return rpc.NewClient(fmt.Sprintf("http://%s/", c.String(RpcAddr.Name)))
+}
+
+//proxyGet decodes the JSON response of the proxy API to GET path
+func proxyGet(c *cli.Context, path string, res interface{}) error {
+	resp, err := http.Get(fmt.Sprintf("http://%s%s", c.String(SequentiaAddress.Name), path))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeProxyResponse(resp, res)
+}
+
+//proxyPost posts body to the proxy API and decodes its JSON response
+func proxyPost(c *cli.Context, path string, body []byte, res interface{}) error {
+	resp, err := http.Post(fmt.Sprintf("http://%s%s", c.String(SequentiaAddress.Name), path),
+		"application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return decodeProxyResponse(resp, res)
+}
+
+func decodeProxyResponse(resp *http.Response, res interface{}) error {
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, res)
+}
+
+//printOutput prints v as indented JSON with --json, or calls human
+func printOutput(c *cli.Context, v interface{}, human func()) error {
+	if !c.Bool(JSONOutputFlag.Name) {
+		human()
+		return nil
+	}
+	js, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(string(js))
+	return nil
+}
+
+//addressArg returns the address given as the only argument
+func addressArg(c *cli.Context) (common.Address, error) {
+	if c.NArg() != 1 || !common.IsHexAddress(c.Args().First()) {
+		return common.Address{}, fmt.Errorf("an account address is required")
+	}
+	return common.HexToAddress(c.Args().First()), nil
+}
+
+func clientBalance(c *cli.Context) error {
+	address, err := addressArg(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var account proxy.JsonAccount
+	if err := proxyGet(c, "/account/"+address.Hex(), &account); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return printOutput(c, account, func() {
+		fmt.Printf("Address: %s\n", address.Hex())
+		fmt.Printf("Balance: %s wei\n", account.Balance)
+	})
+}
+
+type jsonNonce struct {
+	Address common.Address `json:"address"`
+	Nonce   uint64         `json:"nonce"`
+	Pending uint64         `json:"pending"` //nonce of the next transaction, counting the ones not committed yet
+}
+
+func clientNonce(c *cli.Context) error {
+	address, err := addressArg(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var nonce, pending hexutil.Uint64
+	if err := proxyRPC(c).Call(&nonce, "eth_getTransactionCount", address, "latest"); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := proxyRPC(c).Call(&pending, "eth_getTransactionCount", address, "pending"); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	res := jsonNonce{Address: address, Nonce: uint64(nonce), Pending: uint64(pending)}
+	return printOutput(c, res, func() {
+		fmt.Printf("Address: %s\n", address.Hex())
+		fmt.Printf("Nonce: %d\n", res.Nonce)
+		fmt.Printf("Pending: %d\n", res.Pending)
+	})
+}
+
+func clientSend(c *cli.Context) error {
+	if !common.IsHexAddress(c.String(FromFlag.Name)) {
+		return cli.NewExitError("--from must be an account address", 1)
+	}
+	from := common.HexToAddress(c.String(FromFlag.Name))
+	value, ok := new(big.Int).SetString(c.String(ValueFlag.Name), 0)
+	if !ok {
+		return cli.NewExitError(fmt.Sprintf("invalid value %s", c.String(ValueFlag.Name)), 1)
+	}
+	gasPrice, ok := new(big.Int).SetString(c.String(GasPriceFlag.Name), 0)
+	if !ok {
+		return cli.NewExitError(fmt.Sprintf("invalid gas price %s", c.String(GasPriceFlag.Name)), 1)
+	}
+	var data []byte
+	if s := c.String(DataFlag.Name); s != "" {
+		var err error
+		if data, err = hexutil.Decode(s); err != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid data: %s", err), 1)
+		}
+	}
+
+	ks := accountKeyStore(c)
+	account, err := ks.Find(accounts.Account{Address: from})
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("%s: %s", from.Hex(), err), 1)
+	}
+
+	var nonce uint64
+	if s := c.String(NonceFlag.Name); s != "" {
+		if nonce, err = strconv.ParseUint(s, 0, 64); err != nil {
+			return cli.NewExitError(fmt.Sprintf("invalid nonce %s", s), 1)
+		}
+	} else {
+		var pending hexutil.Uint64
+		if err := proxyRPC(c).Call(&pending, "eth_getTransactionCount", from, "pending"); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		nonce = uint64(pending)
+	}
+	var chainID hexutil.Big
+	if err := proxyRPC(c).Call(&chainID, "eth_chainId"); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	gas := new(big.Int).SetUint64(c.Uint64(GasFlag.Name))
+	var tx *types.Transaction
+	if to := c.String(ToFlag.Name); to != "" {
+		if !common.IsHexAddress(to) {
+			return cli.NewExitError("--to must be an address", 1)
+		}
+		tx = types.NewTransaction(nonce, common.HexToAddress(to), value, gas, gasPrice, data)
+	} else {
+		tx = types.NewContractCreation(nonce, value, gas, gasPrice, data)
+	}
+
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase(fmt.Sprintf("Passphrase of %s: ", from.Hex()), false, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	signed, err := ks.SignTxWithPassphrase(account, pwd, tx, chainID.ToInt())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to sign the transaction: %s", err), 1)
+	}
+	raw, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var res proxy.JsonTxRes
+	if err := proxyPost(c, "/rawtx", []byte(hexutil.Encode(raw)), &res); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return printOutput(c, res, func() {
+		fmt.Printf("Transaction: %s\n", res.TxHash)
+		fmt.Printf("Nonce: %d\n", nonce)
+	})
+}
+
+type jsonTx struct {
+	Transaction *proxy.RPCTransaction `json:"transaction"`
+	Receipt     *proxy.RPCReceipt     `json:"receipt"` //null until the transaction is committed
+}
+
+func clientTx(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("a transaction hash is required", 1)
+	}
+	hash := common.HexToHash(c.Args().First())
+	var res jsonTx
+	if err := proxyRPC(c).Call(&res.Transaction, "eth_getTransactionByHash", hash); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if res.Transaction == nil {
+		return cli.NewExitError(fmt.Sprintf("unknown transaction %s", hash.Hex()), 1)
+	}
+	if err := proxyRPC(c).Call(&res.Receipt, "eth_getTransactionReceipt", hash); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return printOutput(c, res, func() {
+		t := res.Transaction
+		fmt.Printf("Hash: %s\n", t.Hash.Hex())
+		fmt.Printf("From: %s\n", t.From.Hex())
+		if t.To != nil {
+			fmt.Printf("To: %s\n", t.To.Hex())
+		} else {
+			fmt.Printf("To: contract creation\n")
+		}
+		fmt.Printf("Value: %s wei\n", t.Value.ToInt())
+		fmt.Printf("Nonce: %d\n", t.Nonce)
+		fmt.Printf("Gas: %s\n", t.Gas.ToInt())
+		fmt.Printf("Gas price: %s wei\n", t.GasPrice.ToInt())
+		r := res.Receipt
+		if r == nil {
+			fmt.Printf("Status: pending\n")
+			return
+		}
+		fmt.Printf("Block: %s, index %d\n", r.BlockNumber.ToInt(), r.TransactionIndex)
+		fmt.Printf("Status: %d\n", r.Status)
+		fmt.Printf("Gas used: %s\n", r.GasUsed.ToInt())
+		if r.ContractAddress != nil {
+			fmt.Printf("Contract: %s\n", r.ContractAddress.Hex())
+		}
+		fmt.Printf("Logs: %d\n", len(r.Logs))
+	})
+}
+
+type jsonBlock struct {
+	Index         int               `json:"index"`
+	RoundReceived int               `json:"roundReceived"`
+	StateHash     hexutil.Bytes     `json:"stateHash"`
+	Transactions  []common.Hash     `json:"transactions"`
+	Signatures    map[string]string `json:"signatures"` //[validator] => signature
+}
+
+func clientBlock(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return cli.NewExitError("a block index is required", 1)
+	}
+	index, err := strconv.Atoi(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("invalid block index %s", c.Args().First()), 1)
+	}
+	var block types.Block
+	if err := nodeRPC(c).Call(&block, "GetBlock", index); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	res := jsonBlock{
+		Index:         block.Index(),
+		RoundReceived: block.RoundReceived(),
+		StateHash:     block.StateHash(),
+		Transactions:  []common.Hash{},
+		Signatures:    block.Signatures,
+	}
+	for _, txBytes := range block.Transactions() {
+		var t types.Transaction
+		if err := rlp.Decode(bytes.NewReader(txBytes), &t); err != nil {
+			return cli.NewExitError(fmt.Sprintf("decoding a transaction of block %d: %s", index, err), 1)
+		}
+		res.Transactions = append(res.Transactions, t.Hash())
+	}
+	return printOutput(c, res, func() {
+		fmt.Printf("Index: %d\n", res.Index)
+		fmt.Printf("Round received: %d\n", res.RoundReceived)
+		fmt.Printf("State hash: %s\n", res.StateHash)
+		fmt.Printf("Transactions: %d\n", len(res.Transactions))
+		for _, h := range res.Transactions {
+			fmt.Printf("  %s\n", h.Hex())
+		}
+		fmt.Printf("Signatures: %d\n", len(res.Signatures))
+		validators := make([]string, 0, len(res.Signatures))
+		for v := range res.Signatures {
+			validators = append(validators, v)
+		}
+		sort.Strings(validators)
+		for _, v := range validators {
+			fmt.Printf("  %s\n", v)
+		}
+	})
+}
+
+func clientStats(c *cli.Context) error {
+	var stats map[string]string
+	if err := nodeRPC(c).Call(&stats, "GetStats"); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return printOutput(c, stats, func() {
+		keys := make([]string, 0, len(stats))
+		for k := range stats {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Printf("%-24s %s\n", k+":", stats[k])
+		}
+	})
+}
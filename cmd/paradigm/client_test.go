@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/network/http/base/rpc"
+	"github.com/paradigm-network/paradigm/proxy"
+	"github.com/paradigm-network/paradigm/types"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	testAccount = common.HexToAddress("0x1dcf3b5a1f8de1e7a82b3fbd7bb2f1e2d08a37a4")
+	testChainID = big.NewInt(7)
+)
+
+//testNode mocks the proxy API and the JSON-RPC server of a node
+type testNode struct {
+	proxy, rpc *httptest.Server
+	sent       []*types.Transaction //transactions posted to /rawtx
+}
+
+func newTestNode(t *testing.T) *testNode {
+	n := &testNode{}
+
+	eth := rpc.NewServer()
+	methods := map[string]interface{}{
+		"eth_getTransactionCount": func(address common.Address, tag string) (hexutil.Uint64, error) {
+			if tag == "pending" {
+				return 5, nil
+			}
+			return 3, nil
+		},
+		"eth_chainId": func() (*hexutil.Big, error) {
+			return (*hexutil.Big)(testChainID), nil
+		},
+		"eth_getTransactionByHash": func(hash common.Hash) (*proxy.RPCTransaction, error) {
+			if hash != common.HexToHash("0x01") {
+				return nil, nil
+			}
+			return &proxy.RPCTransaction{
+				Hash:     hash,
+				From:     testAccount,
+				Gas:      (*hexutil.Big)(big.NewInt(21000)),
+				GasPrice: (*hexutil.Big)(big.NewInt(1)),
+				Value:    (*hexutil.Big)(big.NewInt(10)),
+				Nonce:    2,
+			}, nil
+		},
+		"eth_getTransactionReceipt": func(hash common.Hash) (*proxy.RPCReceipt, error) {
+			return nil, nil
+		},
+	}
+	for name, fn := range methods {
+		if err := eth.Register(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := mux.NewRouter()
+	r.Handle("/rpc", eth).Methods("POST")
+	r.HandleFunc("/account/{address}", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(proxy.JsonAccount{
+			Address: mux.Vars(r)["address"],
+			Balance: big.NewInt(1000),
+			Nonce:   3,
+		})
+	}).Methods("GET")
+	r.HandleFunc("/rawtx", func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		tx := new(types.Transaction)
+		if err := rlp.DecodeBytes(common.FromHex(string(body)), tx); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		n.sent = append(n.sent, tx)
+		json.NewEncoder(w).Encode(proxy.JsonTxRes{TxHash: tx.Hash().Hex()})
+	}).Methods("POST")
+	n.proxy = httptest.NewServer(r)
+
+	node := rpc.NewServer()
+	node.Register("GetStats", func() (map[string]string, error) {
+		return map[string]string{"id": "1", "state": "Babbling", "last_block_index": "4"}, nil
+	})
+	node.Register("GetBlock", func(index int) (types.Block, error) {
+		return types.NewBlock(index, 9, [][]byte{}), nil
+	})
+	n.rpc = httptest.NewServer(node)
+	return n
+}
+
+func (n *testNode) Close() {
+	n.proxy.Close()
+	n.rpc.Close()
+}
+
+//flags returns the address flags of n
+func (n *testNode) flags() []string {
+	return []string{
+		"--" + SequentiaAddress.Name, strings.TrimPrefix(n.proxy.URL, "http://"),
+		"--" + RpcAddr.Name, strings.TrimPrefix(n.rpc.URL, "http://"),
+	}
+}
+
+//runCommand runs the command of args with the client commands and attach, and
+//returns what it printed
+func runCommand(t *testing.T, args ...string) string {
+	app := cli.NewApp()
+	app.Commands = append(clientCommands, attachCommand)
+	app.Writer = ioutil.Discard
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() {
+		data, _ := ioutil.ReadAll(r)
+		out <- string(data)
+	}()
+	err = app.Run(append([]string{"paradigm"}, args...))
+	os.Stdout = stdout
+	w.Close()
+	if err != nil {
+		t.Fatalf("%v: %s", args, err)
+	}
+	return <-out
+}
+
+func TestClientCommands(t *testing.T) {
+	n := newTestNode(t)
+	defer n.Close()
+	command := func(name string, args ...string) []string {
+		return append(append([]string{name, "--json"}, n.flags()...), args...)
+	}
+
+	var account proxy.JsonAccount
+	if err := json.Unmarshal([]byte(runCommand(t, command("balance", testAccount.Hex())...)), &account); err != nil {
+		t.Fatal(err)
+	}
+	if account.Address != testAccount.Hex() || account.Balance.Int64() != 1000 {
+		t.Fatalf("balance %+v", account)
+	}
+
+	var nonce jsonNonce
+	if err := json.Unmarshal([]byte(runCommand(t, command("nonce", testAccount.Hex())...)), &nonce); err != nil {
+		t.Fatal(err)
+	}
+	if nonce.Address != testAccount || nonce.Nonce != 3 || nonce.Pending != 5 {
+		t.Fatalf("nonce %+v", nonce)
+	}
+
+	var tx jsonTx
+	if err := json.Unmarshal([]byte(runCommand(t, command("tx", "0x01")...)), &tx); err != nil {
+		t.Fatal(err)
+	}
+	if tx.Transaction == nil || tx.Transaction.From != testAccount || tx.Receipt != nil {
+		t.Fatalf("tx %+v", tx)
+	}
+
+	var block jsonBlock
+	if err := json.Unmarshal([]byte(runCommand(t, command("block", "4")...)), &block); err != nil {
+		t.Fatal(err)
+	}
+	if block.Index != 4 || block.RoundReceived != 9 || len(block.Transactions) != 0 {
+		t.Fatalf("block %+v", block)
+	}
+
+	var stats map[string]string
+	if err := json.Unmarshal([]byte(runCommand(t, command("stats")...)), &stats); err != nil {
+		t.Fatal(err)
+	}
+	if stats["state"] != "Babbling" {
+		t.Fatalf("stats %v", stats)
+	}
+
+	//human readable output
+	if out := runCommand(t, append([]string{"balance"}, append(n.flags(), testAccount.Hex())...)...); !strings.Contains(out, "Balance: 1000 wei") {
+		t.Fatalf("balance output %q", out)
+	}
+}
+
+func TestClientSend(t *testing.T) {
+	n := newTestNode(t)
+	defer n.Close()
+
+	dir, err := ioutil.TempDir("", "paradigm-client")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ks := keystore.NewKeyStoreWithScrypt(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pwdFile := filepath.Join(dir, "pwd.txt")
+	if err := ioutil.WriteFile(pwdFile, []byte("pwd\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	to := common.HexToAddress("0x02")
+	args := append([]string{"send", "--json",
+		"--" + KeyStorePathFlag.Name, dir,
+		"--" + PasswordFileFlag.Name, pwdFile,
+		"--from", account.Address.Hex(),
+		"--to", to.Hex(),
+		"--value", "0x10",
+	}, n.flags()...)
+	var res proxy.JsonTxRes
+	if err := json.Unmarshal([]byte(runCommand(t, args...)), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(n.sent) != 1 {
+		t.Fatalf("%d transactions sent", len(n.sent))
+	}
+	tx := n.sent[0]
+	if res.TxHash != tx.Hash().Hex() {
+		t.Fatalf("hash %s, expected %s", res.TxHash, tx.Hash().Hex())
+	}
+	//the pending nonce by default
+	if tx.Nonce() != 5 || *tx.To() != to || tx.Value().Int64() != 16 {
+		t.Fatalf("tx nonce %d to %s value %s", tx.Nonce(), tx.To().Hex(), tx.Value())
+	}
+	sender, err := types.Sender(types.MakeSigner(testChainID), tx)
+	if err != nil || sender != account.Address {
+		t.Fatalf("sender %s, expected %s: %v", sender.Hex(), account.Address.Hex(), err)
+	}
+}
+
+func TestConsoleArgs(t *testing.T) {
+	app := cli.NewApp()
+	app.Commands = []cli.Command{attachCommand}
+	var got [][]string
+	app.Commands[0].Action = func(c *cli.Context) error {
+		for _, line := range []string{"balance 0x01", "send --from 0x01", "stats --json", "unknown a"} {
+			got = append(got, consoleArgs(c, strings.Fields(line)))
+		}
+		return nil
+	}
+	if err := app.Run([]string{"paradigm", "attach", "--seq_address", "host:1", "--rpc_addr", "host:2", "--keystore_path", "/ks"}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := [][]string{
+		{"", "balance", "--seq_address", "host:1", "--rpc_addr", "host:2", "0x01"},
+		{"", "send", "--seq_address", "host:1", "--rpc_addr", "host:2", "--keystore_path", "/ks", "--from", "0x01"},
+		{"", "stats", "--seq_address", "host:1", "--rpc_addr", "host:2", "--json"},
+		{"", "unknown", "a"},
+	}
+	for i := range expected {
+		if strings.Join(got[i], " ") != strings.Join(expected[i], " ") {
+			t.Errorf("line %d: args %q, expected %q", i, got[i], expected[i])
+		}
+	}
+}
+
+func TestHasFlag(t *testing.T) {
+	cmd := cli.Command{Flags: []cli.Flag{JSONOutputFlag, SequentiaAddress}}
+	if !hasFlag(cmd, SequentiaAddress.Name) || !hasFlag(cmd, JSONOutputFlag.Name) {
+		t.Fatal("flag not found")
+	}
+	if hasFlag(cmd, KeyStorePathFlag.Name) {
+		t.Fatal("found a missing flag")
+	}
+}
+
+func TestAttach(t *testing.T) {
+	n := newTestNode(t)
+	defer n.Close()
+
+	input := stdin
+	defer func() { stdin = input }()
+	stdin = bufio.NewReader(strings.NewReader(strings.Join([]string{
+		"balance " + testAccount.Hex(),
+		"balance", //fails, without ending the console
+		"nonce --json " + testAccount.Hex(),
+		"exit",
+		"stats",
+	}, "\n")))
+
+	out := runCommand(t, append([]string{"attach"}, n.flags()...)...)
+	if !strings.Contains(out, "Balance: 1000 wei") {
+		t.Fatalf("no balance in %q", out)
+	}
+	if !strings.Contains(out, `"pending": 5`) {
+		t.Fatalf("no nonce in %q", out)
+	}
+	if strings.Contains(out, "Babbling") {
+		t.Fatalf("ran a line after exit: %q", out)
+	}
+	if !bytes.Contains([]byte(out), []byte("Address: "+testAccount.Hex())) {
+		t.Fatalf("no address in %q", out)
+	}
+}
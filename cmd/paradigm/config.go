@@ -0,0 +1,147 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/paradigm-network/paradigm/config"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var ConfigFileFlag = cli.StringFlag{
+	Name:  "config",
+	Usage: "JSON configuration file, the flags given on the command line override it",
+}
+
+var runFlags = []cli.Flag{
+	ConfigFileFlag,
+	OnlyAccretion,
+	DataDirFlag,
+	NodeAddressFlag,
+	Gw2AddressFlag,
+	Fn2AddressFlag,
+	ServiceAddressFlag,
+	LogLevelFlag,
+	HeartbeatFlag,
+	MaxPoolFlag,
+	TcpTimeoutFlag,
+	CacheSizeFlag,
+	SyncLimitFlag,
+	StoreFlag,
+	StorePathFlag,
+	KeyStorePathFlag,
+	PwdFilePathFlag,
+	SequentiaAddress,
+	RpcAddr,
+	ChainIDFlag,
+	AllowUnprotectedTxsFlag,
+	MaxClockSkewFlag,
+	ReadyHeartbeatsFlag,
+	MaxBlockAgeFlag,
+	TraceFileFlag,
+	TraceSampleRateFlag,
+	SignerFlag,
+	SignerTokenFlag,
+}
+
+var configCommand = cli.Command{
+	Name:  "config",
+	Usage: "Inspect the configuration of the run command",
+	Subcommands: []cli.Command{
+		{
+			Name:   "dump",
+			Usage:  "Print the effective configuration, the file merged with the flags",
+			Action: configDump,
+			Flags:  runFlags,
+		},
+	},
+}
+
+//fileBindings binds the flags of the run command to the keys of the
+//configuration file
+func fileBindings(f *config.File) map[string]interface{} {
+	return map[string]interface{}{
+		DataDirFlag.Name:             &f.Node.DataDir,
+		KeyStorePathFlag.Name:        &f.Node.KeyStorePath,
+		PwdFilePathFlag.Name:         &f.Node.PwdPath,
+		LogLevelFlag.Name:            &f.Node.LogLevel,
+		OnlyAccretion.Name:           &f.Node.OnlyAccretion,
+		ChainIDFlag.Name:             &f.Node.ChainID,
+		SignerFlag.Name:              &f.Node.Signer,
+		SignerTokenFlag.Name:         &f.Node.SignerToken,
+		NodeAddressFlag.Name:         &f.Network.NodeAddr,
+		MaxPoolFlag.Name:             &f.Network.MaxPool,
+		TcpTimeoutFlag.Name:          &f.Network.TCPTimeout,
+		SyncLimitFlag.Name:           &f.Network.SyncLimit,
+		StoreFlag.Name:               &f.Store.Type,
+		StorePathFlag.Name:           &f.Store.Path,
+		CacheSizeFlag.Name:           &f.Store.CacheSize,
+		ServiceAddressFlag.Name:      &f.API.ServiceAddr,
+		SequentiaAddress.Name:        &f.API.SequentiaAddr,
+		RpcAddr.Name:                 &f.API.RpcAddr,
+		Gw2AddressFlag.Name:          &f.API.Gw2Addr,
+		Fn2AddressFlag.Name:          &f.API.Fn2Addr,
+		AllowUnprotectedTxsFlag.Name: &f.API.AllowUnprotectedTxs,
+		TraceFileFlag.Name:           &f.API.TraceFile,
+		TraceSampleRateFlag.Name:     &f.API.TraceSampleRate,
+		HeartbeatFlag.Name:           &f.Consensus.Heartbeat,
+		MaxClockSkewFlag.Name:        &f.Consensus.MaxClockSkew,
+		ReadyHeartbeatsFlag.Name:     &f.Consensus.ReadyHeartbeats,
+		MaxBlockAgeFlag.Name:         &f.Consensus.MaxBlockAge,
+	}
+}
+
+//loadConfigFile returns the configuration of the run command: the defaults of
+//the flags, overridden by the configuration file, overridden by the flags set
+//on the command line
+func loadConfigFile(c *cli.Context) (*config.File, error) {
+	f := &config.File{}
+	bindings := fileBindings(f)
+	for name, target := range bindings {
+		setFromFlag(c, name, target)
+	}
+	if path := c.String(ConfigFileFlag.Name); path != "" {
+		if err := config.LoadFile(path, f); err != nil {
+			return nil, err
+		}
+		for name, target := range bindings {
+			if c.IsSet(name) {
+				setFromFlag(c, name, target)
+			}
+		}
+	}
+	return f, nil
+}
+
+func setFromFlag(c *cli.Context, name string, target interface{}) {
+	switch t := target.(type) {
+	case *string:
+		*t = c.String(name)
+	case *int:
+		*t = c.Int(name)
+	case *bool:
+		*t = c.Bool(name)
+	case *uint64:
+		*t = c.Uint64(name)
+	case *float64:
+		*t = c.Float64(name)
+	default:
+		panic(fmt.Sprintf("unsupported type %T of flag %s", target, name))
+	}
+}
+
+func configDump(c *cli.Context) error {
+	f, err := loadConfigFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	data, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Println(string(data))
+	if err := f.Validate(); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
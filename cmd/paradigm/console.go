@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/ssh/terminal"
+	"gopkg.in/urfave/cli.v1"
+)
+
+//attachCommand opens a console on a running node. Its lines are the client
+//commands, run against the node attach is given.
+var attachCommand = cli.Command{
+	Name:   "attach",
+	Usage:  "Open an interactive console on a running node",
+	Action: attach,
+	Flags: []cli.Flag{
+		SequentiaAddress,
+		RpcAddr,
+		KeyStorePathFlag,
+	},
+}
+
+func attach(c *cli.Context) error {
+	console := cli.NewApp()
+	console.Name = "paradigm"
+	console.Usage = "Console attached to a running node, exit or Ctrl-D to leave"
+	console.HideVersion = true
+	console.Commands = clientCommands
+	console.CommandNotFound = func(_ *cli.Context, name string) {
+		fmt.Printf("Unknown command %s, type help for the commands\n", name)
+	}
+
+	//The errors of the commands are printed, they do not end the console
+	exiter := cli.OsExiter
+	cli.OsExiter = func(int) {}
+	defer func() { cli.OsExiter = exiter }()
+
+	interactive := terminal.IsTerminal(int(os.Stdin.Fd()))
+	if interactive {
+		var stats map[string]string
+		if err := nodeRPC(c).Call(&stats, "GetStats"); err != nil {
+			fmt.Printf("Node not reachable on %s: %s\n", c.String(RpcAddr.Name), err)
+		} else {
+			fmt.Printf("Attached to node %s, %s, last block %s\n",
+				stats["id"], stats["state"], stats["last_block_index"])
+		}
+		fmt.Println("Type help for the commands, exit to leave")
+	}
+
+	for {
+		if interactive {
+			fmt.Print("> ")
+		}
+		//stdin is shared with the passphrase prompts of send
+		line, err := stdin.ReadString('\n')
+		if err != nil && err != io.EOF {
+			return cli.NewExitError(err, 1)
+		}
+		fields := strings.Fields(line)
+		if len(fields) > 0 {
+			switch fields[0] {
+			case "exit", "quit":
+				return nil
+			case "help":
+				console.Run(append([]string{""}, fields...))
+			default:
+				console.Run(consoleArgs(c, fields))
+			}
+		}
+		if err == io.EOF {
+			if interactive {
+				fmt.Println()
+			}
+			return nil
+		}
+	}
+}
+
+//consoleArgs returns the arguments of a console line, given the addresses of
+//attach before the ones of the line
+func consoleArgs(c *cli.Context, fields []string) []string {
+	args := []string{"", fields[0]}
+	for _, cmd := range clientCommands {
+		if cmd.Name != fields[0] {
+			continue
+		}
+		for _, f := range []cli.StringFlag{SequentiaAddress, RpcAddr, KeyStorePathFlag} {
+			if hasFlag(cmd, f.Name) {
+				args = append(args, "--"+f.Name, c.String(f.Name))
+			}
+		}
+	}
+	return append(args, fields[1:]...)
+}
+
+func hasFlag(cmd cli.Command, name string) bool {
+	for _, f := range cmd.Flags {
+		if f.GetName() == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/core/sequentia"
+	"github.com/paradigm-network/paradigm/storage"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	FromRoundFlag = cli.IntFlag{
+		Name:  "from",
+		Usage: "First round to export",
+	}
+	ToRoundFlag = cli.IntFlag{
+		Name:  "to",
+		Usage: "Last round to export, -1 for the last round in the store",
+		Value: -1,
+	}
+	ExportFormatFlag = cli.StringFlag{
+		Name:  "format",
+		Usage: "dot or json",
+		Value: "dot",
+	}
+	OutputFlag = cli.StringFlag{
+		Name:  "out",
+		Usage: "Output file, standard output if empty",
+	}
+)
+
+//exportGraph reads the badger store directly, the node must be stopped
+func exportGraph(c *cli.Context) error {
+	format := c.String(ExportFormatFlag.Name)
+	if format != "dot" && format != "json" {
+		return cli.NewExitError("format must be dot or json", 1)
+	}
+
+	log.InitRotateWriter(c.String(DataDirFlag.Name) + "/paradigm.log")
+	store, err := storage.LoadBadgerStore(c.Int(CacheSizeFlag.Name), c.String(StorePathFlag.Name))
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to load BadgerStore: %s", err), 1)
+	}
+	defer store.Close()
+
+	export, err := sequentia.ExportRounds(store, c.Int(FromRoundFlag.Name), c.Int(ToRoundFlag.Name))
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to export rounds: %s", err), 1)
+	}
+
+	var out io.Writer = os.Stdout
+	if path := c.String(OutputFlag.Name); path != "" {
+		f, err := os.Create(path)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	if format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(export)
+	} else {
+		err = export.WriteDOT(out)
+	}
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
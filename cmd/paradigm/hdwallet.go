@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/hdwallet"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var MnemonicFileFlag = cli.StringFlag{
+	Name:  "mnemonic",
+	Usage: "File containing the mnemonic to restore. It is prompted for when it is not given",
+}
+
+//hdCommand manages the HD wallets the proxy serves accounts from, in the
+//hdwallet directory under keystore_path. They are opened with the passphrase
+//of the pwd file, like the keystore accounts are unlocked.
+var hdCommand = cli.Command{
+	Name:  "hd",
+	Usage: "Manage the HD wallets, whose accounts are derived from a mnemonic",
+	Subcommands: []cli.Command{
+		{
+			Name:   "new",
+			Usage:  "Create a wallet from a new mnemonic",
+			Action: hdNew,
+			Flags:  accountFlags,
+			Description: `The mnemonic is printed once: it is the only backup of the wallet, write it
+down. The account m/44'/60'/0'/0/0 is derived.`,
+		},
+		{
+			Name:   "restore",
+			Usage:  "Restore a wallet from its mnemonic",
+			Action: hdRestore,
+			Flags:  append(accountFlags, MnemonicFileFlag),
+		},
+		{
+			Name:   "list",
+			Usage:  "List the wallets and their accounts",
+			Action: hdList,
+			Flags:  accountFlags,
+		},
+		{
+			Name:      "derive",
+			Usage:     "Derive an account of a wallet",
+			ArgsUsage: "<wallet file> <path>",
+			Action:    hdDerive,
+			Flags:     accountFlags,
+			Description: `The path is either absolute, like m/44'/60'/0'/0/1, or relative to
+m/44'/60'/0'/0, like 1. The account is added to the wallet.`,
+		},
+	},
+}
+
+func hdNew(c *cli.Context) error {
+	hub, err := accountHub(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase("Passphrase of the new wallet: ", true, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	w, mnemonic, err := hub.NewWallet(pwd, hdwallet.DefaultEntropyBits)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to create wallet: %s", err), 1)
+	}
+	fmt.Printf("Mnemonic: %s\n", mnemonic)
+	printWallet(w)
+	return nil
+}
+
+func hdRestore(c *cli.Context) error {
+	hub, err := accountHub(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var mnemonic string
+	if path := c.String(MnemonicFileFlag.Name); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		mnemonic = string(data)
+	} else if mnemonic, err = promptPassphrase("Mnemonic: "); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase("Passphrase of the restored wallet: ", true, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	w, err := hub.Restore(strings.TrimSpace(mnemonic), pwd)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to restore wallet: %s", err), 1)
+	}
+	printWallet(w)
+	return nil
+}
+
+func hdList(c *cli.Context) error {
+	hub, err := accountHub(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	for _, w := range hub.Wallets() {
+		printWallet(w)
+	}
+	return nil
+}
+
+func hdDerive(c *cli.Context) error {
+	if c.NArg() != 2 {
+		return cli.NewExitError("the wallet file and the derivation path are required", 1)
+	}
+	path, err := accounts.ParseDerivationPath(c.Args().Get(1))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	hub, err := accountHub(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	file, err := filepath.Abs(c.Args().First())
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	var wallet accounts.Wallet
+	for _, w := range hub.Wallets() {
+		if abs, _ := filepath.Abs(w.URL().Path); abs == file {
+			wallet = w
+		}
+	}
+	if wallet == nil {
+		return cli.NewExitError(accounts.ErrUnknownWallet, 1)
+	}
+
+	passwords, err := readPasswordFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pwd, err := getPassphrase("Passphrase of the wallet: ", false, 0, passwords)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := wallet.Open(pwd); err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to open wallet: %s", err), 1)
+	}
+	defer wallet.Close()
+	account, err := wallet.Derive(path, true)
+	if err != nil {
+		return cli.NewExitError(fmt.Sprintf("failed to derive account: %s", err), 1)
+	}
+	fmt.Printf("Address: {%x}\n", account.Address)
+	return nil
+}
+
+//accountHub opens the HD wallets the proxy serves accounts from
+func accountHub(c *cli.Context) (*hdwallet.Hub, error) {
+	dir := filepath.Join(c.String(KeyStorePathFlag.Name), "hdwallet")
+	os.MkdirAll(dir, 0700)
+	if c.Bool(LightKDFFlag.Name) {
+		return hdwallet.NewHubWithScrypt(dir, keystore.LightScryptN, keystore.LightScryptP)
+	}
+	return hdwallet.NewHub(dir)
+}
+
+func printWallet(w accounts.Wallet) {
+	fmt.Printf("Wallet: %s://%s\n", w.URL().Scheme, w.URL().Path)
+	for i, account := range w.Accounts() {
+		fmt.Printf("Account #%d: {%x}\n", i, account.Address)
+	}
+}
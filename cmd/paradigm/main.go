@@ -5,13 +5,19 @@ import (
 	"github.com/paradigm-network/paradigm/accounts/keystore"
 	"github.com/paradigm-network/paradigm/common/crypto"
 	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/common/trace"
 	"github.com/paradigm-network/paradigm/config"
 	"github.com/paradigm-network/paradigm/core"
+	"github.com/paradigm-network/paradigm/genesis"
+	"github.com/paradigm-network/paradigm/network/http/jsonrpc"
+	"github.com/paradigm-network/paradigm/network/http/service"
 	"github.com/paradigm-network/paradigm/network/peer"
 	"github.com/paradigm-network/paradigm/network/tcp"
 	"github.com/paradigm-network/paradigm/proxy"
+	"github.com/paradigm-network/paradigm/signer"
 	"github.com/paradigm-network/paradigm/storage"
 	"github.com/paradigm-network/paradigm/version"
+	"github.com/rs/zerolog"
 	"gopkg.in/urfave/cli.v1"
 	"io/ioutil"
 	"os"
@@ -112,6 +118,51 @@ var (
 		Usage: "RPC host address",
 		Value: "127.0.0.1:7000",
 	}
+	ChainIDFlag = cli.Uint64Flag{
+		Name:  "chain_id",
+		Usage: "Chain id for transaction replay protection, overridden by genesis.json",
+		Value: config.DEFAULT_CHAIN_ID,
+	}
+	AllowUnprotectedTxsFlag = cli.BoolFlag{
+		Name:  "allow_unprotected_txs",
+		Usage: "Accept transactions signed without a chain id",
+	}
+	MaxClockSkewFlag = cli.IntFlag{
+		Name:  "max_clock_skew",
+		Usage: "Clock skew milliseconds above which a participant is reported, 0 to disable",
+		Value: int(config.DEFAULT_MAX_CLOCK_SKEW / time.Millisecond),
+	}
+	ReadyHeartbeatsFlag = cli.IntFlag{
+		Name:  "ready_heartbeats",
+		Usage: "Heartbeats of failed gossip after which the node is not ready, 0 to disable",
+		Value: config.DEFAULT_READY_HEARTBEATS,
+	}
+	MaxBlockAgeFlag = cli.IntFlag{
+		Name:  "max_block_age",
+		Usage: "Age in seconds of the last block after which the node is not ready, 0 to disable",
+	}
+	TraceFileFlag = cli.StringFlag{
+		Name:  "trace_file",
+		Usage: "File the transaction spans are appended to, in OTLP/JSON. Tracing is disabled without it",
+	}
+	TraceSampleRateFlag = cli.Float64Flag{
+		Name:  "trace_sample_rate",
+		Usage: "Fraction of the transactions traced, between 0 and 1",
+		Value: 1,
+	}
+	KeyTypeFlag = cli.StringFlag{
+		Name:  "key_type",
+		Usage: "Type of the node key, secp256k1 or ed25519",
+		Value: "secp256k1",
+	}
+	SignerFlag = cli.StringFlag{
+		Name:  "signer",
+		Usage: "Signer daemon holding the node key, unix://<path> or <host>:<port>. The key is read from keystore_path without it",
+	}
+	SignerTokenFlag = cli.StringFlag{
+		Name:  "signer_token",
+		Usage: "File of the token of the signer daemon. Defaults to signer.token in keystore_path",
+	}
 )
 
 func main() {
@@ -129,38 +180,45 @@ func main() {
 			Name:   "run",
 			Usage:  "Run paradigm",
 			Action: run,
-			Flags: []cli.Flag{
-				OnlyAccretion,
-				DataDirFlag,
-				NodeAddressFlag,
-				Gw2AddressFlag,
-				Fn2AddressFlag,
-				ServiceAddressFlag,
-				LogLevelFlag,
-				HeartbeatFlag,
-				MaxPoolFlag,
-				TcpTimeoutFlag,
-				CacheSizeFlag,
-				SyncLimitFlag,
-				StoreFlag,
-				StorePathFlag,
-				KeyStorePathFlag,
-				PwdFilePathFlag,
-				SequentiaAddress,
-				RpcAddr,
-			},
+			Flags:  runFlags,
 		},
 		{
 			Name:   "version",
 			Usage:  "Show version info",
 			Action: printVersion,
 		},
+		{
+			Name:   "export",
+			Usage:  "Export rounds of the CometGraph of a stopped node as Graphviz DOT or JSON",
+			Action: exportGraph,
+			Flags: []cli.Flag{
+				DataDirFlag,
+				StorePathFlag,
+				CacheSizeFlag,
+				FromRoundFlag,
+				ToRoundFlag,
+				ExportFormatFlag,
+				OutputFlag,
+			},
+		},
 		{
 			Name:   "initAccount",
-			Usage:  "Init Account",
+			Usage:  "Create the key of the node",
 			Action: createAccount,
+			Flags: []cli.Flag{
+				KeyStorePathFlag,
+				PwdFilePathFlag,
+				KeyTypeFlag,
+			},
 		},
-	}
+		accountCommand,
+		testnetCommand,
+		configCommand,
+		signerCommand,
+		rotateKeyCommand,
+		attachCommand,
+	}
+	app.Commands = append(app.Commands, clientCommands...)
 	app.Run(os.Args)
 }
 
@@ -184,60 +242,82 @@ func printVersion(c *cli.Context) error {
 	return nil
 }
 
-func createAccount(c *cli.Context) {
-	scryptN := keystore.StandardScryptN
-	scryptP := keystore.StandardScryptP
+//createAccount generates the key of the node. It never overwrites an existing
+//key, the node identity would be lost.
+func createAccount(c *cli.Context) error {
 	path := filepath.Join(c.String(KeyStorePathFlag.Name), config.PemKeyPath)
+	if _, err := os.Stat(path); err == nil {
+		return cli.NewExitError(fmt.Sprintf("%s already exists, remove it first to create a new key", path), 1)
+	}
 
-	passwordFile := c.String(PwdFilePathFlag.Name)
-	pwd, _ := ioutil.ReadFile(passwordFile)
-	fmt.Println(string(path))
-	fmt.Println(string(passwordFile))
-	fmt.Println(string(pwd))
+	//The passphrase is only prompted for when no pwd file was given and the
+	//default one is missing
+	pwd, err := proxy.ReadPassword(c.String(PwdFilePathFlag.Name))
+	switch {
+	case err == nil:
+	case c.IsSet(PwdFilePathFlag.Name) || !os.IsNotExist(err):
+		return cli.NewExitError(err, 1)
+	default:
+		if pwd, err = getPassphrase("Passphrase of the node key: ", true, 0, nil); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
 
-	//Generate key and store it in the give directory.
-	address, _ := keystore.StoreKey(path, string(pwd), scryptN, scryptP)
-	fmt.Printf("Address: {%x}\n", address)
+	key, err := newNodeKey(c.String(KeyTypeFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	keyJSON, err := keystore.EncryptNodeKey(key, pwd, keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := ioutil.WriteFile(path, keyJSON, 0600); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("Key type: %s\n", key.Type())
+	fmt.Printf("Public key: 0x%X\n", key.PubKey())
+	fmt.Printf("Key file: %s\n", path)
+	return nil
+}
+
+//newNodeKey generates a node identity key of the type named keyType
+func newNodeKey(keyType string) (crypto.PrivKey, error) {
+	t, err := crypto.ParseKeyType(keyType)
+	if err != nil {
+		return nil, err
+	}
+	if t == crypto.Ed25519 {
+		return crypto.GenerateEd25519Key()
+	}
+	key, err := keystore.NewKey()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.Secp256k1PrivKey{PrivateKey: key.PrivateKey}, nil
 }
 
 func run(c *cli.Context) error {
 	fmt.Println("Paradigm Starting...")
-	onlyAccretion := c.Bool(OnlyAccretion.Name)
-	datadir := c.String(DataDirFlag.Name)
-	addr := c.String(NodeAddressFlag.Name)
-	gw2Address := c.String(Gw2AddressFlag.Name)
-	fn2Address := c.String(Fn2AddressFlag.Name)
-	serviceAddress := c.String(ServiceAddressFlag.Name)
-	heartbeat := c.Int(HeartbeatFlag.Name)
-	maxPool := c.Int(MaxPoolFlag.Name)
-	tcpTimeout := c.Int(TcpTimeoutFlag.Name)
-	cacheSize := c.Int(CacheSizeFlag.Name)
-	syncLimit := c.Int(SyncLimitFlag.Name)
-	storePath := c.String(StorePathFlag.Name)
-	sequentiaAddress := c.String(SequentiaAddress.Name)
-	keyStoreDir := c.String(KeyStorePathFlag.Name)
-	pwdFilePath := c.String(PwdFilePathFlag.Name)
-	rpcAddr := c.String(RpcAddr.Name)
+	file, err := loadConfigFile(c)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := file.Validate(); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	datadir := file.Node.DataDir
+	addr := file.Network.NodeAddr
 
 	log.InitRotateWriter(datadir + "/paradigm.log")
+	level, _ := zerolog.ParseLevel(file.Node.LogLevel)
+	zerolog.SetGlobalLevel(level)
 	logger := log.GetLogger("Main")
-	logger.Info().Interface(
-		"only_accretion", onlyAccretion).Interface(
-		"datadir", datadir).Interface(
-		"gw2_addr", gw2Address).Interface(
-		"fn2_addr", fn2Address).Interface(
-		"node_addr", addr).Interface(
-		"service_addr", serviceAddress).Interface(
-		"heartbeat", heartbeat).Interface(
-		"max_pool", maxPool).Interface(
-		"tcp_timeout", tcpTimeout).Interface(
-		"cache_size", cacheSize).Interface(
-		"store_path", storePath).Interface(
-		"rpcAddr", rpcAddr).Msg("Running Args")
-
-	conf := config.NewConfig(onlyAccretion, time.Duration(heartbeat)*time.Millisecond,
-		time.Duration(tcpTimeout)*time.Millisecond,
-		cacheSize, syncLimit, storePath, gw2Address, fn2Address, sequentiaAddress, keyStoreDir, pwdFilePath,nil,nil, rpcAddr)
+	logger.Info().Interface("config", file).Msg("Running Args")
+
+	conf := file.Config()
 
 	//===============================================================================================================
 	//// Create the PEM key
@@ -249,14 +329,30 @@ func run(c *cli.Context) error {
 	//	return cli.NewExitError(err, 1)
 	//}
 	//===============================================================================================================
-	jsonPrivKey,err:=ioutil.ReadFile(filepath.Join(c.String(KeyStorePathFlag.Name), config.PemKeyPath))
-	if err != nil {
-		logger.Error().Msg("Key reading error")
-	}
-	pwd, _ := ioutil.ReadFile(c.String(PwdFilePathFlag.Name))
-	kk,err := keystore.DecryptKey(jsonPrivKey,string(pwd))
-	if err == nil {
-		logger.Error().Msg("key decrypt error.")
+	//The node key is either held by a signer daemon or read from the keystore
+	var nodeSigner signer.Signer
+	var txSigner signer.TxSigner
+	if conf.Signer != "" {
+		tokenPath := conf.SignerToken
+		if tokenPath == "" {
+			tokenPath = filepath.Join(conf.KeyStoreDir, signer.TokenFile)
+		}
+		token, err := signer.ReadToken(tokenPath)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("signer token: %s", err), 1)
+		}
+		client, err := signer.NewClient(conf.Signer, token)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		nodeSigner, txSigner = client, client
+		logger.Info().Str("signer", conf.Signer).Msg("Signing with the signer daemon")
+	} else {
+		kk, err := readNodeKey(conf.KeyStoreDir, conf.PwdFile)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		nodeSigner = signer.NewLocal(kk)
 	}
 	//===============================================================================================================
 
@@ -284,8 +380,13 @@ func run(c *cli.Context) error {
 
 	//Find the ID --common.Address-- of this node
 	//Raw punlic key ,[]byte
-	nodePub := fmt.Sprintf("0x%X", crypto.FromECDSAPub(&kk.PrivateKey.PublicKey))
-	nodeID := pmap[nodePub]
+	nodePub := fmt.Sprintf("0x%X", nodeSigner.PubKey())
+	nodeID, ok := pmap[nodePub]
+	if !ok {
+		return cli.NewExitError(
+			fmt.Sprintf("the node key %s is not a participant of %s", nodePub, filepath.Join(datadir, "participants.json")),
+			1)
+	}
 
 	logger.Info().Interface("participantMap", pmap).Int("nodeID", nodeID).Msg("PARTICIPANTS")
 
@@ -313,35 +414,100 @@ func run(c *cli.Context) error {
 	}
 
 	trans, err := tcp.NewTCPTransport(addr,
-		nil, maxPool, conf.TCPTimeout)
+		nil, file.Network.MaxPool, conf.TCPTimeout)
 	if err != nil {
 		return cli.NewExitError(err, 1)
 	}
 
-	proxy := proxy.NewInmemAppProxy(conf, store)
+	if conf.TraceFile != "" {
+		exporter, err := trace.NewFileExporter(conf.TraceFile)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		tracer := trace.NewTracer(exporter, conf.TraceSampleRate,
+			trace.String("service.name", "paradigm"),
+			trace.String("service.instance.id", addr),
+			trace.Int("paradigm.node_id", nodeID))
+		trace.SetTracer(tracer)
+		defer tracer.Close()
+	}
+
+	//The genesis identifies the chain, the node only gossips with peers of the
+	//same genesis
+	gen, err := genesis.Load(conf.KeyStoreDir)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	pubKeys := make([]string, len(peers))
+	for i, p := range peers {
+		pubKeys[i] = p.PubKeyHex
+	}
+	if err := gen.Complete(conf.ChainID, pubKeys); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if err := genesis.Check(store, gen.Hash()); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	logger.Info().Str("genesis", gen.Hash().Hex()).Uint64("chain_id", gen.Config.ChainID).Msg("Genesis")
+
+	proxy := proxy.NewInmemAppProxy(conf, store, gen, txSigner)
+	if proxy == nil {
+		return cli.NewExitError("failed to create the application proxy", 1)
+	}
 
 	//todo impl. if no_client
-	node := core.NewNode(conf, nodeID, kk.PrivateKey, peers, store, trans, proxy)
+	node := core.NewNode(conf, nodeID, nodeSigner, peers, store, trans, proxy)
+	if conf.Signer == "" {
+		//The keys the node rotates to are read once their rotation is active
+		pwd, err := ioutil.ReadFile(conf.PwdFile)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		node.SetKeyring(signer.NewKeyring(filepath.Join(conf.KeyStoreDir, config.RotatedKeysDir), string(pwd)))
+	}
 	if err := node.Init(needBootstrap); err != nil {
 		return cli.NewExitError(
 			fmt.Sprintf("failed to initialize node: %s", err),
 			1)
 	}
 
-	//serviceServer := service.NewService(node)
+	serviceServer := service.NewService(node)
 
 	//start rpc server
-	//exitCh := make(chan interface{}, 0)
-	//go func() {
-	//	err = jsonrpc.StartRPCServer(conf, serviceServer)
-	//	close(exitCh)
-	//}()
+	go func() {
+		if err := jsonrpc.StartRPCServer(conf, serviceServer); err != nil {
+			logger.Error().Err(err).Msg("RPC server stopped")
+		}
+	}()
 
 	node.Run(true)
 
 	return nil
 }
 
+//readNodeKey decrypts the node key of keyStoreDir with the password file
+func readNodeKey(keyStoreDir, pwdFile string) (crypto.PrivKey, error) {
+	jsonPrivKey, err := ioutil.ReadFile(filepath.Join(keyStoreDir, config.PemKeyPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the node key: %s", err)
+	}
+	pwd, err := proxy.ReadPassword(pwdFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the password file: %s", err)
+	}
+	kk, err := keystore.DecryptNodeKey(jsonPrivKey, pwd)
+	if err == keystore.ErrDecrypt {
+		//the keys created before were encrypted with the whole file
+		if raw, rerr := ioutil.ReadFile(pwdFile); rerr == nil && string(raw) != pwd {
+			kk, err = keystore.DecryptNodeKey(jsonPrivKey, string(raw))
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt the node key: %s", err)
+	}
+	return kk, nil
+}
+
 func defaultBadgerDir() string {
 	dataDir := defaultDataDir()
 	if dataDir != "" {
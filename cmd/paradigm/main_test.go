@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/config"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func TestCreateAccountPwdFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-init")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keyPath := filepath.Join(dir, config.PemKeyPath)
+
+	//the exit errors must not end the test
+	exiter, errWriter := cli.OsExiter, cli.ErrWriter
+	defer func() { cli.OsExiter, cli.ErrWriter = exiter, errWriter }()
+	cli.OsExiter, cli.ErrWriter = func(int) {}, ioutil.Discard
+
+	app := cli.NewApp()
+	app.Writer = ioutil.Discard
+	app.Commands = []cli.Command{{
+		Name:   "initAccount",
+		Action: createAccount,
+		Flags:  []cli.Flag{KeyStorePathFlag, PwdFilePathFlag, KeyTypeFlag},
+	}}
+	initAccount := func(pwdFile string) error {
+		return app.Run([]string{"paradigm", "initAccount",
+			"--" + KeyStorePathFlag.Name, dir,
+			"--" + PwdFilePathFlag.Name, pwdFile,
+			"--" + KeyTypeFlag.Name, "ed25519",
+		})
+	}
+
+	//a missing pwd file is an error, not a prompt
+	if err := initAccount(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Fatal("created a key without its pwd file")
+	}
+	if _, err := os.Stat(keyPath); !os.IsNotExist(err) {
+		t.Fatalf("key file written without a passphrase: %v", err)
+	}
+
+	pwdFile := filepath.Join(dir, "pwd.txt")
+	if err := ioutil.WriteFile(pwdFile, []byte("secret"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := initAccount(pwdFile); err != nil {
+		t.Fatal(err)
+	}
+	keyJSON, err := ioutil.ReadFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := keystore.DecryptNodeKey(keyJSON, "secret"); err != nil {
+		t.Fatal(err)
+	}
+	//never overwritten
+	if err := initAccount(pwdFile); err == nil {
+		t.Fatal("overwrote the node key")
+	}
+}
+
+func TestReadNodeKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-nodekey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pwdFile := filepath.Join(dir, "pwd")
+	if err := ioutil.WriteFile(pwdFile, []byte("secret\r\nignored\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pwd := range []string{
+		"secret",              //the first line, as the accounts are unlocked
+		"secret\r\nignored\n", //the whole file, as the keys were encrypted before
+	} {
+		key, err := crypto.GenerateEd25519Key()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyJSON, err := keystore.EncryptNodeKey(key, pwd, keystore.LightScryptN, keystore.LightScryptP)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, config.PemKeyPath), keyJSON, 0600); err != nil {
+			t.Fatal(err)
+		}
+		read, err := readNodeKey(dir, pwdFile)
+		if err != nil {
+			t.Fatalf("passphrase %q: %s", pwd, err)
+		}
+		if !bytes.Equal(read.PubKey(), key.PubKey()) {
+			t.Fatalf("passphrase %q: read another key", pwd)
+		}
+	}
+}
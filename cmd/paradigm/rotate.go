@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/config"
+	"github.com/paradigm-network/paradigm/core/sequentia"
+	"github.com/paradigm-network/paradigm/types"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	RotationKeyFlag = cli.StringFlag{
+		Name:  "key",
+		Usage: "Key file signing the rotation, the last key the node rotated to. Defaults to the node key",
+	}
+)
+
+//rotateKeyCommand replaces the key a node signs with, when it is compromised,
+//without changing its participant slot. The node key stays the key of the
+//slot in participants.json, the new key is written to the rotated_keys
+//directory of keystore_path, where the running node finds it.
+var rotateKeyCommand = cli.Command{
+	Name:   "rotate-key",
+	Usage:  "Rotate the key the node signs with, through the consensus",
+	Action: rotateKey,
+	Flags: []cli.Flag{
+		KeyStorePathFlag,
+		PwdFilePathFlag,
+		KeyTypeFlag,
+		RotationKeyFlag,
+		SequentiaAddress,
+	},
+}
+
+func rotateKey(c *cli.Context) error {
+	keyStoreDir := c.String(KeyStorePathFlag.Name)
+	pwd, err := ioutil.ReadFile(c.String(PwdFilePathFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	slotKey, err := readNodeKey(keyStoreDir, c.String(PwdFilePathFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	currentKey := slotKey
+	if path := c.String(RotationKeyFlag.Name); path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if currentKey, err = keystore.DecryptNodeKey(data, string(pwd)); err != nil {
+			return cli.NewExitError(fmt.Sprintf("failed to decrypt %s: %s", path, err), 1)
+		}
+	}
+
+	newKey, err := newNodeKey(c.String(KeyTypeFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	rotation := types.NewKeyRotation(slotKey.PubKey(), newKey.PubKey())
+	if err := rotation.Sign(currentKey); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	data, err := rotation.Marshal()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	//The new key is written before the rotation is submitted, the node must
+	//hold it once the rotation is active
+	keyJSON, err := keystore.EncryptNodeKey(newKey, string(pwd), keystore.StandardScryptN, keystore.StandardScryptP)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	dir := filepath.Join(keyStoreDir, config.RotatedKeysDir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	path := filepath.Join(dir, fmt.Sprintf("%s.pem", time.Now().UTC().Format("20060102T150405Z")))
+	if err := ioutil.WriteFile(path, keyJSON, 0600); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("New key: 0x%X (%s)\n", newKey.PubKey(), newKey.Type())
+	fmt.Printf("Key file: %s\n", path)
+
+	url := fmt.Sprintf("http://%s/keyrotation", c.String(SequentiaAddress.Name))
+	resp, err := http.Post(url, "text/plain", strings.NewReader(hexutil.Encode(data)))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	defer resp.Body.Close()
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return cli.NewExitError(fmt.Sprintf("%s: %s", resp.Status, strings.TrimSpace(string(body))), 1)
+	}
+	var res struct {
+		TxHash string
+	}
+	if err := json.Unmarshal(body, &res); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	fmt.Printf("Rotation: %s\n", res.TxHash)
+	fmt.Printf("The node signs with the new key %d rounds after the rotation is committed\n", sequentia.KeyRotationDelay)
+	return nil
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/proxy"
+	"github.com/paradigm-network/paradigm/signer"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	SignerListenFlag = cli.StringFlag{
+		Name:  "listen",
+		Usage: "Address the signer listens on, unix://<path> or <host>:<port>. Defaults to the socket signer.sock in keystore_path",
+	}
+	SignerStateFlag = cli.StringFlag{
+		Name:  "state",
+		Usage: "File of the last signed comet and block. Defaults to signer_state.json in keystore_path",
+	}
+	SignerDaemonTokenFlag = cli.StringFlag{
+		Name:  "token",
+		Usage: "File of the token the node presents, created when missing. Defaults to signer.token in keystore_path",
+	}
+)
+
+//signerCommand runs the signer daemon of a node started with --signer. It
+//holds the node key and the accounts of keystore_path, so that they need not
+//live on the node, and refuses to sign two comets or two blocks with the
+//same index. Only the requests bearing its token are served, the node is
+//given the token file with --signer_token.
+var signerCommand = cli.Command{
+	Name:   "signer",
+	Usage:  "Run a signer daemon holding the node key",
+	Action: runSigner,
+	Flags: []cli.Flag{
+		KeyStorePathFlag,
+		PwdFilePathFlag,
+		SignerListenFlag,
+		SignerStateFlag,
+		SignerDaemonTokenFlag,
+	},
+}
+
+func runSigner(c *cli.Context) error {
+	keyStoreDir := c.String(KeyStorePathFlag.Name)
+	listen := c.String(SignerListenFlag.Name)
+	if listen == "" {
+		listen = "unix://" + filepath.Join(keyStoreDir, "signer.sock")
+	}
+	statePath := c.String(SignerStateFlag.Name)
+	if statePath == "" {
+		statePath = filepath.Join(keyStoreDir, "signer_state.json")
+	}
+
+	tokenPath := c.String(SignerDaemonTokenFlag.Name)
+	if tokenPath == "" {
+		tokenPath = filepath.Join(keyStoreDir, signer.TokenFile)
+	}
+	token, err := signer.LoadToken(tokenPath)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	kk, err := readNodeKey(keyStoreDir, c.String(PwdFilePathFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	guard, err := signer.NewGuard(signer.NewLocal(kk), statePath)
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	//The accounts are unlocked with the password of the node key, as by the
+	//proxy of a node holding its keys
+	pwd, err := proxy.ReadPassword(c.String(PwdFilePathFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	ks := keystore.NewKeyStore(filepath.Join(keyStoreDir, "keystore"))
+	for _, account := range ks.Accounts() {
+		if err := ks.Unlock(account, pwd); err != nil {
+			return cli.NewExitError(fmt.Sprintf("failed to unlock %s: %s", account.Address.Hex(), err), 1)
+		}
+		log.Info().Str("address", account.Address.Hex()).Msg("Unlocked account")
+	}
+
+	server := signer.NewServer(guard, signer.NewKeyStore(ks), token)
+	if err := server.Serve(listen); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	return nil
+}
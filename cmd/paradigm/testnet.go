@@ -0,0 +1,338 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/config"
+	"github.com/paradigm-network/paradigm/genesis"
+	"github.com/paradigm-network/paradigm/network/peer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	TestnetDirFlag = cli.StringFlag{
+		Name:  "dir",
+		Usage: "Directory of the testnet, one sub directory per node",
+		Value: "testnet",
+	}
+	TestnetNodesFlag = cli.IntFlag{
+		Name:  "nodes",
+		Usage: "Number of nodes, at least 2",
+		Value: 4,
+	}
+	TestnetBasePortFlag = cli.IntFlag{
+		Name:  "base-port",
+		Usage: "First port of the testnet, every node uses the next " + strconv.Itoa(testnetPortsPerNode) + " ports",
+		Value: 12000,
+	}
+	TestnetHostFlag = cli.StringFlag{
+		Name:  "host",
+		Usage: "Host the nodes bind and reach each other on",
+		Value: "127.0.0.1",
+	}
+	TestnetKeyTypeFlag = cli.StringFlag{
+		Name:  "key_type",
+		Usage: "Type of the node keys, secp256k1, ed25519 or mixed to alternate them",
+		Value: "secp256k1",
+	}
+	TestnetBalanceFlag = cli.StringFlag{
+		Name:  "balance",
+		Usage: "Genesis balance in wei of the test account of every node",
+		Value: "1000000000000000000000000",
+	}
+)
+
+const (
+	//testnetPortsPerNode is the size of the block of ports of a node: the
+	//gossip, the HTTP API of the proxy and the RPC server
+	testnetPortsPerNode = 10
+	testnetFlagsFile    = "flags"
+	testnetNodeLog      = "node.out"
+)
+
+//testnetCommand generates and runs a local network. Every node has its own
+//directory, used as datadir and keystore_path: it holds the encrypted node key
+//and its passphrase, the shared participants.json and genesis.json, a test
+//account funded by the genesis and the flags of the run command.
+var testnetCommand = cli.Command{
+	Name:  "testnet",
+	Usage: "Generate and run a local testnet",
+	Subcommands: []cli.Command{
+		{
+			Name:   "init",
+			Usage:  "Generate the directories of the nodes",
+			Action: testnetInit,
+			Flags: []cli.Flag{
+				TestnetDirFlag,
+				TestnetNodesFlag,
+				TestnetBasePortFlag,
+				TestnetHostFlag,
+				TestnetBalanceFlag,
+				TestnetKeyTypeFlag,
+				ChainIDFlag,
+				LightKDFFlag,
+			},
+		},
+		{
+			Name:   "run",
+			Usage:  "Run all the nodes of a testnet until interrupted",
+			Action: testnetRun,
+			Flags: []cli.Flag{
+				TestnetDirFlag,
+			},
+			Description: `Every node runs in its own process, with the flags of the flags file of
+its directory, and writes its output to the node.out file of its directory.`,
+		},
+	},
+}
+
+//testnetNode is the layout of a node of the testnet
+type testnetNode struct {
+	Dir         string
+	NodeAddr    string
+	ServiceAddr string
+	RpcAddr     string
+}
+
+func newTestnetNodes(dir, host string, nodes, basePort int) []testnetNode {
+	res := make([]testnetNode, nodes)
+	for i := range res {
+		port := basePort + i*testnetPortsPerNode
+		res[i] = testnetNode{
+			Dir:         filepath.Join(dir, fmt.Sprintf("node%d", i)),
+			NodeAddr:    net.JoinHostPort(host, strconv.Itoa(port)),
+			ServiceAddr: net.JoinHostPort(host, strconv.Itoa(port+1)),
+			RpcAddr:     net.JoinHostPort(host, strconv.Itoa(port+2)),
+		}
+	}
+	return res
+}
+
+//flags returns the flags of the run command of the node
+func (n testnetNode) flags() []string {
+	return []string{
+		"--" + DataDirFlag.Name + "=" + n.Dir,
+		"--" + KeyStorePathFlag.Name + "=" + n.Dir,
+		"--" + PwdFilePathFlag.Name + "=" + filepath.Join(n.Dir, "pwd"),
+		"--" + StorePathFlag.Name + "=" + filepath.Join(n.Dir, "badger_db"),
+		"--" + NodeAddressFlag.Name + "=" + n.NodeAddr,
+		"--" + SequentiaAddress.Name + "=" + n.ServiceAddr,
+		"--" + RpcAddr.Name + "=" + n.RpcAddr,
+	}
+}
+
+func testnetInit(c *cli.Context) error {
+	count := c.Int(TestnetNodesFlag.Name)
+	if count < 2 {
+		return cli.NewExitError("a testnet needs at least 2 nodes", 1)
+	}
+	basePort := c.Int(TestnetBasePortFlag.Name)
+	if basePort <= 0 || basePort+count*testnetPortsPerNode > 65536 {
+		return cli.NewExitError(fmt.Sprintf("the ports from %d do not fit %d nodes", basePort, count), 1)
+	}
+	dir, err := filepath.Abs(c.String(TestnetDirFlag.Name))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if entries, err := ioutil.ReadDir(dir); err == nil && len(entries) > 0 {
+		return cli.NewExitError(fmt.Sprintf("%s is not empty, remove it first to generate a new testnet", dir), 1)
+	}
+	balance := c.String(TestnetBalanceFlag.Name)
+	if _, ok := new(big.Int).SetString(balance, 10); !ok {
+		return cli.NewExitError(fmt.Sprintf("invalid balance %q", balance), 1)
+	}
+	scryptN, scryptP := keystore.StandardScryptN, keystore.StandardScryptP
+	if c.Bool(LightKDFFlag.Name) {
+		scryptN, scryptP = keystore.LightScryptN, keystore.LightScryptP
+	}
+
+	keyTypes := []string{c.String(TestnetKeyTypeFlag.Name)}
+	if keyTypes[0] == "mixed" {
+		keyTypes = []string{crypto.Secp256k1.String(), crypto.Ed25519.String()}
+	} else if _, err := crypto.ParseKeyType(keyTypes[0]); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	nodes := newTestnetNodes(dir, c.String(TestnetHostFlag.Name), count, basePort)
+	peers := make([]peer.Peer, 0, count)
+	gen := genesis.Genesis{Alloc: genesis.Alloc{}}
+
+	for i, n := range nodes {
+		if err := os.MkdirAll(n.Dir, 0700); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		pwd, err := newTestnetPassphrase()
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if err := ioutil.WriteFile(filepath.Join(n.Dir, "pwd"), []byte(pwd), 0600); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+
+		//The validator key of the node
+		key, err := newNodeKey(keyTypes[i%len(keyTypes)])
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		keyJSON, err := keystore.EncryptNodeKey(key, pwd, scryptN, scryptP)
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if err := ioutil.WriteFile(filepath.Join(n.Dir, config.PemKeyPath), keyJSON, 0600); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		peers = append(peers, peer.Peer{
+			NetAddr:   n.NodeAddr,
+			PubKeyHex: fmt.Sprintf("0x%X", key.PubKey()),
+		})
+
+		//The test account, unlocked by the proxy with the passphrase of the node
+		accountKey, err := keystore.NewKey()
+		if err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		ks := keystore.NewKeyStoreWithScrypt(filepath.Join(n.Dir, "keystore"), scryptN, scryptP)
+		account, err := ks.ImportECDSA(accountKey.PrivateKey, pwd)
+		if err != nil {
+			return cli.NewExitError(fmt.Sprintf("failed to create the test account of node %d: %s", i, err), 1)
+		}
+		gen.Alloc[account.Address.Hex()] = genesis.Account{Balance: balance}
+		fmt.Printf("node%d: %s validator %s, test account %s\n", i, key.Type(), peers[i].PubKeyHex, account.Address.Hex())
+	}
+
+	//All the nodes sort the peers the same way, the file does as well
+	sort.Sort(peer.ByPubKey(peers))
+	pubKeys := make([]string, len(peers))
+	for i, p := range peers {
+		pubKeys[i] = p.PubKeyHex
+	}
+	if err := gen.Complete(c.Uint64(ChainIDFlag.Name), pubKeys); err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	for _, n := range nodes {
+		if err := peer.NewJSONPeers(n.Dir).SetPeers(peers); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		if err := gen.Write(n.Dir); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+		flags := strings.Join(n.flags(), "\n") + "\n"
+		if err := ioutil.WriteFile(filepath.Join(n.Dir, testnetFlagsFile), []byte(flags), 0644); err != nil {
+			return cli.NewExitError(err, 1)
+		}
+	}
+	fmt.Printf("Genesis %s\n", gen.Hash().Hex())
+	fmt.Printf("Testnet of %d nodes generated in %s, start it with: %s testnet run --%s %s\n",
+		count, dir, filepath.Base(os.Args[0]), TestnetDirFlag.Name, dir)
+	return nil
+}
+
+//newTestnetPassphrase returns a random passphrase. run reads the first line
+//of the file.
+func newTestnetPassphrase() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func testnetRun(c *cli.Context) error {
+	dir := c.String(TestnetDirFlag.Name)
+	dirs, err := filepath.Glob(filepath.Join(dir, "node*", testnetFlagsFile))
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+	if len(dirs) == 0 {
+		return cli.NewExitError(fmt.Sprintf("no testnet in %s, generate one with testnet init", dir), 1)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return cli.NewExitError(err, 1)
+	}
+
+	var cmds []*exec.Cmd
+	stop := func() {
+		for _, cmd := range cmds {
+			cmd.Process.Signal(syscall.SIGTERM)
+		}
+	}
+	for _, flagsFile := range dirs {
+		nodeDir := filepath.Dir(flagsFile)
+		flags, err := readFlagsFile(flagsFile)
+		if err != nil {
+			stop()
+			return cli.NewExitError(err, 1)
+		}
+		out, err := os.OpenFile(filepath.Join(nodeDir, testnetNodeLog), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			stop()
+			return cli.NewExitError(err, 1)
+		}
+		defer out.Close()
+		cmd := exec.Command(exe, append([]string{"run"}, flags...)...)
+		cmd.Stdout = out
+		cmd.Stderr = out
+		if err := cmd.Start(); err != nil {
+			stop()
+			return cli.NewExitError(fmt.Sprintf("failed to start %s: %s", nodeDir, err), 1)
+		}
+		cmds = append(cmds, cmd)
+		fmt.Printf("%s: pid %d, output in %s\n", filepath.Base(nodeDir), cmd.Process.Pid, out.Name())
+	}
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		fmt.Println("Stopping the testnet...")
+		stop()
+	}()
+
+	var wg sync.WaitGroup
+	for _, cmd := range cmds {
+		wg.Add(1)
+		go func(cmd *exec.Cmd) {
+			defer wg.Done()
+			if err := cmd.Wait(); err != nil {
+				fmt.Printf("pid %d exited: %s\n", cmd.Process.Pid, err)
+			}
+		}(cmd)
+	}
+	wg.Wait()
+	return nil
+}
+
+//readFlagsFile returns the flags of a flags file, one per line
+func readFlagsFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var flags []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			flags = append(flags, line)
+		}
+	}
+	return flags, scanner.Err()
+}
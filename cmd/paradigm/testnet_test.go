@@ -0,0 +1,164 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/config"
+	"github.com/paradigm-network/paradigm/genesis"
+	"github.com/paradigm-network/paradigm/network/peer"
+	"gopkg.in/urfave/cli.v1"
+)
+
+func TestTestnetInit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-testnet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	//the exit errors must not end the test
+	exiter, errWriter := cli.OsExiter, cli.ErrWriter
+	defer func() { cli.OsExiter, cli.ErrWriter = exiter, errWriter }()
+	cli.OsExiter, cli.ErrWriter = func(int) {}, ioutil.Discard
+
+	app := cli.NewApp()
+	app.Writer = ioutil.Discard
+	app.Commands = []cli.Command{testnetCommand}
+	testnetInit := func(nodes string) error {
+		return app.Run([]string{"paradigm", "testnet", "init",
+			"--" + TestnetDirFlag.Name, dir,
+			"--" + TestnetNodesFlag.Name, nodes,
+			"--" + TestnetBasePortFlag.Name, "22000",
+			"--" + TestnetKeyTypeFlag.Name, "mixed",
+			"--" + TestnetBalanceFlag.Name, "1000",
+			"--" + LightKDFFlag.Name,
+		})
+	}
+
+	if err := testnetInit("1"); err == nil {
+		t.Fatal("generated a testnet of a single node")
+	}
+	if err := testnetInit("3"); err != nil {
+		t.Fatal(err)
+	}
+	nodes := newTestnetNodes(dir, "127.0.0.1", 3, 22000)
+
+	var peers []peer.Peer
+	var gen *genesis.Genesis
+	addrs := map[string]bool{}
+	for i, n := range nodes {
+		pwd, err := ioutil.ReadFile(filepath.Join(n.Dir, "pwd"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		keyJSON, err := ioutil.ReadFile(filepath.Join(n.Dir, config.PemKeyPath))
+		if err != nil {
+			t.Fatal(err)
+		}
+		key, err := keystore.DecryptNodeKey(keyJSON, string(pwd))
+		if err != nil {
+			t.Fatalf("node%d: %s", i, err)
+		}
+		//mixed alternates the key types
+		if expected := []crypto.KeyType{crypto.Secp256k1, crypto.Ed25519}[i%2]; key.Type() != expected {
+			t.Fatalf("node%d: %s key, expected %s", i, key.Type(), expected)
+		}
+
+		//every node has the same participants and genesis
+		nodePeers, err := peer.NewJSONPeers(n.Dir).Peers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		nodeGen, err := genesis.Load(n.Dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 {
+			peers, gen = nodePeers, nodeGen
+		} else if !reflect.DeepEqual(nodePeers, peers) || nodeGen.Hash() != gen.Hash() {
+			t.Fatalf("node%d: participants or genesis differ from the ones of node0", i)
+		}
+		found := false
+		for _, p := range nodePeers {
+			pub, err := p.PubKeyBytes()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reflect.DeepEqual(pub, key.PubKey()) {
+				found = p.NetAddr == n.NodeAddr
+			}
+		}
+		if !found {
+			t.Fatalf("node%d is not a participant at %s", i, n.NodeAddr)
+		}
+
+		//the test account is unlocked by the passphrase of the node
+		ks := keystore.NewKeyStoreWithScrypt(filepath.Join(n.Dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+		accs := ks.Accounts()
+		if len(accs) != 1 {
+			t.Fatalf("node%d: %d test accounts", i, len(accs))
+		}
+		if err := ks.Unlock(accs[0], string(pwd)); err != nil {
+			t.Fatalf("node%d: %s", i, err)
+		}
+		addrs[accs[0].Address.Hex()] = true
+
+		//the flags file is a valid configuration of the run command
+		flags, err := readFlagsFile(filepath.Join(n.Dir, testnetFlagsFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var file *config.File
+		run := cli.NewApp()
+		run.Writer = ioutil.Discard
+		run.Commands = []cli.Command{{
+			Name:  "run",
+			Flags: runFlags,
+			Action: func(c *cli.Context) (err error) {
+				file, err = loadConfigFile(c)
+				return err
+			},
+		}}
+		if err := run.Run(append([]string{"paradigm", "run"}, flags...)); err != nil {
+			t.Fatal(err)
+		}
+		if err := file.Validate(); err != nil {
+			t.Fatalf("node%d: %s", i, err)
+		}
+		if file.Node.DataDir != n.Dir || file.Network.NodeAddr != n.NodeAddr || file.API.RpcAddr != n.RpcAddr {
+			t.Fatalf("node%d: configuration %+v", i, file)
+		}
+	}
+
+	if len(peers) != 3 || !sort.IsSorted(peer.ByPubKey(peers)) {
+		t.Fatalf("participants %+v", peers)
+	}
+	if len(gen.Participants) != len(peers) {
+		t.Fatalf("genesis participants %v", gen.Participants)
+	}
+	for i, p := range peers {
+		if gen.Participants[i] != p.PubKeyHex {
+			t.Fatalf("genesis participants %v, expected the ones of participants.json", gen.Participants)
+		}
+	}
+	if len(gen.Alloc) != 3 {
+		t.Fatalf("genesis allocations %v", gen.Alloc)
+	}
+	for addr, account := range gen.Alloc {
+		if !addrs[addr] || account.Balance != "1000" {
+			t.Fatalf("genesis allocation of %s %+v", addr, account)
+		}
+	}
+
+	//never overwritten
+	if err := testnetInit("3"); err == nil {
+		t.Fatal("generated a testnet over an existing one")
+	}
+}
@@ -0,0 +1,177 @@
+package crypto
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+//KeyType is the type of a node identity key. Participants of a network may
+//have keys of different types.
+type KeyType byte
+
+const (
+	Secp256k1 KeyType = iota + 1
+	Ed25519
+)
+
+const (
+	//ed25519PubKeyTag prefixes the ed25519 public keys. The secp256k1 ones keep
+	//their uncompressed encoding, tagged by its 0x04 prefix, so that the
+	//identities of the existing participants do not change.
+	ed25519PubKeyTag = 0xED
+
+	//ed25519SigPrefix prefixes the ed25519 signatures, hex encoded. The
+	//secp256k1 ones keep their r|s encoding.
+	ed25519SigPrefix = "ed25519:"
+)
+
+func (t KeyType) String() string {
+	switch t {
+	case Secp256k1:
+		return "secp256k1"
+	case Ed25519:
+		return "ed25519"
+	}
+	return fmt.Sprintf("KeyType(%d)", byte(t))
+}
+
+//ParseKeyType parses secp256k1 or ed25519
+func ParseKeyType(s string) (KeyType, error) {
+	switch strings.ToLower(s) {
+	case "secp256k1":
+		return Secp256k1, nil
+	case "ed25519":
+		return Ed25519, nil
+	}
+	return 0, fmt.Errorf("unknown key type %q, expected secp256k1 or ed25519", s)
+}
+
+//PubKeyType returns the type of a public key of a node identity
+func PubKeyType(pub []byte) (KeyType, error) {
+	switch {
+	case len(pub) == 65 && pub[0] == 4:
+		return Secp256k1, nil
+	case len(pub) == ed25519.PublicKeySize+1 && pub[0] == ed25519PubKeyTag:
+		return Ed25519, nil
+	}
+	return 0, fmt.Errorf("unknown public key type, %d bytes", len(pub))
+}
+
+//PrivKey is the private key of a node identity, signing its comets and blocks
+type PrivKey interface {
+	Type() KeyType
+
+	//PubKey is the tagged public key identifying the node
+	PubKey() []byte
+
+	//Sign returns the encoded signature of hash
+	Sign(hash []byte) (string, error)
+}
+
+//Secp256k1PrivKey is a secp256k1 identity key
+type Secp256k1PrivKey struct {
+	*ecdsa.PrivateKey
+}
+
+func (k Secp256k1PrivKey) Type() KeyType { return Secp256k1 }
+
+func (k Secp256k1PrivKey) PubKey() []byte {
+	return FromECDSAPub(&k.PublicKey)
+}
+
+func (k Secp256k1PrivKey) Sign(hash []byte) (string, error) {
+	r, s, err := SignWithPrivKey(k.PrivateKey, hash)
+	if err != nil {
+		return "", err
+	}
+	return EncodeSignature(r, s), nil
+}
+
+//Ed25519PrivKey is an ed25519 identity key
+type Ed25519PrivKey ed25519.PrivateKey
+
+//GenerateEd25519Key returns a new ed25519 identity key
+func GenerateEd25519Key() (Ed25519PrivKey, error) {
+	_, priv, err := ed25519.GenerateKey(rand.Reader)
+	return Ed25519PrivKey(priv), err
+}
+
+//Ed25519FromSeed returns the ed25519 identity key of a 32 bytes seed
+func Ed25519FromSeed(seed []byte) (Ed25519PrivKey, error) {
+	if len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("invalid ed25519 seed length %d", len(seed))
+	}
+	return Ed25519PrivKey(ed25519.NewKeyFromSeed(seed)), nil
+}
+
+func (k Ed25519PrivKey) Type() KeyType { return Ed25519 }
+
+func (k Ed25519PrivKey) PubKey() []byte {
+	pub := ed25519.PrivateKey(k).Public().(ed25519.PublicKey)
+	return append([]byte{ed25519PubKeyTag}, pub...)
+}
+
+//Seed is the 32 bytes the key is derived from
+func (k Ed25519PrivKey) Seed() []byte {
+	return ed25519.PrivateKey(k).Seed()
+}
+
+func (k Ed25519PrivKey) Sign(hash []byte) (string, error) {
+	return ed25519SigPrefix + hex.EncodeToString(ed25519.Sign(ed25519.PrivateKey(k), hash)), nil
+}
+
+//VerifySignature returns whether sig is the signature of hash by the node
+//identity of pub, of either type
+func VerifySignature(pub, hash []byte, sig string) (bool, error) {
+	t, err := PubKeyType(pub)
+	if err != nil {
+		return false, err
+	}
+	switch t {
+	case Ed25519:
+		if !strings.HasPrefix(sig, ed25519SigPrefix) {
+			return false, fmt.Errorf("not an ed25519 signature")
+		}
+		s, err := hex.DecodeString(strings.TrimPrefix(sig, ed25519SigPrefix))
+		if err != nil {
+			return false, err
+		}
+		return ed25519.Verify(ed25519.PublicKey(pub[1:]), hash, s), nil
+	default:
+		x, y := elliptic.Unmarshal(S256(), pub)
+		if x == nil {
+			return false, fmt.Errorf("invalid secp256k1 public key")
+		}
+		r, s, err := DecodeSignature(sig)
+		if err != nil {
+			return false, err
+		}
+		if r == nil || s == nil {
+			return false, fmt.Errorf("invalid secp256k1 signature")
+		}
+		return Verify(&ecdsa.PublicKey{Curve: S256(), X: x, Y: y}, hash, r, s), nil
+	}
+}
+
+//SignatureValue is a number drawn from a signature of either type, breaking
+//the ties in the consensus order: r for secp256k1, the R point for ed25519
+func SignatureValue(sig string) *big.Int {
+	if strings.HasPrefix(sig, ed25519SigPrefix) {
+		s, _ := hex.DecodeString(strings.TrimPrefix(sig, ed25519SigPrefix))
+		if len(s) > 32 {
+			s = s[:32]
+		}
+		return new(big.Int).SetBytes(s)
+	}
+	r, _, _ := DecodeSignature(sig)
+	if r == nil {
+		return new(big.Int)
+	}
+	return r
+}
@@ -25,7 +25,7 @@ func FromECDSAPub(pub *ecdsa.PublicKey) []byte {
 	if pub == nil || pub.X == nil || pub.Y == nil {
 		return nil
 	}
-	return elliptic.Marshal(elliptic.P256(), pub.X, pub.Y)
+	return elliptic.Marshal(pub.Curve, pub.X, pub.Y)
 }
 
 func SignWithPrivKey(priv *ecdsa.PrivateKey, hash []byte) (r, s *big.Int, err error) {
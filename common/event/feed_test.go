@@ -0,0 +1,97 @@
+package event
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFeedSend(t *testing.T) {
+	var feed Feed
+	const nsubs = 5
+
+	var done sync.WaitGroup
+	subscribed := make(chan struct{})
+	for i := 0; i < nsubs; i++ {
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			ch := make(chan int)
+			sub := feed.Subscribe(ch)
+			defer sub.Unsubscribe()
+			subscribed <- struct{}{}
+			if v := <-ch; v != 42 {
+				t.Errorf("received %d, want 42", v)
+			}
+		}()
+	}
+	for i := 0; i < nsubs; i++ {
+		<-subscribed
+	}
+
+	if n := feed.Send(42); n != nsubs {
+		t.Fatalf("Send delivered to %d channels, want %d", n, nsubs)
+	}
+	done.Wait()
+	if n := feed.Send(43); n != 0 {
+		t.Fatalf("Send after unsubscribe delivered to %d channels", n)
+	}
+}
+
+func TestFeedUnsubscribeUnblocksSend(t *testing.T) {
+	var feed Feed
+	ch := make(chan int)
+	sub := feed.Subscribe(ch)
+
+	sent := make(chan int)
+	go func() { sent <- feed.Send(1) }()
+
+	time.Sleep(10 * time.Millisecond)
+	sub.Unsubscribe()
+	select {
+	case n := <-sent:
+		if n != 0 {
+			t.Fatalf("Send delivered to %d channels, want 0", n)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Send still blocked after Unsubscribe")
+	}
+	if _, ok := <-sub.Err(); ok {
+		t.Fatal("Err channel not closed")
+	}
+}
+
+func TestFeedTypeMismatch(t *testing.T) {
+	var feed Feed
+	feed.Subscribe(make(chan int))
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("Subscribe with another type did not panic")
+		}
+	}()
+	feed.Subscribe(make(chan string))
+}
+
+func TestSubscriptionScope(t *testing.T) {
+	var feed Feed
+	var scope SubscriptionScope
+
+	sub1 := scope.Track(feed.Subscribe(make(chan int, 1)))
+	scope.Track(feed.Subscribe(make(chan int, 1)))
+	if n := scope.Count(); n != 2 {
+		t.Fatalf("Count = %d, want 2", n)
+	}
+	sub1.Unsubscribe()
+	if n := scope.Count(); n != 1 {
+		t.Fatalf("Count = %d, want 1", n)
+	}
+
+	scope.Close()
+	if n := feed.Send(1); n != 0 {
+		t.Fatalf("Send after Close delivered to %d channels", n)
+	}
+	if sub := scope.Track(feed.Subscribe(make(chan int))); sub != nil {
+		t.Fatal("Track after Close returned a subscription")
+	}
+}
@@ -0,0 +1,246 @@
+package metrics
+
+import (
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+//DefBuckets are the default histogram buckets, in seconds, suited to network
+//and consensus latencies
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+//ExponentialBuckets returns count buckets, the first one being start and every
+//next one factor times the previous one
+func ExponentialBuckets(start, factor float64, count int) []float64 {
+	buckets := make([]float64, count)
+	for i := range buckets {
+		buckets[i] = start
+		start *= factor
+	}
+	return buckets
+}
+
+//------------------------------------------------------------------------------
+
+//Counter is a value that only goes up
+type Counter struct {
+	bits uint64
+}
+
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+//Add panics if v is negative
+func (c *Counter) Add(v float64) {
+	if v < 0 {
+		panic("metrics: counters cannot decrease")
+	}
+	addFloat(&c.bits, v)
+}
+
+func (c *Counter) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&c.bits))
+}
+
+func (c *Counter) write(w io.Writer, name string, labels []labelPair) {
+	writeSample(w, name, labels, c.Value())
+}
+
+//Gauge is a value that can go up and down
+type Gauge struct {
+	bits uint64
+}
+
+func (g *Gauge) Set(v float64) {
+	atomic.StoreUint64(&g.bits, math.Float64bits(v))
+}
+
+func (g *Gauge) Inc() {
+	g.Add(1)
+}
+
+func (g *Gauge) Dec() {
+	g.Add(-1)
+}
+
+func (g *Gauge) Add(v float64) {
+	addFloat(&g.bits, v)
+}
+
+func (g *Gauge) Value() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&g.bits))
+}
+
+func (g *Gauge) write(w io.Writer, name string, labels []labelPair) {
+	writeSample(w, name, labels, g.Value())
+}
+
+//gaugeFunc is a gauge whose value is read when the metrics are written
+type gaugeFunc func() float64
+
+func (f gaugeFunc) write(w io.Writer, name string, labels []labelPair) {
+	writeSample(w, name, labels, f())
+}
+
+//Histogram counts observations in buckets of upper bounds. Buckets are
+//written cumulatively, as Prometheus expects them.
+type Histogram struct {
+	upperBounds []float64
+	counts      []uint64 //one more than upperBounds, for +Inf
+	sumBits     uint64
+}
+
+func newHistogram(buckets []float64) *Histogram {
+	upperBounds := append([]float64{}, buckets...)
+	sort.Float64s(upperBounds)
+	return &Histogram{
+		upperBounds: upperBounds,
+		counts:      make([]uint64, len(upperBounds)+1),
+	}
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.upperBounds, v)
+	atomic.AddUint64(&h.counts[i], 1)
+	addFloat(&h.sumBits, v)
+}
+
+//ObserveSince observes the seconds elapsed since start
+func (h *Histogram) ObserveSince(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+func (h *Histogram) write(w io.Writer, name string, labels []labelPair) {
+	bucketLabels := make([]labelPair, len(labels)+1)
+	copy(bucketLabels, labels)
+
+	var cumulative uint64
+	for i, upperBound := range h.upperBounds {
+		cumulative += atomic.LoadUint64(&h.counts[i])
+		bucketLabels[len(labels)] = labelPair{"le", formatFloat(upperBound)}
+		writeSample(w, name+"_bucket", bucketLabels, float64(cumulative))
+	}
+	cumulative += atomic.LoadUint64(&h.counts[len(h.upperBounds)])
+	bucketLabels[len(labels)] = labelPair{"le", "+Inf"}
+	writeSample(w, name+"_bucket", bucketLabels, float64(cumulative))
+
+	writeSample(w, name+"_sum", labels, math.Float64frombits(atomic.LoadUint64(&h.sumBits)))
+	writeSample(w, name+"_count", labels, float64(cumulative))
+}
+
+func addFloat(bits *uint64, v float64) {
+	for {
+		old := atomic.LoadUint64(bits)
+		new := math.Float64bits(math.Float64frombits(old) + v)
+		if atomic.CompareAndSwapUint64(bits, old, new) {
+			return
+		}
+	}
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+//------------------------------------------------------------------------------
+
+//CounterVec is a family of counters partitioned by label values
+type CounterVec struct {
+	f *family
+}
+
+func (v *CounterVec) WithLabelValues(values ...string) *Counter {
+	return v.f.with(values).(*Counter)
+}
+
+//GaugeVec is a family of gauges partitioned by label values
+type GaugeVec struct {
+	f *family
+}
+
+func (v *GaugeVec) WithLabelValues(values ...string) *Gauge {
+	return v.f.with(values).(*Gauge)
+}
+
+//HistogramVec is a family of histograms partitioned by label values
+type HistogramVec struct {
+	f *family
+}
+
+func (v *HistogramVec) WithLabelValues(values ...string) *Histogram {
+	return v.f.with(values).(*Histogram)
+}
+
+//------------------------------------------------------------------------------
+
+func (r *Registry) NewCounter(name, help string) *Counter {
+	return r.NewCounterVec(name, help).WithLabelValues()
+}
+
+func (r *Registry) NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	f := newFamily(name, help, "counter", labelNames, func() metric { return &Counter{} })
+	r.register(f)
+	return &CounterVec{f}
+}
+
+func (r *Registry) NewGauge(name, help string) *Gauge {
+	return r.NewGaugeVec(name, help).WithLabelValues()
+}
+
+func (r *Registry) NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	f := newFamily(name, help, "gauge", labelNames, func() metric { return &Gauge{} })
+	r.register(f)
+	return &GaugeVec{f}
+}
+
+//NewGaugeFunc registers a gauge whose value is fn(), called on every scrape
+func (r *Registry) NewGaugeFunc(name, help string, fn func() float64) {
+	f := newFamily(name, help, "gauge", nil, func() metric { return gaugeFunc(fn) })
+	f.with(nil)
+	r.register(f)
+}
+
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	return r.NewHistogramVec(name, help, buckets).WithLabelValues()
+}
+
+func (r *Registry) NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	f := newFamily(name, help, "histogram", labelNames, func() metric { return newHistogram(buckets) })
+	r.register(f)
+	return &HistogramVec{f}
+}
+
+//The package level constructors register in the DefaultRegistry
+
+func NewCounter(name, help string) *Counter {
+	return DefaultRegistry.NewCounter(name, help)
+}
+
+func NewCounterVec(name, help string, labelNames ...string) *CounterVec {
+	return DefaultRegistry.NewCounterVec(name, help, labelNames...)
+}
+
+func NewGauge(name, help string) *Gauge {
+	return DefaultRegistry.NewGauge(name, help)
+}
+
+func NewGaugeVec(name, help string, labelNames ...string) *GaugeVec {
+	return DefaultRegistry.NewGaugeVec(name, help, labelNames...)
+}
+
+func NewGaugeFunc(name, help string, fn func() float64) {
+	DefaultRegistry.NewGaugeFunc(name, help, fn)
+}
+
+func NewHistogram(name, help string, buckets []float64) *Histogram {
+	return DefaultRegistry.NewHistogram(name, help, buckets)
+}
+
+func NewHistogramVec(name, help string, buckets []float64, labelNames ...string) *HistogramVec {
+	return DefaultRegistry.NewHistogramVec(name, help, buckets, labelNames...)
+}
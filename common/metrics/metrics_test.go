@@ -0,0 +1,50 @@
+package metrics
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestRegistryWrite(t *testing.T) {
+	r := NewRegistry()
+	r.NewCounter("b_total", "A counter.").Add(3)
+	vec := r.NewHistogramVec("a_seconds", "A \"histogram\".", []float64{1, 0.5}, "peer")
+	h := vec.WithLabelValues(`1.2.3.4:1337`)
+	h.Observe(0.2)
+	h.Observe(0.5)
+	h.Observe(4)
+	r.NewGaugeFunc("c", "A gauge\nfunc.", func() float64 { return -1.5 })
+
+	var buf bytes.Buffer
+	if err := r.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# HELP a_seconds A "histogram".
+# TYPE a_seconds histogram
+a_seconds_bucket{peer="1.2.3.4:1337",le="0.5"} 2
+a_seconds_bucket{peer="1.2.3.4:1337",le="1"} 2
+a_seconds_bucket{peer="1.2.3.4:1337",le="+Inf"} 3
+a_seconds_sum{peer="1.2.3.4:1337"} 4.7
+a_seconds_count{peer="1.2.3.4:1337"} 3
+# HELP b_total A counter.
+# TYPE b_total counter
+b_total 3
+# HELP c A gauge\nfunc.
+# TYPE c gauge
+c -1.5
+`
+	if buf.String() != expected {
+		t.Fatalf("expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestRegistryDuplicate(t *testing.T) {
+	r := NewRegistry()
+	r.NewGauge("g", "")
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering a duplicate name did not panic")
+		}
+	}()
+	r.NewCounter("g", "")
+}
@@ -0,0 +1,191 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+)
+
+//contentType is the version 0.0.4 of the Prometheus text exposition format
+const contentType = "text/plain; version=0.0.4; charset=utf-8"
+
+//Registry holds metric families and writes them in the Prometheus text format.
+//Every family is registered once, under a unique name.
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+//DefaultRegistry is the registry used by the package level constructors
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		families: make(map[string]*family),
+	}
+}
+
+//register panics when the name is already taken: metrics are declared once,
+//as package variables, so a duplicate is a programming error.
+func (r *Registry) register(f *family) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.families[f.name]; ok {
+		panic(fmt.Sprintf("metrics: duplicate metric %s", f.name))
+	}
+	r.families[f.name] = f
+}
+
+//Write writes all the families of the registry, sorted by name
+func (r *Registry) Write(w io.Writer) error {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	bw := bufio.NewWriter(w)
+	for _, f := range families {
+		f.write(bw)
+	}
+	return bw.Flush()
+}
+
+//Handler serves the registry to Prometheus scrapers
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		if err := r.Write(w); err != nil {
+			log.Error().Err(err).Msg("Writing metrics")
+		}
+	})
+}
+
+//Handler serves the DefaultRegistry
+func Handler() http.Handler {
+	return DefaultRegistry.Handler()
+}
+
+//------------------------------------------------------------------------------
+
+//metric is a single time series, or a group of series for histograms
+type metric interface {
+	write(w io.Writer, name string, labels []labelPair)
+}
+
+type labelPair struct {
+	name, value string
+}
+
+//family is a named group of metrics of the same type, one per combination of
+//label values. Unlabelled families have a single metric.
+type family struct {
+	name       string
+	help       string
+	typ        string
+	labelNames []string
+	newMetric  func() metric
+
+	mu       sync.RWMutex
+	children map[string]*child
+}
+
+type child struct {
+	labels []labelPair
+	metric metric
+}
+
+func newFamily(name, help, typ string, labelNames []string, newMetric func() metric) *family {
+	return &family{
+		name:       name,
+		help:       help,
+		typ:        typ,
+		labelNames: labelNames,
+		newMetric:  newMetric,
+		children:   make(map[string]*child),
+	}
+}
+
+//with returns the metric for the given label values, creating it on first use
+func (f *family) with(values []string) metric {
+	if len(values) != len(f.labelNames) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labelNames), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+
+	f.mu.RLock()
+	c, ok := f.children[key]
+	f.mu.RUnlock()
+	if ok {
+		return c.metric
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if c, ok := f.children[key]; ok {
+		return c.metric
+	}
+	labels := make([]labelPair, len(values))
+	for i, v := range values {
+		labels[i] = labelPair{f.labelNames[i], v}
+	}
+	c = &child{labels: labels, metric: f.newMetric()}
+	f.children[key] = c
+	return c.metric
+}
+
+func (f *family) write(w io.Writer) {
+	f.mu.RLock()
+	keys := make([]string, 0, len(f.children))
+	for k := range f.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	children := make([]*child, len(keys))
+	for i, k := range keys {
+		children[i] = f.children[k]
+	}
+	f.mu.RUnlock()
+
+	fmt.Fprintf(w, "# HELP %s %s\n", f.name, escapeHelp(f.help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", f.name, f.typ)
+	for _, c := range children {
+		c.metric.write(w, f.name, c.labels)
+	}
+}
+
+func writeSample(w io.Writer, name string, labels []labelPair, value float64) {
+	fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(labels), formatFloat(value))
+}
+
+func formatLabels(labels []labelPair) string {
+	if len(labels) == 0 {
+		return ""
+	}
+	pairs := make([]string, len(labels))
+	for i, l := range labels {
+		pairs[i] = l.name + `="` + escapeLabel(l.value) + `"`
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpEscaper.Replace(s)
+}
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
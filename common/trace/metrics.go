@@ -0,0 +1,10 @@
+package trace
+
+import "github.com/paradigm-network/paradigm/common/metrics"
+
+var (
+	exportedSpans = metrics.NewCounter("paradigm_trace_spans_exported_total",
+		"Number of transaction spans handed to the exporter.")
+	droppedSpans = metrics.NewCounter("paradigm_trace_spans_dropped_total",
+		"Number of transaction spans dropped because the exporter did not keep up.")
+)
@@ -0,0 +1,225 @@
+package trace
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+//Spans follow a transaction from its submission to its receipt. They are
+//correlated by the hash of the transaction: the trace id of a transaction is
+//the first 16 bytes of its hash, on every node, so that the spans exported by
+//several nodes can be merged.
+
+type TraceID [16]byte
+type SpanID [8]byte
+
+//Attribute is a key and a string, int or bool value
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+func String(key, value string) Attribute {
+	return Attribute{key, value}
+}
+
+func Int(key string, value int) Attribute {
+	return Attribute{key, int64(value)}
+}
+
+func Bool(key string, value bool) Attribute {
+	return Attribute{key, value}
+}
+
+//Span is a stage of the processing of a transaction
+type Span struct {
+	TraceID    TraceID
+	SpanID     SpanID
+	Name       string
+	Start      time.Time
+	End        time.Time
+	Attributes []Attribute
+}
+
+//Exporter sends the spans out of the node. Export is called with batches of
+//spans, from a single goroutine.
+type Exporter interface {
+	Export(resource []Attribute, spans []Span) error
+	Close() error
+}
+
+const (
+	batchSize     = 256
+	queueSize     = 4096
+	flushInterval = time.Second
+)
+
+//Tracer samples the transactions and exports the spans of the sampled ones in
+//batches. The sampling is a function of the transaction hash, so all the
+//stages of a transaction, and all the nodes, agree on it without sharing any
+//state.
+type Tracer struct {
+	exporter  Exporter
+	resource  []Attribute
+	threshold uint64 //transactions whose hash starts below it are sampled
+	all       bool
+
+	spans  chan Span
+	done   chan struct{}
+	lock   sync.RWMutex //guards the sends on spans against Close
+	closed bool
+}
+
+//NewTracer returns a Tracer sampling a sampleRate fraction of the
+//transactions, between 0 and 1. The resource attributes describe the node.
+func NewTracer(exporter Exporter, sampleRate float64, resource ...Attribute) *Tracer {
+	t := &Tracer{
+		exporter: exporter,
+		resource: resource,
+		all:      sampleRate >= 1,
+		spans:    make(chan Span, queueSize),
+		done:     make(chan struct{}),
+	}
+	if sampleRate > 0 && sampleRate < 1 {
+		t.threshold = uint64(sampleRate * math.MaxUint64)
+	}
+	go t.loop()
+	return t
+}
+
+//Sampled tells whether the spans of the transaction are recorded
+func (t *Tracer) Sampled(txHash []byte) bool {
+	if t.all {
+		return true
+	}
+	if len(txHash) < 8 {
+		return false
+	}
+	return binary.BigEndian.Uint64(txHash[:8]) < t.threshold
+}
+
+//Record records a span of a transaction, if the transaction is sampled. Spans
+//are dropped when the exporter does not keep up.
+func (t *Tracer) Record(txHash []byte, name string, start, end time.Time, attrs ...Attribute) {
+	if !t.Sampled(txHash) {
+		return
+	}
+	span := Span{
+		TraceID:    traceID(txHash),
+		SpanID:     newSpanID(),
+		Name:       name,
+		Start:      start,
+		End:        end,
+		Attributes: attrs,
+	}
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	if t.closed {
+		return
+	}
+	select {
+	case t.spans <- span:
+	default:
+		droppedSpans.Inc()
+	}
+}
+
+//Close exports the pending spans and closes the exporter. The spans recorded
+//afterwards are ignored.
+func (t *Tracer) Close() error {
+	t.lock.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.spans)
+	}
+	t.lock.Unlock()
+	<-t.done
+	return t.exporter.Close()
+}
+
+func (t *Tracer) loop() {
+	defer close(t.done)
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var batch []Span
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := t.exporter.Export(t.resource, batch); err != nil {
+			log.Error().Err(err).Int("spans", len(batch)).Msg("Exporting spans")
+		}
+		exportedSpans.Add(float64(len(batch)))
+		batch = nil
+	}
+	for {
+		select {
+		case span, ok := <-t.spans:
+			if !ok {
+				flush()
+				return
+			}
+			batch = append(batch, span)
+			if len(batch) >= batchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+func traceID(txHash []byte) (id TraceID) {
+	copy(id[:], txHash)
+	return id
+}
+
+func newSpanID() (id SpanID) {
+	rand.Read(id[:])
+	return id
+}
+
+//------------------------------------------------------------------------------
+
+//The package level functions use the Tracer set with SetTracer. They do
+//nothing until one is set.
+
+var tracer atomic.Value //*Tracer
+
+func SetTracer(t *Tracer) {
+	tracer.Store(t)
+}
+
+func getTracer() *Tracer {
+	t, _ := tracer.Load().(*Tracer)
+	return t
+}
+
+//Enabled tells whether a Tracer is set. Callers check it before computing
+//transaction hashes.
+func Enabled() bool {
+	return getTracer() != nil
+}
+
+func Sampled(txHash []byte) bool {
+	t := getTracer()
+	return t != nil && t.Sampled(txHash)
+}
+
+func Record(txHash []byte, name string, start, end time.Time, attrs ...Attribute) {
+	if t := getTracer(); t != nil {
+		t.Record(txHash, name, start, end, attrs...)
+	}
+}
+
+//Since records a span of a transaction ending now
+func Since(txHash []byte, name string, start time.Time, attrs ...Attribute) {
+	Record(txHash, name, start, time.Now(), attrs...)
+}
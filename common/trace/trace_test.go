@@ -0,0 +1,89 @@
+package trace
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+type testExporter struct {
+	spans  []Span
+	closed bool
+}
+
+func (e *testExporter) Export(resource []Attribute, spans []Span) error {
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *testExporter) Close() error {
+	e.closed = true
+	return nil
+}
+
+func TestSampling(t *testing.T) {
+	low := append([]byte{0x10}, bytes.Repeat([]byte{0}, 31)...)
+	high := append([]byte{0xf0}, bytes.Repeat([]byte{0}, 31)...)
+
+	exporter := &testExporter{}
+	tracer := NewTracer(exporter, 0.5)
+	if !tracer.Sampled(low) || tracer.Sampled(high) {
+		t.Fatal("a rate of 0.5 should sample the lower half of the hashes")
+	}
+	start := time.Unix(0, 1000)
+	tracer.Record(low, "sampled", start, start.Add(time.Millisecond), Int("block", 3))
+	tracer.Record(high, "not sampled", start, start.Add(time.Millisecond))
+	if err := tracer.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if !exporter.closed {
+		t.Fatal("the exporter should be closed")
+	}
+	if len(exporter.spans) != 1 || exporter.spans[0].Name != "sampled" {
+		t.Fatalf("expected the sampled span only, got %v", exporter.spans)
+	}
+	if !bytes.Equal(exporter.spans[0].TraceID[:], low[:16]) {
+		t.Fatal("the trace id should be the prefix of the transaction hash")
+	}
+
+	//Closed tracers ignore the spans
+	tracer.Record(low, "late", start, start)
+
+	if NewTracer(exporter, 0).Sampled(low) || !NewTracer(exporter, 1).Sampled(high) {
+		t.Fatal("rates of 0 and 1 should sample nothing and everything")
+	}
+}
+
+func TestOTLPRequest(t *testing.T) {
+	span := Span{
+		TraceID:    TraceID{0xab},
+		SpanID:     SpanID{0xcd},
+		Name:       "tx.pool",
+		Start:      time.Unix(1, 0),
+		End:        time.Unix(2, 5),
+		Attributes: []Attribute{String("peer", "a"), Int("block", 3), Bool("error", false)},
+	}
+	data, err := NewOTLPRequest([]Attribute{String("service.name", "paradigm")}, []Span{span})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var req otlpRequest
+	if err := json.Unmarshal(data, &req); err != nil {
+		t.Fatal(err)
+	}
+	spans := req.ResourceSpans[0].ScopeSpans[0].Spans
+	if len(spans) != 1 {
+		t.Fatalf("expected 1 span, got %d", len(spans))
+	}
+	s := spans[0]
+	if s.TraceID != "ab000000000000000000000000000000" || s.SpanID != "cd00000000000000" {
+		t.Fatalf("unexpected ids %s %s", s.TraceID, s.SpanID)
+	}
+	if s.StartTimeUnixNano != "1000000000" || s.EndTimeUnixNano != "2000000005" {
+		t.Fatalf("unexpected times %s %s", s.StartTimeUnixNano, s.EndTimeUnixNano)
+	}
+	if len(s.Attributes) != 3 || *s.Attributes[1].Value.IntValue != "3" || *s.Attributes[2].Value.BoolValue {
+		t.Fatalf("unexpected attributes %s", data)
+	}
+}
@@ -6,14 +6,19 @@ import (
 )
 
 const (
-	PemKeyPath = "priv_key.pem"
+	PemKeyPath     = "priv_key.pem"
+	RotatedKeysDir = "rotated_keys" //keys the node rotates to, beside the node key
 )
 
 const (
 	DEFAULT_GEN_BLOCK_TIME   = 6
+	DEFAULT_CHAIN_ID         = 1 //chain id used for replay protection unless genesis says otherwise
 	DBFT_MIN_NODE_NUM        = 4 //min node number of dbft consensus
 	SOLO_MIN_NODE_NUM        = 1 //min node number of solo consensus
 	VBFT_MIN_NODE_NUM        = 4 //min node number of vbft consensus
+
+	DEFAULT_MAX_CLOCK_SKEW   = 5 * time.Second //deviation from the consensus timestamps above which a creator's clock is reported as skewed
+	DEFAULT_READY_HEARTBEATS = 10              //heartbeats without a successful gossip after which a gossiping node is not ready
 )
 
 type Config struct {
@@ -30,6 +35,16 @@ type Config struct {
 	KeyStoreDir      string //keyfile dir
 	PwdFile          string //password  file
 	RpcAddr          string
+
+	ChainID             uint64 //replay protection chain id, overridden by genesis.json
+	AllowUnprotectedTxs bool   //accept transactions signed without a chain id
+	MaxClockSkew        time.Duration
+	ReadyHeartbeats     int           //see DEFAULT_READY_HEARTBEATS, 0 disables the check
+	MaxBlockAge         time.Duration //age of the last block above which the node is not ready, 0 disables the check
+	TraceFile           string        //file the transaction spans are appended to in OTLP/JSON, empty disables tracing
+	TraceSampleRate     float64       //fraction of the transactions traced, between 0 and 1
+	Signer              string        //signer daemon holding the node key, unix://<path> or <host>:<port>, empty to read the key from KeyStoreDir
+	SignerToken         string        //file of the token of the signer daemon, signer.token in KeyStoreDir when empty
 	//TODO add QCP config here
 	P2PNodeConfig   *P2PNodeConfig
 	ConsensusConfig *ConsensusConfig
@@ -138,6 +153,10 @@ func NewConfig(
 		P2PNodeConfig:        P2PNodeConfig,
 		ConsensusConfig:      ConsensusConfig,
 		RpcAddr:              RpcAddr,
+		ChainID:              DEFAULT_CHAIN_ID,
+		MaxClockSkew:         DEFAULT_MAX_CLOCK_SKEW,
+		ReadyHeartbeats:      DEFAULT_READY_HEARTBEATS,
+		TraceSampleRate:      1,
 	}
 }
 
@@ -158,5 +177,9 @@ func DefaultConfig() *Config {
 		P2PNodeConfig:        nil,
 		ConsensusConfig:      nil,
 		RpcAddr:              "127.0.0.1:7000",
+		ChainID:              DEFAULT_CHAIN_ID,
+		MaxClockSkew:         DEFAULT_MAX_CLOCK_SKEW,
+		ReadyHeartbeats:      DEFAULT_READY_HEARTBEATS,
+		TraceSampleRate:      1,
 	}
 }
@@ -0,0 +1,294 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+)
+
+const (
+	MIN_HEARTBEAT = 10 * time.Millisecond
+	MAX_HEARTBEAT = time.Minute
+)
+
+//File is a configuration file of the run command, in JSON. The keys are the
+//names of the flags of the run command, with the same units, grouped in
+//sections. The flags given on the command line override the file. The p2p
+//section, enable_consensus and max_tx_in_block have no flags.
+type File struct {
+	Node      NodeSection      `json:"node"`
+	Network   NetworkSection   `json:"network"`
+	Store     StoreSection     `json:"store"`
+	API       APISection       `json:"api"`
+	Consensus ConsensusSection `json:"consensus"`
+	P2P       P2PSection       `json:"p2p"`
+}
+
+type NodeSection struct {
+	DataDir       string `json:"datadir"`
+	KeyStorePath  string `json:"keystore_path"`
+	PwdPath       string `json:"pwd_path"`
+	LogLevel      string `json:"log_level"`
+	OnlyAccretion bool   `json:"only_accretion"`
+	ChainID       uint64 `json:"chain_id"`
+	Signer        string `json:"signer"`
+	SignerToken   string `json:"signer_token"`
+}
+
+type NetworkSection struct {
+	NodeAddr   string `json:"node_addr"`
+	MaxPool    int    `json:"max_pool"`
+	TCPTimeout int    `json:"tcp_timeout"` //milliseconds
+	SyncLimit  int    `json:"sync_limit"`
+}
+
+type StoreSection struct {
+	Type      string `json:"store"`
+	Path      string `json:"store_path"`
+	CacheSize int    `json:"cache_size"`
+}
+
+type APISection struct {
+	ServiceAddr         string  `json:"service_addr"`
+	SequentiaAddr       string  `json:"seq_address"`
+	RpcAddr             string  `json:"rpc_addr"`
+	Gw2Addr             string  `json:"gw2_addr"`
+	Fn2Addr             string  `json:"fn2_address"`
+	AllowUnprotectedTxs bool    `json:"allow_unprotected_txs"`
+	TraceFile           string  `json:"trace_file"`
+	TraceSampleRate     float64 `json:"trace_sample_rate"`
+}
+
+type ConsensusSection struct {
+	Heartbeat       int `json:"heartbeat"`      //milliseconds
+	MaxClockSkew    int `json:"max_clock_skew"` //milliseconds
+	ReadyHeartbeats int `json:"ready_heartbeats"`
+	MaxBlockAge     int `json:"max_block_age"` //seconds
+
+	EnableConsensus bool `json:"enable_consensus"`
+	MaxTxInBlock    uint `json:"max_tx_in_block"`
+}
+
+//P2PSection is the P2PNodeConfig of the p2p server
+type P2PSection struct {
+	ReservedPeersOnly         bool     `json:"reserved_peers_only"`
+	ReservedPeers             []string `json:"reserved_peers"`
+	MaskPeers                 []string `json:"mask_peers"`
+	NetworkMagic              uint32   `json:"network_magic"`
+	NetworkId                 uint32   `json:"network_id"`
+	NetworkName               string   `json:"network_name"`
+	NodePort                  uint     `json:"node_port"`
+	NodeConsensusPort         uint     `json:"node_consensus_port"`
+	DualPortSupport           bool     `json:"dual_port_support"`
+	IsTLS                     bool     `json:"tls"`
+	CertPath                  string   `json:"cert_path"`
+	KeyPath                   string   `json:"key_path"`
+	CAPath                    string   `json:"ca_path"`
+	HttpInfoPort              uint     `json:"http_info_port"`
+	MaxHdrSyncReqs            uint     `json:"max_hdr_sync_reqs"`
+	MaxConnInBound            uint     `json:"max_conn_in_bound"`
+	MaxConnOutBound           uint     `json:"max_conn_out_bound"`
+	MaxConnInBoundForSingleIP uint     `json:"max_conn_in_bound_for_single_ip"`
+}
+
+//LoadFile reads a configuration file over f: the keys missing from the file
+//keep the values of f. Unknown keys are errors, they are most likely typos.
+func LoadFile(path string, f *File) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(f); err != nil {
+		return fmt.Errorf("%s: %s", path, err)
+	}
+	return nil
+}
+
+//Validate checks the values of the configuration and that the files the node
+//needs at startup exist. All the problems are reported at once.
+func (f *File) Validate() error {
+	var errs []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			errs = append(errs, fmt.Sprintf(format, args...))
+		}
+	}
+
+	//Node
+	check(f.Node.DataDir != "", "node.datadir is required")
+	if _, err := zerolog.ParseLevel(f.Node.LogLevel); err != nil {
+		errs = append(errs, fmt.Sprintf("node.log_level: %s", err))
+	}
+	check(f.Node.ChainID > 0, "node.chain_id should be positive")
+	if f.Node.Signer == "" {
+		keyPath := filepath.Join(f.Node.KeyStorePath, PemKeyPath)
+		if err := checkFile(keyPath); err != nil {
+			errs = append(errs, fmt.Sprintf("node.keystore_path: %s, create the key with initAccount", err))
+		}
+		if err := checkFile(f.Node.PwdPath); err != nil {
+			errs = append(errs, fmt.Sprintf("node.pwd_path: %s", err))
+		}
+	} else if !strings.HasPrefix(f.Node.Signer, "unix://") {
+		if err := checkAddr(strings.TrimPrefix(f.Node.Signer, "http://")); err != nil {
+			errs = append(errs, fmt.Sprintf("node.signer: %s, expected unix://<path> or <host>:<port>", err))
+		}
+	}
+
+	//Network and API addresses, the ones the node listens on are distinct
+	bound := map[string]string{}
+	for _, a := range []struct{ name, addr string }{
+		{"network.node_addr", f.Network.NodeAddr},
+		{"api.seq_address", f.API.SequentiaAddr},
+		{"api.rpc_addr", f.API.RpcAddr},
+	} {
+		if err := checkAddr(a.addr); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %s", a.name, err))
+			continue
+		}
+		if other, ok := bound[a.addr]; ok {
+			errs = append(errs, fmt.Sprintf("%s and %s are both %s", other, a.name, a.addr))
+			continue
+		}
+		bound[a.addr] = a.name
+	}
+	check(f.Network.MaxPool > 0, "network.max_pool should be positive")
+	check(f.Network.TCPTimeout > 0, "network.tcp_timeout should be positive")
+	check(f.Network.SyncLimit > 0, "network.sync_limit should be positive")
+
+	//Store
+	check(f.Store.Type == "badger", "store.store: unsupported store %q, only badger is", f.Store.Type)
+	check(f.Store.Path != "", "store.store_path is required")
+	if info, err := os.Stat(f.Store.Path); err == nil && !info.IsDir() {
+		errs = append(errs, fmt.Sprintf("store.store_path: %s is not a directory", f.Store.Path))
+	}
+	check(f.Store.CacheSize > 0, "store.cache_size should be positive")
+
+	//API
+	check(f.API.TraceSampleRate >= 0 && f.API.TraceSampleRate <= 1,
+		"api.trace_sample_rate should be between 0 and 1")
+
+	//Consensus
+	heartbeat := time.Duration(f.Consensus.Heartbeat) * time.Millisecond
+	check(heartbeat >= MIN_HEARTBEAT && heartbeat <= MAX_HEARTBEAT,
+		"consensus.heartbeat should be between %d and %d milliseconds",
+		MIN_HEARTBEAT/time.Millisecond, MAX_HEARTBEAT/time.Millisecond)
+	check(f.Consensus.MaxClockSkew >= 0, "consensus.max_clock_skew should not be negative")
+	check(f.Consensus.ReadyHeartbeats >= 0, "consensus.ready_heartbeats should not be negative")
+	check(f.Consensus.MaxBlockAge >= 0, "consensus.max_block_age should not be negative")
+
+	//P2P
+	for _, p := range []struct {
+		name string
+		port uint
+	}{
+		{"p2p.node_port", f.P2P.NodePort},
+		{"p2p.node_consensus_port", f.P2P.NodeConsensusPort},
+		{"p2p.http_info_port", f.P2P.HttpInfoPort},
+	} {
+		check(p.port <= 65535, "%s: invalid port %d", p.name, p.port)
+	}
+	check(!f.P2P.DualPortSupport || f.P2P.NodeConsensusPort != 0,
+		"p2p.node_consensus_port is required by p2p.dual_port_support")
+	if f.P2P.IsTLS {
+		for _, file := range []struct{ name, path string }{
+			{"p2p.cert_path", f.P2P.CertPath},
+			{"p2p.key_path", f.P2P.KeyPath},
+			{"p2p.ca_path", f.P2P.CAPath},
+		} {
+			if err := checkFile(file.path); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %s", file.name, err))
+			}
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration:\n  %s", strings.Join(errs, "\n  "))
+	}
+	return nil
+}
+
+func checkFile(path string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+func checkAddr(addr string) error {
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	p, err := strconv.Atoi(port)
+	if err != nil || p <= 0 || p > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+//Config returns the configuration of the node
+func (f *File) Config() *Config {
+	conf := NewConfig(f.Node.OnlyAccretion,
+		time.Duration(f.Consensus.Heartbeat)*time.Millisecond,
+		time.Duration(f.Network.TCPTimeout)*time.Millisecond,
+		f.Store.CacheSize, f.Network.SyncLimit, f.Store.Path,
+		f.API.Gw2Addr, f.API.Fn2Addr, f.API.SequentiaAddr,
+		f.Node.KeyStorePath, f.Node.PwdPath, f.p2pNodeConfig(), f.consensusConfig(), f.API.RpcAddr)
+	conf.ChainID = f.Node.ChainID
+	conf.AllowUnprotectedTxs = f.API.AllowUnprotectedTxs
+	conf.MaxClockSkew = time.Duration(f.Consensus.MaxClockSkew) * time.Millisecond
+	conf.ReadyHeartbeats = f.Consensus.ReadyHeartbeats
+	conf.MaxBlockAge = time.Duration(f.Consensus.MaxBlockAge) * time.Second
+	conf.TraceFile = f.API.TraceFile
+	conf.TraceSampleRate = f.API.TraceSampleRate
+	conf.Signer = f.Node.Signer
+	conf.SignerToken = f.Node.SignerToken
+	return conf
+}
+
+func (f *File) p2pNodeConfig() *P2PNodeConfig {
+	return &P2PNodeConfig{
+		ReservedPeersOnly: f.P2P.ReservedPeersOnly,
+		ReservedCfg: &P2PRsvConfig{
+			ReservedPeers: f.P2P.ReservedPeers,
+			MaskPeers:     f.P2P.MaskPeers,
+		},
+		NetworkMagic:              f.P2P.NetworkMagic,
+		NetworkId:                 f.P2P.NetworkId,
+		NetworkName:               f.P2P.NetworkName,
+		NodePort:                  f.P2P.NodePort,
+		NodeConsensusPort:         f.P2P.NodeConsensusPort,
+		DualPortSupport:           f.P2P.DualPortSupport,
+		IsTLS:                     f.P2P.IsTLS,
+		CertPath:                  f.P2P.CertPath,
+		KeyPath:                   f.P2P.KeyPath,
+		CAPath:                    f.P2P.CAPath,
+		HttpInfoPort:              f.P2P.HttpInfoPort,
+		MaxHdrSyncReqs:            f.P2P.MaxHdrSyncReqs,
+		MaxConnInBound:            f.P2P.MaxConnInBound,
+		MaxConnOutBound:           f.P2P.MaxConnOutBound,
+		MaxConnInBoundForSingleIP: f.P2P.MaxConnInBoundForSingleIP,
+	}
+}
+
+func (f *File) consensusConfig() *ConsensusConfig {
+	return &ConsensusConfig{
+		EnableConsensus: f.Consensus.EnableConsensus,
+		MaxTxInBlock:    f.Consensus.MaxTxInBlock,
+	}
+}
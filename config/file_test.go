@@ -0,0 +1,135 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func validFile(t *testing.T, dir string) *File {
+	for _, name := range []string{PemKeyPath, "pwd"} {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte("x"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &File{
+		Node:      NodeSection{DataDir: dir, KeyStorePath: dir, PwdPath: filepath.Join(dir, "pwd"), LogLevel: "info", ChainID: 1},
+		Network:   NetworkSection{NodeAddr: "127.0.0.1:1337", MaxPool: 2, TCPTimeout: 1000, SyncLimit: 1000},
+		Store:     StoreSection{Type: "badger", Path: filepath.Join(dir, "badger_db"), CacheSize: 500},
+		API:       APISection{SequentiaAddr: "127.0.0.1:8090", RpcAddr: "127.0.0.1:7000", TraceSampleRate: 1},
+		Consensus: ConsensusSection{Heartbeat: 1000, ReadyHeartbeats: 10},
+	}
+}
+
+func TestLoadFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdm_config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f := validFile(t, dir)
+
+	path := filepath.Join(dir, "config.json")
+	content := `{"network": {"max_pool": 5}, "consensus": {"heartbeat": 200}}`
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(path, f); err != nil {
+		t.Fatal(err)
+	}
+	if f.Network.MaxPool != 5 || f.Consensus.Heartbeat != 200 {
+		t.Fatalf("the file values should be loaded, got %+v", f)
+	}
+	if f.Network.NodeAddr != "127.0.0.1:1337" || f.Store.CacheSize != 500 {
+		t.Fatalf("the values missing from the file should be kept, got %+v", f)
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path, []byte(`{"netwrk": {}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(path, f); err == nil {
+		t.Fatal("unknown keys should be rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdm_config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f := validFile(t, dir)
+	f.Node.PwdPath = filepath.Join(dir, "missing")
+	f.API.RpcAddr = f.Network.NodeAddr
+	f.API.SequentiaAddr = "127.0.0.1:70000"
+	f.Consensus.Heartbeat = 1
+
+	err = f.Validate()
+	if err == nil {
+		t.Fatal("the configuration should be invalid")
+	}
+	for _, expected := range []string{"node.pwd_path", "api.rpc_addr", "api.seq_address", "consensus.heartbeat"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s should be reported in %q", expected, err)
+		}
+	}
+}
+
+func TestConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pdm_config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	f := validFile(t, dir)
+
+	path := filepath.Join(dir, "config.json")
+	content := `{
+		"consensus": {"enable_consensus": true, "max_tx_in_block": 500},
+		"p2p": {"reserved_peers_only": true, "reserved_peers": ["10.0.0.1"], "mask_peers": ["10.0.0.2"],
+			"network_magic": 7, "node_port": 20338, "dual_port_support": true, "node_consensus_port": 20339,
+			"max_conn_out_bound": 10}
+	}`
+	if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(path, f); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := f.Config()
+	if c := conf.ConsensusConfig; c == nil || !c.EnableConsensus || c.MaxTxInBlock != 500 {
+		t.Fatalf("consensus config %+v", c)
+	}
+	p2p := conf.P2PNodeConfig
+	if p2p == nil || !p2p.ReservedPeersOnly || p2p.NetworkMagic != 7 || p2p.NodePort != 20338 ||
+		!p2p.DualPortSupport || p2p.NodeConsensusPort != 20339 || p2p.MaxConnOutBound != 10 {
+		t.Fatalf("p2p config %+v", p2p)
+	}
+	if rsv := p2p.ReservedCfg; rsv == nil || len(rsv.ReservedPeers) != 1 || rsv.ReservedPeers[0] != "10.0.0.1" ||
+		len(rsv.MaskPeers) != 1 || rsv.MaskPeers[0] != "10.0.0.2" {
+		t.Fatalf("reserved peers %+v", rsv)
+	}
+
+	f.P2P.NodeConsensusPort = 0
+	f.P2P.NodePort = 70000
+	f.P2P.IsTLS = true
+	f.P2P.CertPath = filepath.Join(dir, "missing")
+	err = f.Validate()
+	if err == nil {
+		t.Fatal("the p2p configuration should be invalid")
+	}
+	for _, expected := range []string{"p2p.node_port", "p2p.node_consensus_port", "p2p.cert_path", "p2p.key_path", "p2p.ca_path"} {
+		if !strings.Contains(err.Error(), expected) {
+			t.Errorf("%s should be reported in %q", expected, err)
+		}
+	}
+}
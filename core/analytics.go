@@ -0,0 +1,260 @@
+package core
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/paradigm-network/paradigm/common/metrics"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+var (
+	participantEvents = metrics.NewCounterVec("paradigm_participant_comets_total",
+		"Number of comets inserted, by creator.", "participant")
+	participantLastIndex = metrics.NewGaugeVec("paradigm_participant_last_index",
+		"Index of the last comet inserted, by creator.", "participant")
+	participantTransactions = metrics.NewCounterVec("paradigm_participant_transactions_total",
+		"Number of transactions carried by the comets of a creator.", "participant")
+	participantBlockSignatures = metrics.NewCounterVec("paradigm_participant_block_signatures_total",
+		"Number of block signatures carried by the comets of a creator.", "participant")
+	participantWitnesses = metrics.NewCounterVec("paradigm_participant_witnesses_total",
+		"Number of witnesses of decided rounds, by creator.", "participant")
+	participantFamousWitnesses = metrics.NewCounterVec("paradigm_participant_famous_witnesses_total",
+		"Number of famous witnesses of decided rounds, by creator.", "participant")
+	participantConsensusLag = metrics.NewHistogramVec("paradigm_participant_consensus_lag_seconds",
+		"Time between the insertion of a comet and its consensus ordering, by creator.",
+		metrics.ExponentialBuckets(0.05, 2, 12), "participant")
+	participantClockSkew = metrics.NewGaugeVec("paradigm_participant_clock_skew_seconds",
+		"Smoothed deviation of the comet timestamps of a creator from their consensus timestamps.", "participant")
+	participantClockSkewWarnings = metrics.NewCounterVec("paradigm_participant_clock_skew_warnings_total",
+		"Number of times the clock skew of a creator went above the maximum.", "participant")
+)
+
+//clockSkewWeight is the weight of the last comet in the smoothed clock skew
+const clockSkewWeight = 0.1
+
+//ParticipantStats describes the activity of a participant as seen by this
+//node. Lags are measured with the local clock, from the insertion of a comet
+//to its ordering, so they do not depend on the creators' clocks.
+//
+//ClockSkew is the deviation of the timestamps claimed by the creator from the
+//consensus timestamps of its comets, smoothed over the last comets. Comets are
+//timestamped before they are gossiped, so a few heartbeats of negative skew are
+//expected from a well synchronised clock.
+type ParticipantStats struct {
+	ID                  int       `json:"id"`
+	PubKey              string    `json:"pubKey"`
+	Comets              int       `json:"comets"`
+	LastIndex           int       `json:"lastIndex"`
+	LastSeen            time.Time `json:"lastSeen"`
+	Transactions        int       `json:"transactions"`
+	BlockSignatures     int       `json:"blockSignatures"`
+	Witnesses           int       `json:"witnesses"`
+	FamousWitnesses     int       `json:"famousWitnesses"`
+	LastWitnessRound    int       `json:"lastWitnessRound"`
+	LastFamousRound     int       `json:"lastFamousRound"`
+	ConsensusComets     int       `json:"consensusComets"`
+	AverageConsensusLag float64   `json:"averageConsensusLag"` //seconds
+	ClockSkew           float64   `json:"clockSkew"`           //seconds
+	MaxClockSkew        float64   `json:"maxClockSkew"`        //seconds, largest absolute deviation of a single comet
+	ClockSkewed         bool      `json:"clockSkewed"`
+
+	totalLag    time.Duration
+	skewSamples int
+}
+
+//analytics computes the ParticipantStats incrementally, from the events of
+//the node: comets inserted, rounds decided and comets ordered.
+type analytics struct {
+	sync.Mutex
+	stats        map[int]*ParticipantStats //[participant id] => stats
+	participants map[string]int            //[PubKey] => participant id
+
+	//local insertion time of the comets that are not ordered yet
+	insertedAt map[string]time.Time
+
+	maxClockSkew time.Duration
+}
+
+func newAnalytics(participants map[string]int, maxClockSkew time.Duration) *analytics {
+	a := &analytics{
+		stats:        make(map[int]*ParticipantStats),
+		participants: participants,
+		insertedAt:   make(map[string]time.Time),
+		maxClockSkew: maxClockSkew,
+	}
+	for pk, id := range participants {
+		a.stats[id] = &ParticipantStats{
+			ID:               id,
+			PubKey:           pk,
+			LastIndex:        -1,
+			LastWitnessRound: -1,
+			LastFamousRound:  -1,
+		}
+	}
+	return a
+}
+
+func (a *analytics) setMaxClockSkew(maxClockSkew time.Duration) {
+	a.Lock()
+	defer a.Unlock()
+	a.maxClockSkew = maxClockSkew
+}
+
+//participant returns the stats of the creator of a comet
+func (a *analytics) participant(creator string) (*ParticipantStats, bool) {
+	id, ok := a.participants[creator]
+	if !ok {
+		return nil, false
+	}
+	s, ok := a.stats[id]
+	return s, ok
+}
+
+func (a *analytics) cometInserted(comet types.Comet) {
+	a.Lock()
+	defer a.Unlock()
+	s, ok := a.participant(comet.Creator())
+	if !ok {
+		return
+	}
+	now := time.Now()
+	label := strconv.Itoa(s.ID)
+
+	s.Comets++
+	if comet.Index() > s.LastIndex {
+		s.LastIndex = comet.Index()
+	}
+	s.LastSeen = now
+	s.Transactions += len(comet.Transactions())
+	s.BlockSignatures += len(comet.Body.BlockSignatures)
+	a.insertedAt[comet.Hex()] = now
+
+	participantEvents.WithLabelValues(label).Inc()
+	participantLastIndex.WithLabelValues(label).Set(float64(s.LastIndex))
+	participantTransactions.WithLabelValues(label).Add(float64(len(comet.Transactions())))
+	participantBlockSignatures.WithLabelValues(label).Add(float64(len(comet.Body.BlockSignatures)))
+}
+
+//roundDecided counts the witnesses of the round. witnesses maps the witnesses
+//to their creator.
+func (a *analytics) roundDecided(round int, witnesses map[string]string, famous []string) {
+	a.Lock()
+	defer a.Unlock()
+	isFamous := make(map[string]bool, len(famous))
+	for _, hash := range famous {
+		isFamous[hash] = true
+	}
+	for hash, creator := range witnesses {
+		s, ok := a.participant(creator)
+		if !ok {
+			continue
+		}
+		label := strconv.Itoa(s.ID)
+		s.Witnesses++
+		if round > s.LastWitnessRound {
+			s.LastWitnessRound = round
+		}
+		participantWitnesses.WithLabelValues(label).Inc()
+		if isFamous[hash] {
+			s.FamousWitnesses++
+			if round > s.LastFamousRound {
+				s.LastFamousRound = round
+			}
+			participantFamousWitnesses.WithLabelValues(label).Inc()
+		}
+	}
+}
+
+//cometOrdered accounts for a comet that just received its consensus
+//timestamp. It returns the stats of the creator and whether its clock just
+//went above or back under the maximum skew.
+func (a *analytics) cometOrdered(comet types.Comet) (stats ParticipantStats, changed bool) {
+	a.Lock()
+	defer a.Unlock()
+	s, ok := a.participant(comet.Creator())
+	if !ok {
+		return ParticipantStats{}, false
+	}
+	label := strconv.Itoa(s.ID)
+
+	if insertedAt, ok := a.insertedAt[comet.Hex()]; ok {
+		delete(a.insertedAt, comet.Hex())
+		lag := time.Since(insertedAt)
+		s.ConsensusComets++
+		s.totalLag += lag
+		participantConsensusLag.WithLabelValues(label).Observe(lag.Seconds())
+	}
+
+	//The initial comets have no timestamp
+	if comet.Body.Timestamp.IsZero() || comet.ConsensusTimestamp.IsZero() {
+		return *s, false
+	}
+	skew := comet.Body.Timestamp.Sub(comet.ConsensusTimestamp).Seconds()
+	if s.skewSamples == 0 {
+		s.ClockSkew = skew
+	} else {
+		s.ClockSkew += clockSkewWeight * (skew - s.ClockSkew)
+	}
+	s.skewSamples++
+	if math.Abs(skew) > s.MaxClockSkew {
+		s.MaxClockSkew = math.Abs(skew)
+	}
+	participantClockSkew.WithLabelValues(label).Set(s.ClockSkew)
+
+	skewed := a.maxClockSkew > 0 && math.Abs(s.ClockSkew) > a.maxClockSkew.Seconds()
+	if skewed == s.ClockSkewed {
+		return *s, false
+	}
+	s.ClockSkewed = skewed
+	if skewed {
+		participantClockSkewWarnings.WithLabelValues(label).Inc()
+	}
+	return *s, true
+}
+
+//cometsSkipped forgets the insertion time of the comets that were ordered
+//without a cometOrdered, keeping those of the undetermined comets
+func (a *analytics) cometsSkipped(undetermined []string) {
+	a.Lock()
+	defer a.Unlock()
+	pending := make(map[string]time.Time, len(undetermined))
+	for _, hash := range undetermined {
+		if t, ok := a.insertedAt[hash]; ok {
+			pending[hash] = t
+		}
+	}
+	a.insertedAt = pending
+}
+
+//clockSkewedCount returns the number of participants whose clock is skewed
+func (a *analytics) clockSkewedCount() int {
+	a.Lock()
+	defer a.Unlock()
+	count := 0
+	for _, s := range a.stats {
+		if s.ClockSkewed {
+			count++
+		}
+	}
+	return count
+}
+
+//list returns a copy of the stats, sorted by participant id
+func (a *analytics) list() []ParticipantStats {
+	a.Lock()
+	defer a.Unlock()
+	res := make([]ParticipantStats, 0, len(a.stats))
+	for _, s := range a.stats {
+		c := *s
+		if c.ConsensusComets > 0 {
+			c.AverageConsensusLag = (c.totalLag / time.Duration(c.ConsensusComets)).Seconds()
+		}
+		res = append(res, c)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res
+}
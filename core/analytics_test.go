@@ -0,0 +1,43 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func TestAnalyticsCometsSkipped(t *testing.T) {
+	a := newAnalytics(map[string]int{"0x0A": 1, "0x0B": 0}, time.Second)
+
+	var comets []types.Comet
+	for i := 0; i < 3; i++ {
+		comet := types.Comet{Body: types.CometBody{Creator: []byte{0x0A}, Index: i}}
+		a.cometInserted(comet)
+		comets = append(comets, comet)
+	}
+	//a comet of an unknown creator is not accounted for
+	a.cometInserted(types.Comet{Body: types.CometBody{Creator: []byte{0x0C}}})
+
+	a.cometOrdered(comets[0])
+	//comets[1] was ordered out of the consensus window
+	a.cometsSkipped([]string{comets[2].Hex()})
+
+	if len(a.insertedAt) != 1 {
+		t.Fatalf("%d insertion times kept, expected 1", len(a.insertedAt))
+	}
+	if _, ok := a.insertedAt[comets[2].Hex()]; !ok {
+		t.Fatal("lost the insertion time of an undetermined comet")
+	}
+
+	stats := a.list()
+	if len(stats) != 2 || stats[0].ID != 0 || stats[1].ID != 1 {
+		t.Fatalf("stats %+v", stats)
+	}
+	if stats[1].PubKey != "0x0A" || stats[1].Comets != 3 || stats[1].ConsensusComets != 1 || stats[1].LastIndex != 2 {
+		t.Fatalf("stats of participant 1 %+v", stats[1])
+	}
+	if stats[0].Comets != 0 {
+		t.Fatalf("stats of participant 0 %+v", stats[0])
+	}
+}
@@ -1,13 +1,14 @@
 package core
 
 import (
-	"crypto/ecdsa"
+	"bytes"
 	"fmt"
 	"sort"
 	"time"
 
-	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/config"
 	"github.com/paradigm-network/paradigm/core/sequentia"
+	"github.com/paradigm-network/paradigm/signer"
 	"github.com/paradigm-network/paradigm/types"
 	"github.com/paradigm-network/paradigm/storage"
 	"github.com/paradigm-network/paradigm/common/log"
@@ -15,56 +16,76 @@ import (
 )
 
 type Core struct {
-	id     int
-	key    *ecdsa.PrivateKey
-	pubKey []byte
-	hexID  string
-	cg     *sequentia.CometGraph
-
-	participants        map[string]int //[PubKey] => id
-	reverseParticipants map[int]string //[id] => PubKey
+	id      int
+	signer  signer.Signer   //holds the key of the participant slot of the node
+	keyring *signer.Keyring //holds the keys the node rotates to, nil without rotations
+	pubKey  []byte
+	hexID   string
+	cg      *sequentia.CometGraph
+
+	participants        map[string]int //[PubKey] => id, every key of the history of the slot, see sequentia.KeyHistory
+	reverseParticipants map[int]string //[id] => PubKey the participant signs the last round with
 	Head                string
 	Seq                 int
 
 	transactionPool    [][]byte
 	blockSignaturePool []types.BlockSignature
 
+	feeds     *nodeFeeds
+	analytics *analytics
+	tracer    *txTracer
+
 	logger *zerolog.Logger
 }
 
 func NewCore(
 	id int,
-	key *ecdsa.PrivateKey,
+	signer signer.Signer,
 	participants map[string]int,
 	store storage.Store,
 	commitCh chan types.Block,
 	) Core {
 
-	reverseParticipants := make(map[int]string)
-	for pk, id := range participants {
-		reverseParticipants[id] = pk
-	}
-
 	core := Core{
 		id:                  id,
-		key:                 key,
+		signer:              signer,
 		cg:                  sequentia.BuildCometGraph(participants, store, commitCh),
-		participants:        participants,
-		reverseParticipants: reverseParticipants,
 		transactionPool:     [][]byte{},
 		blockSignaturePool:  []types.BlockSignature{},
+		feeds:               newNodeFeeds(),
+		analytics:           newAnalytics(participants, config.DEFAULT_MAX_CLOCK_SKEW),
+		tracer:              newTxTracer(),
 		logger:              log.GetLogger("Core"),
 	}
+	core.updateParticipants()
 	return core
 }
 
+//updateParticipants follows the key rotations applied by the consensus: the
+//participants resolve every key a participant signed with, the reverse ones
+//give the key it signs the last round with. The comets, and the participant
+//events and roots of the Store, stay keyed by the slot keys of the
+//CometGraph's Participants.
+func (c *Core) updateParticipants() {
+	round := c.cg.Store.LastRound()
+	history := c.cg.Keys.Snapshot()
+	c.participants = make(map[string]int)
+	c.reverseParticipants = make(map[int]string, len(history))
+	for id, changes := range history {
+		for _, change := range changes {
+			c.participants[fmt.Sprintf("0x%X", change.Key)] = id
+		}
+		c.reverseParticipants[id] = fmt.Sprintf("0x%X", c.cg.Keys.KeyAt(id, round))
+	}
+}
+
 func (c *Core) ID() int {
 	return c.id
 }
 
 func (c *Core) PubKey() []byte {
 	if c.pubKey == nil {
-		c.pubKey = crypto.FromECDSAPub(&c.key.PublicKey)
+		c.pubKey = c.signer.PubKey()
 	}
 	return c.pubKey
 }
@@ -99,6 +120,7 @@ func (c *Core) Bootstrap() error {
 	if err := c.cg.Bootstrap(); err != nil {
 		return err
 	}
+	c.updateParticipants()
 
 	var head string
 	var seq int
@@ -129,12 +151,36 @@ func (c *Core) Bootstrap() error {
 	return nil
 }
 
+//SetKeyring sets the keyring of the keys the node rotates to. The node signs
+//with them once their rotation is active.
+func (c *Core) SetKeyring(keyring *signer.Keyring) {
+	c.keyring = keyring
+}
+
+//signerAt returns the signer of the key of the node at round
+func (c *Core) signerAt(round int) (signer.Signer, error) {
+	key := c.cg.Keys.KeyAt(c.id, round)
+	if bytes.Equal(key, c.PubKey()) {
+		return c.signer, nil
+	}
+	if c.keyring == nil {
+		return nil, fmt.Errorf("no keyring holding the key 0x%X, active at round %d", key, round)
+	}
+	return c.keyring.Signer(key)
+}
+
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func (c *Core) SignAndInsertSelfEvent(event types.Comet) error {
-	if err := event.Sign(c.key); err != nil {
+	s, err := c.signerAt(c.cg.SigningRound(event))
+	if err != nil {
+		return err
+	}
+	sig, err := s.SignComet(event.Body)
+	if err != nil {
 		return err
 	}
+	event.Signature = sig
 	if err := c.InsertEvent(event, true); err != nil {
 		return err
 	}
@@ -145,10 +191,12 @@ func (c *Core) InsertEvent(event types.Comet, setWireInfo bool) error {
 	if err := c.cg.InsertComet(event, setWireInfo); err != nil {
 		return err
 	}
-	if event.Creator() == c.HexID() {
+	self := event.Creator() == c.HexID()
+	if self {
 		c.Head = event.Hex()
 		c.Seq = event.Index()
 	}
+	c.feeds.post(CometInsertedEvent{Comet: event, Self: self})
 	return nil
 }
 
@@ -159,10 +207,20 @@ func (c *Core) KnownEvents() map[int]int {
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 func (c *Core) SignBlock(block types.Block) (types.BlockSignature, error) {
-	sig, err := block.Sign(c.key)
+	//sign with the key of the next self comet, which carries the signature
+	s, err := c.signerAt(c.cg.NextSigningRound(c.HexID(), c.Head))
 	if err != nil {
 		return types.BlockSignature{}, err
 	}
+	signature, err := s.SignBlock(block.Body)
+	if err != nil {
+		return types.BlockSignature{}, err
+	}
+	sig := types.BlockSignature{
+		Validator: c.PubKey(),
+		Index:     block.Index(),
+		Signature: signature,
+	}
 	if err := block.SetSignature(sig); err != nil {
 		return types.BlockSignature{}, err
 	}
@@ -196,7 +254,7 @@ func (c *Core) EventDiff(known map[int]int) (events []types.Comet, err error) {
 	//compare this to our view of events and fill unknown with events that we know of
 	// and the other doesnt
 	for id, ct := range known {
-		pk := c.reverseParticipants[id]
+		pk := c.cg.ReverseParticipants[id]
 		//get participant Events with index > ct
 		participantEvents, err := c.cg.Store.ParticipantEvents(pk, ct)
 		if err != nil {
@@ -227,7 +285,16 @@ func (c *Core) Sync(unknownEvents []types.WireEvent) error {
 		if err != nil {
 			return err
 		}
-		if err := c.InsertEvent(*ev, false); err != nil {
+		err = c.InsertEvent(*ev, false)
+		if err == sequentia.ErrInvalidSignature && c.cg.RotationPending(*ev) {
+			//The event may be signed with a rotated key, known once the
+			//events before it are ordered
+			if err := c.RunConsensus(); err != nil {
+				return err
+			}
+			err = c.InsertEvent(*ev, false)
+		}
+		if err != nil {
 			return err
 		}
 		//assume last event corresponds to other-head
@@ -254,6 +321,7 @@ func (c *Core) Sync(unknownEvents []types.WireEvent) error {
 		//empty the pools
 		c.transactionPool = [][]byte{}
 		c.blockSignaturePool = []types.BlockSignature{}
+		mempoolSize.Set(0)
 	}
 
 	return nil
@@ -282,6 +350,7 @@ func (c *Core) AddSelfEvent() error {
 		Msg("Created Self-Event")
 	c.transactionPool = [][]byte{}
 	c.blockSignaturePool = []types.BlockSignature{}
+	mempoolSize.Set(0)
 
 	return nil
 }
@@ -315,6 +384,7 @@ func (c *Core) RunConsensus() error {
 		return err
 	}
 
+	undecidedRounds := append([]int{}, c.cg.UndecidedRounds...)
 	start = time.Now()
 	err = c.cg.DecideFame()
 	c.logger.Debug().Int64("duration",time.Since(start).Nanoseconds()).Msg("DecideFame()")
@@ -322,7 +392,9 @@ func (c *Core) RunConsensus() error {
 		c.logger.Error().Err(err).Msg("DecideFame")
 		return err
 	}
+	c.postDecidedRounds(undecidedRounds)
 
+	consensusEvents := c.cg.Store.ConsensusEventsCount()
 	start = time.Now()
 	err = c.cg.FindOrder()
 	c.logger.Debug().Int64("duration",time.Since(start).Nanoseconds()).Msg("FindOrder()")
@@ -330,12 +402,86 @@ func (c *Core) RunConsensus() error {
 		c.logger.Error().Err(err).Msg("FindOrder")
 		return err
 	}
+	c.postOrderedEvents(c.cg.Store.ConsensusEventsCount() - consensusEvents)
+	//the ordered comets may have applied key rotations
+	c.updateParticipants()
 
 	return nil
 }
 
+//postDecidedRounds posts a RoundDecidedEvent for every round of undecided that
+//DecideFame removed from the undecided rounds
+func (c *Core) postDecidedRounds(undecided []int) {
+	stillUndecided := make(map[int]bool, len(c.cg.UndecidedRounds))
+	for _, r := range c.cg.UndecidedRounds {
+		stillUndecided[r] = true
+	}
+	for _, r := range undecided {
+		if stillUndecided[r] {
+			continue
+		}
+		roundInfo, err := c.cg.Store.GetRound(r)
+		if err != nil {
+			c.logger.Error().Err(err).Int("round", r).Msg("Posting decided round")
+			continue
+		}
+		c.feeds.post(RoundDecidedEvent{
+			Round:           r,
+			Witnesses:       c.witnessCreators(roundInfo),
+			FamousWitnesses: roundInfo.FamousWitnesses(),
+		})
+	}
+}
+
+//witnessCreators maps the witnesses of the round to their creator
+func (c *Core) witnessCreators(roundInfo types.RoundInfo) map[string]string {
+	creators := make(map[string]string)
+	for _, w := range roundInfo.Witnesses() {
+		if comet, err := c.cg.Store.GetComet(w); err == nil {
+			creators[w] = comet.Creator()
+		}
+	}
+	return creators
+}
+
+//postOrderedEvents posts a CometOrderedEvent for the last count consensus
+//events. Only the events still in the Store's consensus window can be posted,
+//a CometsSkippedEvent accounts for the others.
+func (c *Core) postOrderedEvents(count int) {
+	window := c.cg.Store.ConsensusEvents()
+	skipped := 0
+	if count > len(window) {
+		skipped = count - len(window)
+		count = len(window)
+	}
+	for _, hash := range window[len(window)-count:] {
+		comet, err := c.cg.Store.GetComet(hash)
+		if err != nil {
+			continue
+		}
+		c.feeds.post(CometOrderedEvent{Comet: comet})
+	}
+	if skipped > 0 {
+		undetermined := make([]string, len(c.cg.UndeterminedEvents))
+		copy(undetermined, c.cg.UndeterminedEvents)
+		c.feeds.post(CometsSkippedEvent{Count: skipped, Undetermined: undetermined})
+	}
+}
+
+//SetMaxClockSkew sets the smoothed clock skew above which a participant is
+//reported. Zero disables the reports.
+func (c *Core) SetMaxClockSkew(maxClockSkew time.Duration) {
+	c.analytics.setMaxClockSkew(maxClockSkew)
+}
+
+func (c *Core) GetParticipantStats() []ParticipantStats {
+	return c.analytics.list()
+}
+
 func (c *Core) AddTransactions(txs [][]byte) {
 	c.transactionPool = append(c.transactionPool, txs...)
+	mempoolSize.Set(float64(len(c.transactionPool)))
+	c.tracer.txsPooled(txs)
 }
 
 func (c *Core) AddBlockSignature(bs types.BlockSignature) {
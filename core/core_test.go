@@ -0,0 +1,59 @@
+package core
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/core/sequentia"
+	"github.com/paradigm-network/paradigm/storage"
+)
+
+//roundStore is a Store at round lastRound
+type roundStore struct {
+	storage.Store
+	lastRound int
+}
+
+func (s *roundStore) LastRound() int {
+	return s.lastRound
+}
+
+func TestUpdateParticipants(t *testing.T) {
+	slot, rotated := []byte{0x04, 0x01}, []byte{0x04, 0x03}
+	participants := map[string]int{"0x0401": 0, "0x0402": 1}
+	store := &roundStore{lastRound: 4}
+	c := &Core{cg: &sequentia.CometGraph{
+		Participants:        participants,
+		ReverseParticipants: map[int]string{0: "0x0401", 1: "0x0402"},
+		Keys:                sequentia.NewKeyHistory(participants),
+		Store:               store,
+	}}
+	c.updateParticipants()
+	if c.reverseParticipants[0] != "0x0401" || len(c.participants) != 2 {
+		t.Fatalf("participants %v, reverse %v", c.participants, c.reverseParticipants)
+	}
+
+	//the rotation applied by the consensus signs from round 5
+	if err := c.cg.Keys.Rotate(0, 5, rotated); err != nil {
+		t.Fatal(err)
+	}
+	c.updateParticipants()
+	if c.reverseParticipants[0] != fmt.Sprintf("0x%X", slot) {
+		t.Fatalf("participant 0 signs round 4 with %s", c.reverseParticipants[0])
+	}
+	store.lastRound = 5
+	c.updateParticipants()
+	if c.reverseParticipants[0] != fmt.Sprintf("0x%X", rotated) || c.reverseParticipants[1] != "0x0402" {
+		t.Fatalf("reverse participants %v after the rotation", c.reverseParticipants)
+	}
+	//every key of the history resolves to the slot
+	for pk, id := range map[string]int{"0x0401": 0, "0x0403": 0, "0x0402": 1} {
+		if got, ok := c.participants[pk]; !ok || got != id {
+			t.Fatalf("participant of %s: %d, expected %d", pk, got, id)
+		}
+	}
+	//the Store stays keyed by the slot keys
+	if _, ok := participants["0x0403"]; ok || c.cg.ReverseParticipants[0] != "0x0401" {
+		t.Fatalf("slot keys changed: %v", c.cg.ReverseParticipants)
+	}
+}
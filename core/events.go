@@ -0,0 +1,267 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paradigm-network/paradigm/common/event"
+	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/proxy"
+	"github.com/paradigm-network/paradigm/types"
+	"github.com/rs/zerolog"
+)
+
+//nodeFeedsQueueSize is the number of events waiting for dispatch beyond which
+//the new events are dropped
+const nodeFeedsQueueSize = 4096
+
+//CometInsertedEvent is posted when a comet is inserted in the CometGraph,
+//whether this node created it (Self) or received it through gossip.
+type CometInsertedEvent struct {
+	Comet types.Comet
+	Self  bool
+}
+
+//RoundDecidedEvent is posted when the fame of all the witnesses of a round
+//has been decided.
+type RoundDecidedEvent struct {
+	Round           int
+	Witnesses       map[string]string //[witness hash] => creator
+	FamousWitnesses []string
+}
+
+//CometOrderedEvent is posted when a comet received its consensus timestamp
+type CometOrderedEvent struct {
+	Comet types.Comet
+}
+
+//CometsSkippedEvent is posted when more comets were ordered at once than the
+//Store's consensus window holds: no CometOrderedEvent was posted for Count of
+//them. Undetermined lists the comets that are still not ordered.
+type CometsSkippedEvent struct {
+	Count        int
+	Undetermined []string
+}
+
+//BlockCommittedEvent is posted when consensus produced a block, before it is
+//handed to the application.
+type BlockCommittedEvent struct {
+	Block types.Block
+}
+
+//StateCommittedEvent is posted once the application processed a block. Err is
+//the error returned by the application, if any. Start and End time the
+//CommitBlock call.
+type StateCommittedEvent struct {
+	Block      types.Block
+	StateHash  []byte
+	Err        error
+	Start, End time.Time
+}
+
+//BlockSignedEvent is posted when this node signed a block
+type BlockSignedEvent struct {
+	Block     types.Block
+	Signature types.BlockSignature
+}
+
+//nodeFeeds carries the activity of the node to its subscribers. Events are
+//queued by post, under coreLock where they happen, and sent in order by
+//dispatch: neither consensus nor coreLock wait for a subscriber. The next
+//event is only sent once every subscriber received the previous one, so a
+//subscriber reading several feeds sees the events in order. A slow
+//subscriber lets the queue grow up to nodeFeedsQueueSize, after which the
+//events are dropped until dispatch catches up.
+type nodeFeeds struct {
+	cometInserted  event.Feed
+	roundDecided   event.Feed
+	cometOrdered   event.Feed
+	cometsSkipped  event.Feed
+	blockCommitted event.Feed
+	stateCommitted event.Feed
+	blockSigned    event.Feed
+
+	scope event.SubscriptionScope
+
+	mu      sync.Mutex
+	queue   []interface{}
+	dropped int //events dropped since the queue was last taken
+	wake    chan struct{}
+
+	logger *zerolog.Logger
+}
+
+func newNodeFeeds() *nodeFeeds {
+	return &nodeFeeds{
+		wake:   make(chan struct{}, 1),
+		logger: log.GetLogger("NodeFeeds"),
+	}
+}
+
+//post queues ev for dispatch, or drops it if the queue is full
+func (f *nodeFeeds) post(ev interface{}) {
+	f.mu.Lock()
+	if len(f.queue) >= nodeFeedsQueueSize {
+		if f.dropped == 0 {
+			f.logger.Warn().Msg("Node event queue full, dropping events")
+		}
+		f.dropped++
+	} else {
+		f.queue = append(f.queue, ev)
+	}
+	f.mu.Unlock()
+	select {
+	case f.wake <- struct{}{}:
+	default:
+	}
+}
+
+//dispatch sends the queued events until quit is closed. The events still
+//queued then are dropped.
+func (f *nodeFeeds) dispatch(quit <-chan struct{}) {
+	for {
+		select {
+		case <-f.wake:
+		case <-quit:
+			return
+		}
+		f.mu.Lock()
+		queue, dropped := f.queue, f.dropped
+		f.queue, f.dropped = nil, 0
+		f.mu.Unlock()
+		if dropped > 0 {
+			f.logger.Warn().Int("dropped", dropped).Msg("Node events dropped by a slow subscriber")
+		}
+		for _, ev := range queue {
+			f.send(ev)
+		}
+	}
+}
+
+func (f *nodeFeeds) send(ev interface{}) {
+	switch ev := ev.(type) {
+	case CometInsertedEvent:
+		f.cometInserted.Send(ev)
+	case RoundDecidedEvent:
+		f.roundDecided.Send(ev)
+	case CometOrderedEvent:
+		f.cometOrdered.Send(ev)
+	case CometsSkippedEvent:
+		f.cometsSkipped.Send(ev)
+	case BlockCommittedEvent:
+		f.blockCommitted.Send(ev)
+	case StateCommittedEvent:
+		f.stateCommitted.Send(ev)
+	case BlockSignedEvent:
+		f.blockSigned.Send(ev)
+	}
+}
+
+func (n *Node) SubscribeCometInserted(ch chan<- CometInsertedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.cometInserted.Subscribe(ch))
+}
+
+func (n *Node) SubscribeRoundDecided(ch chan<- RoundDecidedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.roundDecided.Subscribe(ch))
+}
+
+func (n *Node) SubscribeCometOrdered(ch chan<- CometOrderedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.cometOrdered.Subscribe(ch))
+}
+
+func (n *Node) SubscribeCometsSkipped(ch chan<- CometsSkippedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.cometsSkipped.Subscribe(ch))
+}
+
+func (n *Node) SubscribeBlockCommitted(ch chan<- BlockCommittedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.blockCommitted.Subscribe(ch))
+}
+
+func (n *Node) SubscribeStateCommitted(ch chan<- StateCommittedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.stateCommitted.Subscribe(ch))
+}
+
+func (n *Node) SubscribeBlockSigned(ch chan<- BlockSignedEvent) event.Subscription {
+	return n.core.feeds.scope.Track(n.core.feeds.blockSigned.Subscribe(ch))
+}
+
+//observe feeds the metrics, the analytics, the health tracker and the tracer
+//with the events of the node, until the subscriptions end. The channels are
+//unbuffered, so the events are handled in the order they were posted.
+func (n *Node) observe() {
+	inserted := make(chan CometInsertedEvent)
+	decided := make(chan RoundDecidedEvent)
+	ordered := make(chan CometOrderedEvent)
+	skipped := make(chan CometsSkippedEvent)
+	committed := make(chan BlockCommittedEvent)
+	stateCommitted := make(chan StateCommittedEvent)
+	subs := []event.Subscription{
+		n.SubscribeCometInserted(inserted),
+		n.SubscribeRoundDecided(decided),
+		n.SubscribeCometOrdered(ordered),
+		n.SubscribeCometsSkipped(skipped),
+		n.SubscribeBlockCommitted(committed),
+		n.SubscribeStateCommitted(stateCommitted),
+	}
+	defer func() {
+		for _, sub := range subs {
+			sub.Unsubscribe()
+		}
+	}()
+
+	for {
+		select {
+		case ev := <-inserted:
+			cometsInserted.Inc()
+			n.core.analytics.cometInserted(ev.Comet)
+			n.core.tracer.cometInserted(ev.Comet, ev.Self)
+		case ev := <-decided:
+			roundsDecided.Inc()
+			n.core.analytics.roundDecided(ev.Round, ev.Witnesses, ev.FamousWitnesses)
+		case ev := <-ordered:
+			n.core.tracer.cometOrdered(ev.Comet)
+			n.cometOrdered(ev.Comet)
+		case ev := <-skipped:
+			n.core.analytics.cometsSkipped(ev.Undetermined)
+		case ev := <-committed:
+			blocksCommitted.Inc()
+			n.health.blockCommitted(ev.Block.Index())
+		case ev := <-stateCommitted:
+			n.core.tracer.blockCommitted(ev.Block, ev.Start, ev.End)
+		case <-subs[0].Err():
+			return
+		}
+	}
+}
+
+//publishComets hands the inserted comets over to the WebSocket clients of p,
+//until the subscription ends
+func (n *Node) publishComets(p *proxy.InmemAppProxy) {
+	ch := make(chan CometInsertedEvent)
+	sub := n.SubscribeCometInserted(ch)
+	defer sub.Unsubscribe()
+	for {
+		select {
+		case ev := <-ch:
+			p.PublishComet(ev.Comet, ev.Self)
+		case <-sub.Err():
+			return
+		}
+	}
+}
+
+//cometOrdered accounts for an ordered comet in the analytics, and reports the
+//participants whose clock goes above or back under the maximum skew
+func (n *Node) cometOrdered(comet types.Comet) {
+	stats, changed := n.core.analytics.cometOrdered(comet)
+	if !changed {
+		return
+	}
+	if stats.ClockSkewed {
+		n.logger.Warn().Int("participant", stats.ID).Float64("clock_skew", stats.ClockSkew).
+			Msg("Participant clock is skewed")
+	} else {
+		n.logger.Info().Int("participant", stats.ID).Float64("clock_skew", stats.ClockSkew).
+			Msg("Participant clock is back in sync")
+	}
+}
@@ -0,0 +1,104 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func TestNodeFeedsOrder(t *testing.T) {
+	f := newNodeFeeds()
+	inserted := make(chan CometInsertedEvent)
+	ordered := make(chan CometOrderedEvent)
+	f.scope.Track(f.cometInserted.Subscribe(inserted))
+	f.scope.Track(f.cometOrdered.Subscribe(ordered))
+
+	//posting does not wait for the subscribers
+	for i := 0; i < 5; i++ {
+		comet := types.Comet{Body: types.CometBody{Index: i}}
+		f.post(CometInsertedEvent{Comet: comet})
+		f.post(CometOrderedEvent{Comet: comet})
+	}
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		f.dispatch(quit)
+		close(done)
+	}()
+
+	for i := 0; i < 10; i++ {
+		var index int
+		var isOrdered bool
+		select {
+		case ev := <-inserted:
+			index = ev.Comet.Index()
+		case ev := <-ordered:
+			index, isOrdered = ev.Comet.Index(), true
+		case <-time.After(time.Second):
+			t.Fatalf("event %d not sent", i)
+		}
+		if index != i/2 || isOrdered != (i%2 == 1) {
+			t.Fatalf("event %d: comet %d, ordered %v", i, index, isOrdered)
+		}
+	}
+
+	//a subscriber that stopped reading does not hold back the shutdown
+	f.post(CometInsertedEvent{})
+	time.Sleep(10 * time.Millisecond)
+	f.scope.Close()
+	close(quit)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("dispatch did not return")
+	}
+}
+
+func TestNodeFeedsBounded(t *testing.T) {
+	f := newNodeFeeds()
+	blocked := make(chan CometInsertedEvent)
+	f.scope.Track(f.cometInserted.Subscribe(blocked))
+
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		f.dispatch(quit)
+		close(done)
+	}()
+
+	//the first event holds dispatch on the subscriber, which never reads
+	f.post(CometInsertedEvent{})
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 2*nodeFeedsQueueSize; i++ {
+		f.post(CometInsertedEvent{Comet: types.Comet{Body: types.CometBody{Index: i}}})
+	}
+
+	f.mu.Lock()
+	queued, dropped := len(f.queue), f.dropped
+	f.mu.Unlock()
+	if queued != nodeFeedsQueueSize || dropped != nodeFeedsQueueSize {
+		t.Fatalf("%d events queued, %d dropped", queued, dropped)
+	}
+
+	//the oldest events are kept
+	ev := <-blocked
+	if ev.Comet.Index() != 0 {
+		t.Fatalf("first event: comet %d", ev.Comet.Index())
+	}
+	ev = <-blocked
+	if ev.Comet.Index() != 0 {
+		t.Fatalf("second event: comet %d", ev.Comet.Index())
+	}
+	f.mu.Lock()
+	dropped = f.dropped
+	f.mu.Unlock()
+	if dropped != 0 {
+		t.Fatalf("%d events dropped after dispatch caught up", dropped)
+	}
+
+	f.scope.Close()
+	close(quit)
+	<-done
+}
@@ -0,0 +1,129 @@
+package core
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+//Reasons for which a node is not alive or not ready. They are meant to be
+//matched by orchestrators, the Message of a Health gives the details.
+const (
+	ReasonShutdown         = "shutdown"
+	ReasonStoreUnavailable = "store_unavailable"
+	ReasonCatchingUp       = "catching_up"
+	ReasonGossipStalled    = "gossip_stalled"
+	ReasonStaleBlock       = "stale_block"
+)
+
+//Health is the answer to a liveness or readiness probe. Reason is empty when
+//the probe succeeds.
+type Health struct {
+	OK             bool      `json:"ok"`
+	Reason         string    `json:"reason,omitempty"`
+	Message        string    `json:"message,omitempty"`
+	State          string    `json:"state"`
+	LastGossip     time.Time `json:"lastGossip"`
+	LastBlockIndex int       `json:"lastBlockIndex"`
+	LastBlock      time.Time `json:"lastBlock"`
+}
+
+//healthTracker records the progress of the node, as seen by the probes
+type healthTracker struct {
+	sync.Mutex
+	lastGossip     time.Time
+	failingSince   time.Time //first failed gossip since lastGossip
+	lastBlockIndex int
+	lastBlock      time.Time
+}
+
+func (h *healthTracker) gossipDone(err error) {
+	h.Lock()
+	defer h.Unlock()
+	if err == nil {
+		h.lastGossip = time.Now()
+		h.failingSince = time.Time{}
+	} else if h.failingSince.IsZero() {
+		h.failingSince = time.Now()
+	}
+}
+
+func (h *healthTracker) blockCommitted(index int) {
+	h.Lock()
+	defer h.Unlock()
+	h.lastBlockIndex = index
+	h.lastBlock = time.Now()
+}
+
+//Liveness succeeds until the node is shut down
+func (n *Node) Liveness() Health {
+	health := n.newHealth()
+	if n.getState() == Shutdown {
+		health.Reason = ReasonShutdown
+		health.Message = "node is shut down"
+	}
+	health.OK = health.Reason == ""
+	return health
+}
+
+//Readiness succeeds when the node is making progress. It fails while the node
+//is catching up, when gossiping has been failing for conf.ReadyHeartbeats
+//heartbeats, when the last block is older than conf.MaxBlockAge, or when the
+//Store cannot be read. An idle node does not gossip, so only failed gossips
+//count.
+func (n *Node) Readiness() Health {
+	health := n.newHealth()
+	n.health.Lock()
+	failingSince := n.health.failingSince
+	n.health.Unlock()
+
+	lastBlock := health.LastBlock
+	if lastBlock.IsZero() {
+		lastBlock = n.start
+	}
+	gossipTimeout := time.Duration(n.conf.ReadyHeartbeats) * n.conf.HeartbeatTimeout
+
+	//the Store is closed on shutdown
+	if n.getState() == Shutdown {
+		health.Reason = ReasonShutdown
+		health.Message = "node is shut down"
+		return health
+	}
+	storeErr := n.pingStore()
+
+	switch {
+	case storeErr != nil:
+		health.Reason = ReasonStoreUnavailable
+		health.Message = storeErr.Error()
+	case n.getState() == CatchingUp:
+		health.Reason = ReasonCatchingUp
+		health.Message = "node is too far behind its peers"
+	case n.conf.ReadyHeartbeats > 0 && !failingSince.IsZero() && time.Since(failingSince) > gossipTimeout:
+		health.Reason = ReasonGossipStalled
+		health.Message = fmt.Sprintf("no successful gossip since %s", failingSince.Format(time.RFC3339))
+	case n.conf.MaxBlockAge > 0 && time.Since(lastBlock) > n.conf.MaxBlockAge:
+		health.Reason = ReasonStaleBlock
+		health.Message = fmt.Sprintf("no block committed since %s", lastBlock.Format(time.RFC3339))
+	}
+	health.OK = health.Reason == ""
+	return health
+}
+
+func (n *Node) newHealth() Health {
+	n.health.Lock()
+	defer n.health.Unlock()
+	return Health{
+		State:          n.getState().String(),
+		LastGossip:     n.health.lastGossip,
+		LastBlockIndex: n.health.lastBlockIndex,
+		LastBlock:      n.health.lastBlock,
+	}
+}
+
+//pingStore does not take the coreLock, which is held during consensus
+//computations. Ping only reads the badger database, which is safe for
+//concurrent use, and none of the caches of the Store, which are not. The
+//Store is closed on shutdown, so Readiness checks the state first.
+func (n *Node) pingStore() error {
+	return n.core.cg.Store.Ping()
+}
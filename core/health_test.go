@@ -0,0 +1,103 @@
+package core
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/paradigm-network/paradigm/config"
+	"github.com/paradigm-network/paradigm/core/sequentia"
+	"github.com/paradigm-network/paradigm/storage"
+)
+
+//pingStore is a Store whose Ping fails with err
+type pingStore struct {
+	storage.Store
+	err error
+}
+
+func (s *pingStore) Ping() error {
+	return s.err
+}
+
+func TestReadiness(t *testing.T) {
+	store := &pingStore{}
+	newNode := func() *Node {
+		n := &Node{
+			conf: &config.Config{
+				HeartbeatTimeout: 10 * time.Millisecond,
+				ReadyHeartbeats:  3,
+				MaxBlockAge:      time.Minute,
+			},
+			core:   &Core{cg: &sequentia.CometGraph{Store: store}},
+			start:  time.Now(),
+			health: healthTracker{lastBlockIndex: -1},
+		}
+		n.setState(Booting)
+		return n
+	}
+
+	tests := []struct {
+		name   string
+		setup  func(n *Node)
+		reason string
+	}{
+		{"ready", func(n *Node) {}, ""},
+		{"recent failures", func(n *Node) {
+			n.health.gossipDone(errors.New("timeout"))
+		}, ""},
+		{"shutdown", func(n *Node) {
+			n.setState(Shutdown)
+			store.err = errors.New("closed")
+		}, ReasonShutdown},
+		{"store", func(n *Node) {
+			store.err = errors.New("io error")
+		}, ReasonStoreUnavailable},
+		{"catching up", func(n *Node) {
+			n.setState(CatchingUp)
+		}, ReasonCatchingUp},
+		{"gossip stalled", func(n *Node) {
+			n.health.failingSince = time.Now().Add(-time.Second)
+		}, ReasonGossipStalled},
+		{"gossip recovered", func(n *Node) {
+			n.health.failingSince = time.Now().Add(-time.Second)
+			n.health.gossipDone(nil)
+		}, ""},
+		{"stale block", func(n *Node) {
+			n.health.blockCommitted(4)
+			n.health.lastBlock = time.Now().Add(-2 * time.Minute)
+		}, ReasonStaleBlock},
+		{"no block since start", func(n *Node) {
+			n.start = time.Now().Add(-2 * time.Minute)
+		}, ReasonStaleBlock},
+		{"checks disabled", func(n *Node) {
+			n.conf.ReadyHeartbeats, n.conf.MaxBlockAge = 0, 0
+			n.start = time.Now().Add(-2 * time.Minute)
+			n.health.failingSince = time.Now().Add(-time.Second)
+		}, ""},
+	}
+	for _, test := range tests {
+		store.err = nil
+		n := newNode()
+		test.setup(n)
+		health := n.Readiness()
+		if health.Reason != test.reason || health.OK != (test.reason == "") {
+			t.Errorf("%s: readiness %+v, expected reason %q", test.name, health, test.reason)
+		}
+		if health.Reason != "" && health.Message == "" {
+			t.Errorf("%s: no message", test.name)
+		}
+	}
+}
+
+func TestLiveness(t *testing.T) {
+	n := &Node{}
+	n.setState(CatchingUp)
+	if health := n.Liveness(); !health.OK || health.State != "CatchingUp" {
+		t.Fatalf("liveness %+v", health)
+	}
+	n.setState(Shutdown)
+	if health := n.Liveness(); health.OK || health.Reason != ReasonShutdown {
+		t.Fatalf("liveness %+v", health)
+	}
+}
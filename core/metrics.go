@@ -0,0 +1,23 @@
+package core
+
+import (
+	"github.com/paradigm-network/paradigm/common/metrics"
+)
+
+var (
+	gossipDuration = metrics.NewHistogramVec("paradigm_gossip_duration_seconds",
+		"Duration of a complete gossip (pull and push) with a peer.",
+		metrics.DefBuckets, "peer")
+	syncErrors = metrics.NewCounter("paradigm_sync_errors_total",
+		"Number of gossips with a peer that failed.")
+	genesisMismatches = metrics.NewCounter("paradigm_genesis_mismatches_total",
+		"Number of sync messages refused because the peer has another genesis.")
+	cometsInserted = metrics.NewCounter("paradigm_comets_inserted_total",
+		"Number of comets inserted in the CometGraph.")
+	roundsDecided = metrics.NewCounter("paradigm_rounds_decided_total",
+		"Number of rounds whose witnesses have all been decided.")
+	blocksCommitted = metrics.NewCounter("paradigm_blocks_committed_total",
+		"Number of blocks produced by consensus and handed to the application.")
+	mempoolSize = metrics.NewGauge("paradigm_mempool_transactions",
+		"Number of transactions waiting to be included in a comet.")
+)
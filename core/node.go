@@ -1,10 +1,10 @@
 package core
 
 import (
+	"github.com/paradigm-network/paradigm/common"
 	"github.com/paradigm-network/paradigm/config"
 	"sync"
 	"time"
-	"crypto/ecdsa"
 	"fmt"
 	"strconv"
 	"github.com/paradigm-network/paradigm/core/sequentia"
@@ -14,6 +14,7 @@ import (
 	"github.com/paradigm-network/paradigm/storage"
 	"github.com/paradigm-network/paradigm/network/peer"
 	"github.com/paradigm-network/paradigm/proxy"
+	"github.com/paradigm-network/paradigm/signer"
 	"github.com/rs/zerolog"
 	"github.com/paradigm-network/paradigm/common/log"
 )
@@ -24,9 +25,10 @@ type Node struct {
 	conf   *config.Config
 	logger *zerolog.Logger
 
-	id       int
-	core     *Core
-	coreLock sync.Mutex
+	id          int
+	core        *Core
+	coreLock    sync.Mutex
+	genesisHash common.Hash
 
 	localAddr string
 
@@ -49,11 +51,13 @@ type Node struct {
 	start        time.Time
 	syncRequests int
 	syncErrors   int
+
+	health healthTracker
 }
 
 func NewNode(conf *config.Config,
 	id int,
-	key *ecdsa.PrivateKey,
+	signer signer.Signer,
 	participants []peer.Peer,
 	store storage.Store,
 	trans network.Transport,
@@ -65,12 +69,14 @@ func NewNode(conf *config.Config,
 	pmap, _ := store.Participants()
 
 	commitCh := make(chan types.Block, 400)
-	core := NewCore(id, key, pmap, store, commitCh)
+	core := NewCore(id, signer, pmap, store, commitCh)
+	core.SetMaxClockSkew(conf.MaxClockSkew)
 
 	peerSelector := sequentia.NewRandomPeerSelector(participants, localAddr)
 
 	node := Node{
 		id:           id,
+		genesisHash:  proxy.GenesisHash(),
 		conf:         conf,
 		core:         &core,
 		localAddr:    localAddr,
@@ -83,15 +89,27 @@ func NewNode(conf *config.Config,
 		commitCh:     commitCh,
 		shutdownCh:   make(chan struct{}),
 		controlTimer: timer.NewRandomControlTimer(conf.HeartbeatTimeout),
+		start:        time.Now(),
+		health:       healthTracker{lastBlockIndex: -1},
 	}
 
 	//Initialize as Booting
 	node.setStarting(true)
 	node.setState(Booting)
 
+	//Deliver the events of the node, from the bootstrap on
+	node.goFunc(func() { node.core.feeds.dispatch(node.shutdownCh) })
+	node.goFunc(node.observe)
+	node.goFunc(func() { node.publishComets(proxy) })
+
 	return &node
 }
 
+//SetKeyring sets the keyring of the keys the node rotates to
+func (n *Node) SetKeyring(keyring *signer.Keyring) {
+	n.core.SetKeyring(keyring)
+}
+
 func (n *Node) Init(bootstrap bool) error {
 	peerAddresses := []string{}
 	for _, p := range n.peerSelector.Peers() {
@@ -204,7 +222,8 @@ func (n *Node) processRPC(rpc network.RPC) {
 		//XXX Use a SyncResponse by default but this should be either a special
 		//ErrorResponse type or a type that corresponds to the request
 		resp := &network.SyncResponse{
-			FromID: n.id,
+			FromID:      n.id,
+			GenesisHash: n.genesisHash,
 		}
 		rpc.Respond(resp, fmt.Errorf("not ready: %s", s.String()))
 		return
@@ -229,7 +248,12 @@ func (n *Node) processSyncRequest(rpc network.RPC, cmd *network.SyncRequest) {
 		Interface("known", cmd.Known).
 		Msg("Process SyncRequest")
 	resp := &network.SyncResponse{
-		FromID: n.id,
+		FromID:      n.id,
+		GenesisHash: n.genesisHash,
+	}
+	if err := n.checkGenesis(cmd.FromID, cmd.GenesisHash); err != nil {
+		rpc.Respond(resp, err)
+		return
 	}
 	var respErr error
 
@@ -285,6 +309,11 @@ func (n *Node) processEagerSyncRequest(rpc network.RPC, cmd *network.EagerSyncRe
 		Int("events", len(cmd.Events)).
 		Msg("EagerSyncRequest")
 
+	if err := n.checkGenesis(cmd.FromID, cmd.GenesisHash); err != nil {
+		rpc.Respond(&network.EagerSyncResponse{FromID: n.id}, err)
+		return
+	}
+
 	success := true
 	n.coreLock.Lock()
 	err := n.sync(cmd.Events)
@@ -322,10 +351,14 @@ func (n *Node) preGossip() (bool, error) {
 	return true, nil
 }
 
-func (n *Node) gossip(peerAddr string) error {
+func (n *Node) gossip(peerAddr string) (err error) {
+	start := time.Now()
+	defer func() { n.health.gossipDone(err) }()
+
 	//pull
 	syncLimit, otherKnownEvents, err := n.pull(peerAddr)
 	if err != nil {
+		syncErrors.Inc()
 		return err
 	}
 
@@ -339,8 +372,10 @@ func (n *Node) gossip(peerAddr string) error {
 	//push
 	err = n.push(peerAddr, otherKnownEvents)
 	if err != nil {
+		syncErrors.Inc()
 		return err
 	}
+	gossipDuration.WithLabelValues(peerAddr).ObserveSince(start)
 
 	//update peer selector
 	n.selectorLock.Lock()
@@ -380,6 +415,10 @@ func (n *Node) pull(peerAddr string) (syncLimit bool, otherKnownEvents map[int]i
 		Interface("known", resp.Known).
 		Msg("SyncResponse")
 
+	if err := n.checkGenesis(resp.FromID, resp.GenesisHash); err != nil {
+		return false, nil, err
+	}
+
 	if resp.SyncLimit {
 		return true, nil, nil
 	}
@@ -435,6 +474,8 @@ func (n *Node) push(peerAddr string, knownEvents map[int]int) error {
 		n.logger.Error().Err(err).Msg("requestEagerSync()")
 		return err
 	}
+
+	n.core.tracer.cometsPushed(peerAddr, eventDiff, start, start.Add(elapsed))
 	n.logger.Debug().
 		Int("from_id", resp2.FromID).
 		Bool("success", resp2.Success).
@@ -457,8 +498,9 @@ func (n *Node) fastForward() error {
 func (n *Node) requestSync(target string, known map[int]int) (network.SyncResponse, error) {
 
 	args := network.SyncRequest{
-		FromID: n.id,
-		Known:  known,
+		FromID:      n.id,
+		GenesisHash: n.genesisHash,
+		Known:       known,
 	}
 
 	var out network.SyncResponse
@@ -469,8 +511,9 @@ func (n *Node) requestSync(target string, known map[int]int) (network.SyncRespon
 
 func (n *Node) requestEagerSync(target string, events []types.WireEvent) (network.EagerSyncResponse, error) {
 	args := network.EagerSyncRequest{
-		FromID: n.id,
-		Events: events,
+		FromID:      n.id,
+		GenesisHash: n.genesisHash,
+		Events:      events,
 	}
 
 	var out network.EagerSyncResponse
@@ -479,6 +522,21 @@ func (n *Node) requestEagerSync(target string, events []types.WireEvent) (networ
 	return out, err
 }
 
+//checkGenesis returns an error when a peer is on another chain
+func (n *Node) checkGenesis(fromID int, hash common.Hash) error {
+	if hash == n.genesisHash {
+		return nil
+	}
+	genesisMismatches.Inc()
+	n.logger.Warn().
+		Int("from_id", fromID).
+		Str("genesis", hash.Hex()).
+		Str("expected", n.genesisHash.Hex()).
+		Msg("Peer of another genesis")
+	return fmt.Errorf("genesis mismatch: peer %d has genesis %s, expected %s",
+		fromID, hash.Hex(), n.genesisHash.Hex())
+}
+
 func (n *Node) sync(events []types.WireEvent) error {
 	//Insert Comets in Paradigm and create new Head if necessary
 	start := time.Now()
@@ -502,13 +560,23 @@ func (n *Node) sync(events []types.WireEvent) error {
 }
 
 func (n *Node) commit(block types.Block) error {
+	n.core.feeds.post(BlockCommittedEvent{Block: block})
 
+	start := time.Now()
 	stateHash, err := n.proxy.CommitBlock(block)
+	end := time.Now()
 	n.logger.Debug().
 		Int("block", block.Index()).
 		Str("state_hash", fmt.Sprintf("0x%X", stateHash)).
 		Err(err).
 		Msg("CommitBlock Response")
+	n.core.feeds.post(StateCommittedEvent{
+		Block:     block,
+		StateHash: stateHash,
+		Err:       err,
+		Start:     start,
+		End:       end,
+	})
 
 	block.Body.StateHash = stateHash
 
@@ -519,6 +587,7 @@ func (n *Node) commit(block types.Block) error {
 		return err
 	}
 	n.core.AddBlockSignature(sig)
+	n.core.feeds.post(BlockSignedEvent{Block: block, Signature: sig})
 
 	return err
 }
@@ -538,6 +607,9 @@ func (n *Node) Shutdown() {
 
 		//Stop and wait for concurrent operations
 		close(n.shutdownCh)
+		//End the event subscriptions, which releases a dispatch waiting for
+		//a subscriber
+		n.core.feeds.scope.Close()
 		n.waitRoutines()
 
 		//For some reason this needs to be called after closing the shutdownCh
@@ -560,16 +632,12 @@ func (n *Node) GetStats() map[string]string {
 	}
 
 	timeElapsed := time.Since(n.start)
+	numPeers := len(n.GetPeers())
 
+	//The core is read under coreLock, like the getters below
+	n.coreLock.Lock()
 	consensusEvents := n.core.GetConsensusEventsCount()
-	consensusEventsPerSecond := float64(consensusEvents) / timeElapsed.Seconds()
-
 	lastConsensusRound := n.core.GetLastConsensusRoundIndex()
-	var consensusRoundsPerSecond float64
-	if lastConsensusRound != nil {
-		consensusRoundsPerSecond = float64(*lastConsensusRound) / timeElapsed.Seconds()
-	}
-
 	s := map[string]string{
 		"last_consensus_round":   toString(lastConsensusRound),
 		"last_block_index":       strconv.Itoa(n.core.GetLastBlockIndex()),
@@ -577,14 +645,24 @@ func (n *Node) GetStats() map[string]string {
 		"consensus_transactions": strconv.Itoa(n.core.GetConsensusTransactionsCount()),
 		"undetermined_events":    strconv.Itoa(len(n.core.GetUndeterminedEvents())),
 		"transaction_pool":       strconv.Itoa(len(n.core.transactionPool)),
-		"num_peers":              strconv.Itoa(len(n.peerSelector.Peers())),
-		"sync_rate":              strconv.FormatFloat(n.SyncRate(), 'f', 2, 64),
-		"events_per_second":      strconv.FormatFloat(consensusEventsPerSecond, 'f', 2, 64),
-		"rounds_per_second":      strconv.FormatFloat(consensusRoundsPerSecond, 'f', 2, 64),
 		"round_events":           strconv.Itoa(n.core.GetLastCommitedRoundEventsCount()),
-		"id":                     strconv.Itoa(n.id),
-		"state":                  n.getState().String(),
+		"clock_skewed":           strconv.Itoa(n.core.analytics.clockSkewedCount()),
+	}
+	n.coreLock.Unlock()
+
+	consensusEventsPerSecond := float64(consensusEvents) / timeElapsed.Seconds()
+	var consensusRoundsPerSecond float64
+	if lastConsensusRound != nil {
+		consensusRoundsPerSecond = float64(*lastConsensusRound) / timeElapsed.Seconds()
 	}
+
+	s["num_peers"] = strconv.Itoa(numPeers)
+	s["sync_rate"] = strconv.FormatFloat(n.SyncRate(), 'f', 2, 64)
+	s["events_per_second"] = strconv.FormatFloat(consensusEventsPerSecond, 'f', 2, 64)
+	s["rounds_per_second"] = strconv.FormatFloat(consensusRoundsPerSecond, 'f', 2, 64)
+	s["id"] = strconv.Itoa(n.id)
+	s["genesis_hash"] = n.genesisHash.Hex()
+	s["state"] = n.getState().String()
 	return s
 }
 
@@ -601,6 +679,120 @@ func (n *Node) SyncRate() float64 {
 	return 1 - syncErrorRate
 }
 
+//The getters below read the Store, which is not safe for concurrent use, so
+//they hold coreLock.
+
 func (n *Node) GetBlock(blockIndex int) (types.Block, error) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
 	return n.core.cg.Store.GetBlock(blockIndex)
 }
+
+func (n *Node) GetLastBlockIndex() int {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.GetLastBlockIndex()
+}
+
+func (n *Node) GetComet(hash string) (types.Comet, error) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.GetComet(hash)
+}
+
+func (n *Node) GetRound(roundIndex int) (types.RoundInfo, error) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.cg.Store.GetRound(roundIndex)
+}
+
+func (n *Node) GetLastRound() int {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.cg.Store.LastRound()
+}
+
+//GetParticipants returns the id of every participant, by the public key of
+//its slot, the creator of its comets
+func (n *Node) GetParticipants() map[string]int {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	participants := make(map[string]int, len(n.core.cg.Participants))
+	for pk, id := range n.core.cg.Participants {
+		participants[pk] = id
+	}
+	return participants
+}
+
+//GetParticipantKeys returns the public key every participant signs with, by
+//id. It is the key of its slot until it rotates its key.
+func (n *Node) GetParticipantKeys() map[int]string {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	keys := make(map[int]string, len(n.core.reverseParticipants))
+	for id, pk := range n.core.reverseParticipants {
+		keys[id] = pk
+	}
+	return keys
+}
+
+//GetParticipantID returns the id of the participant of pubKey, the key of
+//its slot or any key it rotated to
+func (n *Node) GetParticipantID(pubKey string) (int, bool) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	id, ok := n.core.participants[pubKey]
+	return id, ok
+}
+
+//GetParticipantEvents returns the hashes of the comets created by the
+//participant after index skip
+func (n *Node) GetParticipantEvents(pubKey string, skip int) ([]string, error) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.cg.Store.ParticipantEvents(pubKey, skip)
+}
+
+func (n *Node) GetRoot(pubKey string) (types.Root, error) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.cg.Store.GetRoot(pubKey)
+}
+
+//GetKnownEvents returns the index of the last comet known from every
+//participant, by participant id
+func (n *Node) GetKnownEvents() map[int]int {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.KnownEvents()
+}
+
+//GetConsensusEvents returns the window of consensus comets still cached by the
+//Store, in consensus order, along with the total number of consensus comets.
+//The first comet of the window is the consensus comet total-len(events).
+func (n *Node) GetConsensusEvents() (events []string, total int) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.GetConsensusEvents(), n.core.GetConsensusEventsCount()
+}
+
+//GetParticipantStats returns the activity of every participant, sorted by id
+func (n *Node) GetParticipantStats() []ParticipantStats {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return n.core.GetParticipantStats()
+}
+
+//ExportGraph exports rounds of the CometGraph, see sequentia.ExportRounds
+func (n *Node) ExportGraph(fromRound, toRound int) (*sequentia.GraphExport, error) {
+	n.coreLock.Lock()
+	defer n.coreLock.Unlock()
+	return sequentia.ExportRounds(n.core.cg.Store, fromRound, toRound)
+}
+
+//GetPeers returns the participants this node gossips with
+func (n *Node) GetPeers() []peer.Peer {
+	n.selectorLock.Lock()
+	defer n.selectorLock.Unlock()
+	return n.peerSelector.Peers()
+}
@@ -0,0 +1,46 @@
+package core
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/network"
+)
+
+func TestMain(m *testing.M) {
+	log.InitRotateWriter(filepath.Join(os.TempDir(), "paradigm-core-test.log"))
+	os.Exit(m.Run())
+}
+
+func TestCheckGenesis(t *testing.T) {
+	genesis := common.HexToHash("0x01")
+	//without a core: the requests of another genesis are refused before
+	//reaching it
+	n := &Node{id: 0, genesisHash: genesis, logger: log.GetLogger("Node")}
+
+	if err := n.checkGenesis(1, genesis); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.checkGenesis(1, common.HexToHash("0x02")); err == nil {
+		t.Fatal("accepted a peer of another genesis")
+	}
+
+	respCh := make(chan network.RPCResponse, 2)
+	rpc := network.RPC{RespChan: respCh}
+	n.processSyncRequest(rpc, &network.SyncRequest{FromID: 1, GenesisHash: common.HexToHash("0x02")})
+	resp := <-respCh
+	if resp.Error == nil {
+		t.Fatal("answered the SyncRequest of another genesis")
+	}
+	if sync, ok := resp.Response.(*network.SyncResponse); !ok || sync.GenesisHash != genesis {
+		t.Fatalf("response %+v should carry the genesis of the node", resp.Response)
+	}
+
+	n.processEagerSyncRequest(rpc, &network.EagerSyncRequest{FromID: 1, GenesisHash: common.HexToHash("0x02")})
+	if resp := <-respCh; resp.Error == nil {
+		t.Fatal("synced the events of another genesis")
+	}
+}
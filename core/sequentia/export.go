@@ -0,0 +1,182 @@
+package sequentia
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/paradigm-network/paradigm/errors"
+	"github.com/paradigm-network/paradigm/storage"
+)
+
+//Edge kinds of a GraphExport
+const (
+	SelfParentEdge  = "self-parent"
+	OtherParentEdge = "other-parent"
+)
+
+//GraphExport is a range of rounds of the CometGraph, ready to be visualized
+type GraphExport struct {
+	FromRound int         `json:"fromRound"`
+	ToRound   int         `json:"toRound"`
+	Nodes     []GraphNode `json:"nodes"`
+	Edges     []GraphEdge `json:"edges"`
+}
+
+type GraphNode struct {
+	Hash          string `json:"hash"`
+	Creator       string `json:"creator"`
+	CreatorID     int    `json:"creatorId"`
+	Index         int    `json:"index"`
+	Round         int    `json:"round"`
+	Witness       bool   `json:"witness"`
+	Famous        string `json:"famous"` //Undefined, True or False
+	RoundReceived *int   `json:"roundReceived"`
+	Transactions  int    `json:"transactions"`
+}
+
+//GraphEdge goes from a comet to one of its parents. Parents out of the
+//exported rounds have no edge.
+type GraphEdge struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Kind string `json:"kind"`
+}
+
+//ExportRounds reads the rounds from to to, included, from the Store. It stops
+//at the first round the Store does not hold, so a negative to exports up to the
+//last round. Only the Store is read, which allows to export the graph of a
+//stopped node.
+func ExportRounds(store storage.Store, from, to int) (*GraphExport, error) {
+	if from < 0 {
+		from = 0
+	}
+	participants, err := store.Participants()
+	if err != nil {
+		return nil, err
+	}
+
+	export := &GraphExport{
+		FromRound: from,
+		ToRound:   from - 1,
+		Nodes:     []GraphNode{},
+		Edges:     []GraphEdge{},
+	}
+	parents := make(map[string][]string)
+	for r := from; to < 0 || r <= to; r++ {
+		round, err := store.GetRound(r)
+		if err != nil {
+			if errors.Is(err, errors.KeyNotFound) {
+				break
+			}
+			return nil, err
+		}
+		export.ToRound = r
+
+		for hash, re := range round.Events {
+			comet, err := store.GetComet(hash)
+			if err != nil {
+				return nil, err
+			}
+			node := GraphNode{
+				Hash:          hash,
+				Creator:       comet.Creator(),
+				CreatorID:     participants[comet.Creator()],
+				Index:         comet.Index(),
+				Round:         r,
+				Witness:       re.Witness,
+				RoundReceived: comet.RoundReceived,
+				Transactions:  len(comet.Transactions()),
+			}
+			if re.Witness {
+				node.Famous = re.Famous.String()
+			}
+			export.Nodes = append(export.Nodes, node)
+			parents[hash] = []string{comet.SelfParent(), comet.OtherParent()}
+		}
+	}
+
+	sort.Slice(export.Nodes, func(i, j int) bool {
+		a, b := export.Nodes[i], export.Nodes[j]
+		if a.Round != b.Round {
+			return a.Round < b.Round
+		}
+		if a.CreatorID != b.CreatorID {
+			return a.CreatorID < b.CreatorID
+		}
+		return a.Index < b.Index
+	})
+	for _, node := range export.Nodes {
+		p := parents[node.Hash]
+		if _, ok := parents[p[0]]; ok {
+			export.Edges = append(export.Edges, GraphEdge{From: node.Hash, To: p[0], Kind: SelfParentEdge})
+		}
+		if _, ok := parents[p[1]]; ok {
+			export.Edges = append(export.Edges, GraphEdge{From: node.Hash, To: p[1], Kind: OtherParentEdge})
+		}
+	}
+	return export, nil
+}
+
+//WriteDOT writes the export in the Graphviz DOT language. Comets are drawn
+//bottom-up, one rank per round. Witnesses are boxes, filled in gold once
+//famous and in grey once not famous. Self-parent edges are solid and
+//other-parent edges dashed.
+func (g *GraphExport) WriteDOT(w io.Writer) error {
+	p := &dotPrinter{w: w}
+	p.printf("digraph CometGraph {\n")
+	p.printf("\trankdir=BT;\n")
+	p.printf("\tnode [shape=ellipse, fontsize=10];\n")
+
+	for i := 0; i < len(g.Nodes); {
+		round := g.Nodes[i].Round
+		p.printf("\tsubgraph round_%d {\n\t\trank=same;\n", round)
+		for ; i < len(g.Nodes) && g.Nodes[i].Round == round; i++ {
+			p.printf("\t\t%s;\n", dotNode(g.Nodes[i]))
+		}
+		p.printf("\t}\n")
+	}
+	for _, e := range g.Edges {
+		style := "solid"
+		if e.Kind == OtherParentEdge {
+			style = "dashed"
+		}
+		p.printf("\t%q -> %q [style=%s];\n", e.From, e.To, style)
+	}
+	p.printf("}\n")
+	return p.err
+}
+
+func dotNode(n GraphNode) string {
+	roundReceived := "-"
+	if n.RoundReceived != nil {
+		roundReceived = fmt.Sprint(*n.RoundReceived)
+	}
+	label := fmt.Sprintf("%d:%d\\nr%d rr%s", n.CreatorID, n.Index, n.Round, roundReceived)
+
+	attrs := fmt.Sprintf(`label="%s", tooltip="%s"`, label, n.Hash)
+	if n.Witness {
+		attrs += ", shape=box"
+		switch n.Famous {
+		case "True":
+			attrs += ", style=filled, fillcolor=gold"
+		case "False":
+			attrs += ", style=filled, fillcolor=grey"
+		default:
+			attrs += ", style=dashed"
+		}
+	}
+	return fmt.Sprintf("%q [%s]", n.Hash, attrs)
+}
+
+//dotPrinter keeps the first write error
+type dotPrinter struct {
+	w   io.Writer
+	err error
+}
+
+func (p *dotPrinter) printf(format string, args ...interface{}) {
+	if p.err == nil {
+		_, p.err = fmt.Fprintf(p.w, format, args...)
+	}
+}
@@ -0,0 +1,87 @@
+package sequentia
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/storage"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func TestExportRounds(t *testing.T) {
+	creators := [][]byte{{0x01}, {0x02}}
+	participants := map[string]int{"0x01": 0, "0x02": 1}
+	dir, err := ioutil.TempDir("", "export")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	log.InitRotateWriter(filepath.Join(dir, "test.log"))
+	store, err := storage.NewBadgerStore(participants, 10, filepath.Join(dir, "badger"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	a0 := types.NewComet(nil, nil, []string{"", ""}, creators[0], 0)
+	b0 := types.NewComet(nil, nil, []string{"", ""}, creators[1], 0)
+	a1 := types.NewComet([][]byte{[]byte("tx")}, nil, []string{a0.Hex(), b0.Hex()}, creators[0], 1)
+	for _, c := range []types.Comet{a0, b0, a1} {
+		if err := store.SetComet(c); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	round0 := types.NewRoundInfo()
+	round0.AddEvent(a0.Hex(), true)
+	round0.AddEvent(b0.Hex(), true)
+	round0.SetFame(a0.Hex(), true)
+	round1 := types.NewRoundInfo()
+	round1.AddEvent(a1.Hex(), false)
+	store.SetRound(0, *round0)
+	store.SetRound(1, *round1)
+
+	export, err := ExportRounds(store, 0, -1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.ToRound != 1 || len(export.Nodes) != 3 {
+		t.Fatalf("expected 3 comets up to round 1, got %d up to %d", len(export.Nodes), export.ToRound)
+	}
+	first := export.Nodes[0]
+	if first.Hash != a0.Hex() || !first.Witness || first.Famous != "True" {
+		t.Fatalf("unexpected first node %+v", first)
+	}
+	if last := export.Nodes[2]; last.Round != 1 || last.Transactions != 1 || last.Witness {
+		t.Fatalf("unexpected last node %+v", last)
+	}
+	expectedEdges := []GraphEdge{
+		{From: a1.Hex(), To: a0.Hex(), Kind: SelfParentEdge},
+		{From: a1.Hex(), To: b0.Hex(), Kind: OtherParentEdge},
+	}
+	if len(export.Edges) != 2 || export.Edges[0] != expectedEdges[0] || export.Edges[1] != expectedEdges[1] {
+		t.Fatalf("expected edges %v, got %v", expectedEdges, export.Edges)
+	}
+
+	//round 1 alone has no edge, its parents are not exported
+	export, err = ExportRounds(store, 1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(export.Nodes) != 1 || len(export.Edges) != 0 {
+		t.Fatalf("expected a single comet without edges, got %+v", export)
+	}
+
+	var buf bytes.Buffer
+	if err := export.WriteDOT(&buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(buf.String(), "digraph CometGraph {") || !strings.Contains(buf.String(), a1.Hex()) {
+		t.Fatalf("unexpected DOT output:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,240 @@
+package sequentia
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//ErrInvalidSignature is returned for a comet not signed by the key of its
+//creator. Until the rotations of its ancestors are committed, a comet signed
+//with a rotated key is rejected with it, see RotationPending.
+var ErrInvalidSignature = errors.New("Invalid Event signature")
+
+//KeyRotationDelay is the number of rounds between the round a key rotation is
+//received in and the first round signed with the new key. It leaves time to
+//every participant to commit the rotation before the new key shows up.
+const KeyRotationDelay = 10
+
+//keyHistoryKey is the key of the KeyHistory in the Store
+var keyHistoryKey = []byte("key_history")
+
+//KeyHistory is the keys every participant signed with, from its key in
+//participants.json to its latest rotation. The participants keep their slot,
+//and the key of their slot, whatever the key they sign with.
+//
+//The creators of the comets, and the participant events and roots of the
+//Store, are the slot keys: the Participants of the CometGraph map them to the
+//ids. The signatures are checked with the key of the creator at the signing
+//round of the comet, and the participants of the Core follow the rotations.
+type KeyHistory struct {
+	changes map[int][]types.KeyChange //[id] => keys by increasing round
+}
+
+func NewKeyHistory(participants map[string]int) *KeyHistory {
+	h := &KeyHistory{changes: make(map[int][]types.KeyChange, len(participants))}
+	for pk, id := range participants {
+		key, _ := hex.DecodeString(strings.TrimPrefix(pk, "0x"))
+		h.changes[id] = []types.KeyChange{{Round: -1, Key: key}}
+	}
+	return h
+}
+
+//KeyAt returns the key participant id signs round with
+func (h *KeyHistory) KeyAt(id, round int) []byte {
+	changes := h.changes[id]
+	for i := len(changes) - 1; i > 0; i-- {
+		if changes[i].Round <= round {
+			return changes[i].Key
+		}
+	}
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes[0].Key
+}
+
+//Latest returns the last key participant id rotated to, which may not sign
+//yet. It signs the next rotation.
+func (h *KeyHistory) Latest(id int) []byte {
+	changes := h.changes[id]
+	if len(changes) == 0 {
+		return nil
+	}
+	return changes[len(changes)-1].Key
+}
+
+//Changes returns the keys of participant id
+func (h *KeyHistory) Changes(id int) []types.KeyChange {
+	return append([]types.KeyChange{}, h.changes[id]...)
+}
+
+//Snapshot returns the keys of every participant
+func (h *KeyHistory) Snapshot() map[int][]types.KeyChange {
+	res := make(map[int][]types.KeyChange, len(h.changes))
+	for id := range h.changes {
+		res[id] = h.Changes(id)
+	}
+	return res
+}
+
+//Load replaces the keys of the participants of changes, a Snapshot of the
+//KeyHistory of a peer or of the Store
+func (h *KeyHistory) Load(changes map[int][]types.KeyChange) error {
+	for id, cs := range changes {
+		if _, ok := h.changes[id]; !ok {
+			return fmt.Errorf("unknown participant %d", id)
+		}
+		if len(cs) == 0 {
+			return fmt.Errorf("no key for participant %d", id)
+		}
+	}
+	for id, cs := range changes {
+		h.changes[id] = append([]types.KeyChange{}, cs...)
+	}
+	return nil
+}
+
+//Rotate makes key the key of participant id from round on. A key is never
+//reused, by the same participant or by another. Rotating again to the last
+//key at the same round does nothing, the rotations of a Bootstrap are applied
+//again over the KeyHistory of the Store.
+func (h *KeyHistory) Rotate(id, round int, key []byte) error {
+	changes, ok := h.changes[id]
+	if !ok {
+		return fmt.Errorf("unknown participant %d", id)
+	}
+	last := changes[len(changes)-1]
+	if round == last.Round && bytes.Equal(key, last.Key) {
+		return nil
+	}
+	if round <= last.Round {
+		return fmt.Errorf("round %d is not after the last rotation, at round %d", round, last.Round)
+	}
+	for pid, cs := range h.changes {
+		for _, c := range cs {
+			if bytes.Equal(c.Key, key) {
+				return fmt.Errorf("key 0x%X is already used by participant %d", key, pid)
+			}
+		}
+	}
+	h.changes[id] = append(changes, types.KeyChange{Round: round, Key: key})
+	return nil
+}
+
+//applyKeyRotation applies a key rotation received in round roundReceived. The
+//new key signs from KeyRotationDelay rounds later. Invalid rotations are
+//ordered like any transaction, but ignored by every participant alike.
+func (cg *CometGraph) applyKeyRotation(tx []byte, roundReceived int) {
+	var rotation types.KeyRotation
+	if err := rotation.Unmarshal(tx); err != nil {
+		cg.logger.Warn().Err(err).Msg("Decoding key rotation")
+		return
+	}
+	id, ok := cg.Participants[rotation.ParticipantHex()]
+	if !ok {
+		cg.logger.Warn().Str("participant", rotation.ParticipantHex()).Msg("Key rotation of an unknown participant")
+		return
+	}
+	valid, err := rotation.Verify(cg.Keys.Latest(id))
+	if err != nil || !valid {
+		cg.logger.Warn().Err(err).Int("participant", id).Msg("Key rotation not signed by the participant")
+		return
+	}
+	round := roundReceived + KeyRotationDelay
+	if err := cg.Keys.Rotate(id, round, rotation.Body.NewKey); err != nil {
+		cg.logger.Warn().Err(err).Int("participant", id).Msg("Rotating key")
+		return
+	}
+	if err := cg.saveKeys(); err != nil {
+		cg.logger.Error().Err(err).Msg("Saving key history")
+	}
+	cg.logger.Info().
+		Int("participant", id).
+		Str("key", rotation.NewKeyHex()).
+		Int("round", round).
+		Msg("Key rotated")
+}
+
+//saveKeys writes the KeyHistory to the Store, a node restarting from a Reset
+//Store does not replay the rotations before its Frame
+func (cg *CometGraph) saveKeys() error {
+	data, err := json.Marshal(cg.Keys.Snapshot())
+	if err != nil {
+		return err
+	}
+	return cg.Store.Put(keyHistoryKey, data)
+}
+
+//loadKeys reads the KeyHistory of the Store, when there is one
+func (cg *CometGraph) loadKeys() error {
+	data, _ := cg.Store.Get(keyHistoryKey)
+	if len(data) == 0 {
+		return nil
+	}
+	var changes map[int][]types.KeyChange
+	if err := json.Unmarshal(data, &changes); err != nil {
+		return fmt.Errorf("key history: %s", err)
+	}
+	return cg.Keys.Load(changes)
+}
+
+//addPendingRotations records the key rotations of comet, which apply once it
+//is ordered
+func (cg *CometGraph) addPendingRotations(comet types.Comet) {
+	for _, tx := range comet.Transactions() {
+		if !types.IsKeyRotation(tx) {
+			continue
+		}
+		var rotation types.KeyRotation
+		if err := rotation.Unmarshal(tx); err != nil {
+			continue
+		}
+		if id, ok := cg.Participants[rotation.ParticipantHex()]; ok {
+			cg.pendingRotations[id] = append(cg.pendingRotations[id], comet.Hex())
+		}
+	}
+}
+
+//removePendingRotations forgets the rotations of comet, once it is ordered
+func (cg *CometGraph) removePendingRotations(comet types.Comet) {
+	hash := comet.Hex()
+	for id, hashes := range cg.pendingRotations {
+		kept := hashes[:0]
+		for _, h := range hashes {
+			if h != hash {
+				kept = append(kept, h)
+			}
+		}
+		if len(kept) == 0 {
+			delete(cg.pendingRotations, id)
+		} else {
+			cg.pendingRotations[id] = kept
+		}
+	}
+}
+
+//RotationPending returns whether the creator of comet may sign it with a key
+//the KeyHistory does not know yet: a rotation of the creator is inserted but
+//not ordered, and could be active at the signing round of comet. Only then is
+//running the consensus worth it for a comet rejected with
+//ErrInvalidSignature.
+func (cg *CometGraph) RotationPending(comet types.Comet) bool {
+	id, ok := cg.Participants[comet.Creator()]
+	if !ok {
+		return false
+	}
+	round := cg.SigningRound(comet)
+	for _, h := range cg.pendingRotations[id] {
+		//the rotation is received at the round of its comet at the earliest
+		if cg.Round(h)+KeyRotationDelay <= round {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,244 @@
+package sequentia
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/storage"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//newKeysCometGraph returns a CometGraph of participants over the Badger store
+//of dir, without the global Instance of BuildCometGraph
+func newKeysCometGraph(t *testing.T, participants map[string]int, dir string) *CometGraph {
+	store, err := storage.NewBadgerStore(participants, 10, dir)
+	if err != nil {
+		if store, err = storage.LoadBadgerStore(10, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cg := &CometGraph{
+		Participants:     participants,
+		Keys:             NewKeyHistory(participants),
+		pendingRotations: make(map[int][]string),
+		Store:            store,
+		roundCache:       common.NewLRU(10, nil),
+		logger:           log.GetLogger("Sequentia"),
+	}
+	if err := cg.loadKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return cg
+}
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "paradigm-keys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+func TestKeyHistory(t *testing.T) {
+	h := NewKeyHistory(map[string]int{"0x01": 0, "0x02": 1})
+
+	if err := h.Rotate(0, 5, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.Rotate(0, 5, []byte{0x04}); err == nil {
+		t.Fatal("rotated twice at the same round")
+	}
+	if err := h.Rotate(1, 8, []byte{0x01}); err == nil {
+		t.Fatal("rotated to the key of another participant")
+	}
+	if err := h.Rotate(0, 9, []byte{0x01}); err == nil {
+		t.Fatal("rotated back to a previous key")
+	}
+
+	cases := []struct {
+		id, round int
+		key       []byte
+	}{
+		{0, -1, []byte{0x01}},
+		{0, 4, []byte{0x01}},
+		{0, 5, []byte{0x03}},
+		{0, 100, []byte{0x03}},
+		{1, 100, []byte{0x02}},
+	}
+	for _, c := range cases {
+		if key := h.KeyAt(c.id, c.round); !bytes.Equal(key, c.key) {
+			t.Fatalf("participant %d round %d: key %X, expected %X", c.id, c.round, key, c.key)
+		}
+	}
+	if latest := h.Latest(0); !bytes.Equal(latest, []byte{0x03}) {
+		t.Fatalf("latest key %X", latest)
+	}
+}
+
+func TestApplyKeyRotation(t *testing.T) {
+	slot, _ := crypto.GenerateEd25519Key()
+	other, _ := crypto.GenerateEd25519Key()
+	next, _ := crypto.GenerateEd25519Key()
+
+	participants := map[string]int{
+		fmt.Sprintf("0x%X", slot.PubKey()):  0,
+		fmt.Sprintf("0x%X", other.PubKey()): 1,
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	cg := newKeysCometGraph(t, participants, filepath.Join(dir, "badger"))
+
+	rotation := types.NewKeyRotation(slot.PubKey(), next.PubKey())
+
+	//not signed by the participant
+	rotation.Sign(other)
+	tx, _ := rotation.Marshal()
+	if !types.IsKeyRotation(tx) {
+		t.Fatal("not a key rotation")
+	}
+	cg.applyKeyRotation(tx, 3)
+	if len(cg.Keys.Changes(0)) != 1 {
+		t.Fatal("applied a rotation signed by another participant")
+	}
+
+	rotation.Sign(slot)
+	tx, _ = rotation.Marshal()
+	cg.applyKeyRotation(tx, 3)
+	if key := cg.Keys.KeyAt(0, 3+KeyRotationDelay-1); !bytes.Equal(key, slot.PubKey()) {
+		t.Fatal("rotated before the delay")
+	}
+	if key := cg.Keys.KeyAt(0, 3+KeyRotationDelay); !bytes.Equal(key, next.PubKey()) {
+		t.Fatal("not rotated after the delay")
+	}
+
+	//replayed, the participant now signs with next
+	cg.applyKeyRotation(tx, 20)
+	if len(cg.Keys.Changes(0)) != 2 {
+		t.Fatal("applied a replayed rotation")
+	}
+	//applied again by a Bootstrap
+	cg.applyKeyRotation(tx, 3)
+	if len(cg.Keys.Changes(0)) != 2 {
+		t.Fatal("applied a rotation twice")
+	}
+
+	//a restarted node loads the rotations from the Store
+	cg.Store.Close()
+	restarted := newKeysCometGraph(t, participants, filepath.Join(dir, "badger"))
+	defer restarted.Store.Close()
+	if key := restarted.Keys.KeyAt(0, 3+KeyRotationDelay); !bytes.Equal(key, next.PubKey()) {
+		t.Fatal("rotation lost by the restart")
+	}
+}
+
+func TestResetKeys(t *testing.T) {
+	participants := map[string]int{"0x01": 0, "0x02": 1}
+	source := NewKeyHistory(participants)
+	if err := source.Rotate(1, 12, []byte{0x03}); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	cg := newKeysCometGraph(t, participants, filepath.Join(dir, "badger"))
+	defer cg.Store.Close()
+	roots := map[string]types.Root{"0x01": types.NewBaseRoot(), "0x02": types.NewBaseRoot()}
+
+	//the keys of a Frame of a peer past the rotation
+	if err := cg.Reset(roots, map[int][]types.KeyChange{5: {{Round: -1, Key: []byte{0x05}}}}); err == nil {
+		t.Fatal("reset with the keys of an unknown participant")
+	}
+	if err := cg.Reset(roots, source.Snapshot()); err != nil {
+		t.Fatal(err)
+	}
+	if key := cg.Keys.KeyAt(1, 12); !bytes.Equal(key, []byte{0x03}) {
+		t.Fatalf("key %X after the reset", key)
+	}
+	var stored map[int][]types.KeyChange
+	data, _ := cg.Store.Get(keyHistoryKey)
+	if err := json.Unmarshal(data, &stored); err != nil || len(stored[1]) != 2 {
+		t.Fatalf("key history not saved by the reset: %v", err)
+	}
+}
+
+func TestRotationPending(t *testing.T) {
+	slot, _ := crypto.GenerateEd25519Key()
+	next, _ := crypto.GenerateEd25519Key()
+	participants := map[string]int{fmt.Sprintf("0x%X", slot.PubKey()): 0, "0x02": 1}
+
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	cg := newKeysCometGraph(t, participants, filepath.Join(dir, "badger"))
+	defer cg.Store.Close()
+
+	rotation := types.NewKeyRotation(slot.PubKey(), next.PubKey())
+	rotation.Sign(slot)
+	tx, _ := rotation.Marshal()
+	holder := types.NewComet([][]byte{tx}, nil, []string{"", ""}, []byte{0x02}, 0)
+	parent := types.NewComet(nil, nil, []string{"", ""}, slot.PubKey(), 4)
+	comet := types.NewComet(nil, nil, []string{parent.Hex(), ""}, slot.PubKey(), 5)
+	cg.roundCache.Add(holder.Hex(), 2)
+
+	cg.roundCache.Add(parent.Hex(), 2+KeyRotationDelay-1)
+	if cg.RotationPending(comet) {
+		t.Fatal("pending without a rotation")
+	}
+	cg.addPendingRotations(holder)
+	if cg.RotationPending(comet) {
+		t.Fatal("pending before the rotation can be active")
+	}
+	cg.roundCache.Add(parent.Hex(), 2+KeyRotationDelay)
+	if !cg.RotationPending(comet) {
+		t.Fatal("not pending once the rotation can be active")
+	}
+	cg.removePendingRotations(holder)
+	if cg.RotationPending(comet) {
+		t.Fatal("pending once ordered")
+	}
+}
+
+func TestRecordBlockSignaturesValidator(t *testing.T) {
+	creator, _ := crypto.GenerateEd25519Key()
+	other, _ := crypto.GenerateEd25519Key()
+	participants := map[string]int{
+		fmt.Sprintf("0x%X", creator.PubKey()): 0,
+		fmt.Sprintf("0x%X", other.PubKey()):   1,
+	}
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	cg := newKeysCometGraph(t, participants, filepath.Join(dir, "badger"))
+
+	block := types.NewBlock(0, 1, [][]byte{[]byte("tx")})
+	if err := cg.Store.SetBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	own, err := block.SignWith(creator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	//signed with the key of the creator, for the slot of another participant
+	spoofed := own
+	spoofed.Validator = other.PubKey()
+
+	comet := types.NewComet(nil, []types.BlockSignature{spoofed, own}, []string{"", ""}, creator.PubKey(), 0)
+	cg.recordBlockSignatures(comet, creator.PubKey())
+
+	block, err = cg.Store.GetBlock(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := block.GetSignature(fmt.Sprintf("0x%X", other.PubKey())); err == nil {
+		t.Fatal("recorded a signature of another validator")
+	}
+	if _, err := block.GetSignature(fmt.Sprintf("0x%X", creator.PubKey())); err != nil {
+		t.Fatal("the signature of the creator is not recorded")
+	}
+}
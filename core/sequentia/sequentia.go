@@ -1,6 +1,7 @@
 package sequentia
 
 import (
+	"bytes"
 	"fmt"
 	"math"
 	"encoding/hex"
@@ -19,6 +20,8 @@ import (
 type CometGraph struct {
 	Participants            map[string]int //map of all node running the sequentia layer  [public key] => id
 	ReverseParticipants     map[int]string //reverse of Participants map  [id] => public key
+	Keys                    *KeyHistory    //keys the participants sign with, following their rotations
+	pendingRotations        map[int][]string //[id] => inserted comets holding a key rotation of id, not ordered yet
 	Store                   storage.Store  //storage interface of Comets and Comets Rounds
 	UndeterminedEvents      []string       //undetermined comets [index] => hash
 	UndecidedRounds         []int          //queue of Rounds which have undecided witnesses
@@ -61,6 +64,8 @@ func BuildCometGraph(participants map[string]int, store storage.Store, commitCh
 	cometGraph := CometGraph{
 		Participants:            participants,
 		ReverseParticipants:     reverseParticipants,
+		Keys:                    NewKeyHistory(participants),
+		pendingRotations:        make(map[int][]string),
 		Store:                   store,
 		commitCh:                commitCh,
 		ancestorCache:           common.NewLRU(cacheSize, nil),
@@ -74,6 +79,10 @@ func BuildCometGraph(participants map[string]int, store storage.Store, commitCh
 		LastBlockIndex:          -1,
 		logger:                  log.GetLogger("Sequentia"),
 	}
+	//the rotations before the Frame of a Reset Store are not replayed
+	if err := cometGraph.loadKeys(); err != nil {
+		cometGraph.logger.Error().Err(err).Msg("Loading key history")
+	}
 	Instance.Store(cometGraph)
 	return &cometGraph
 }
@@ -377,12 +386,16 @@ func (cg *CometGraph) InsertComet(comet types.Comet, setWireInfo bool) error {
 		Str("Signature",comet.Signature).
 		Int("CreatorID",comet.Body.CreatorID()).Str("MarshalJson",string(b)).
 		Msg("InsertComet")
-	//verify signature
-	if ok, err := comet.Verify(); !ok {
+	//verify signature, with the key the creator signs its round with
+	key, err := cg.signingKey(comet)
+	if err != nil {
+		return err
+	}
+	if ok, err := comet.VerifyWith(key); !ok {
 		if err != nil {
 			return err
 		}
-		return fmt.Errorf("Invalid Event signature")
+		return ErrInvalidSignature
 	}
 
 	if err := cg.CheckSelfParent(comet); err != nil {
@@ -428,21 +441,48 @@ func (cg *CometGraph) InsertComet(comet types.Comet, setWireInfo bool) error {
 		cg.PendingLoadedEvents++
 	}
 
-	cg.recordBlockSignatures(comet.BlockSignatures())
+	cg.recordBlockSignatures(comet, key)
+	cg.addPendingRotations(comet)
 
 	return nil
 }
 
-func (cg *CometGraph) recordBlockSignatures(blockSignatures []types.BlockSignature) {
-	for _, bs := range blockSignatures {
-		//check if validator belongs to list of participants
-		validatorHex := fmt.Sprintf("0x%X", bs.Validator)
-		if _, ok := cg.Participants[validatorHex]; !ok {
+//SigningRound returns the round that chooses the key of a comet: the round of
+//its self-parent, which is known before the comet is inserted
+func (cg *CometGraph) SigningRound(comet types.Comet) int {
+	return cg.NextSigningRound(comet.Creator(), comet.SelfParent())
+}
+
+//NextSigningRound returns the round that chooses the key of the next comet of
+//creator, whose self-parent is selfParent
+func (cg *CometGraph) NextSigningRound(creator, selfParent string) int {
+	if root, err := cg.Store.GetRoot(creator); err == nil && selfParent == root.X {
+		return root.Round
+	}
+	return cg.Round(selfParent)
+}
+
+//signingKey returns the key the creator of comet signs it with
+func (cg *CometGraph) signingKey(comet types.Comet) ([]byte, error) {
+	id, ok := cg.Participants[comet.Creator()]
+	if !ok {
+		return nil, fmt.Errorf("Unknown creator %s", comet.Creator())
+	}
+	return cg.Keys.KeyAt(id, cg.SigningRound(comet)), nil
+}
+
+//recordBlockSignatures records the block signatures of a comet. Its creator
+//signs them with key, the key of the comet, for its own slot only.
+func (cg *CometGraph) recordBlockSignatures(comet types.Comet, key []byte) {
+	for _, bs := range comet.BlockSignatures() {
+		//a participant only signs for itself
+		if !bytes.Equal(bs.Validator, comet.Body.Creator) {
 			cg.logger.
 				Warn().
 				Int("index",bs.Index).
-				Str("validator",validatorHex).
-				Msg("Verifying Block signature. Unknown validator")
+				Str("validator",bs.ValidatorHex()).
+				Str("creator",comet.Creator()).
+				Msg("Verifying Block signature. Validator is not the creator")
 			continue
 		}
 
@@ -455,7 +495,7 @@ func (cg *CometGraph) recordBlockSignatures(blockSignatures []types.BlockSignatu
 				Msg("Verifying Block signature. Could not fetch Block")
 			continue
 		}
-		valid, err := block.Verify(bs)
+		valid, err := block.VerifyWith(key, bs)
 		if err != nil {
 			cg.logger.
 				Warn().
@@ -938,7 +978,15 @@ func (cg *CometGraph) handleNewConsensusEvents(newConsensusEvents []types.Comet)
 			btxs = [][]byte{}
 			blockOrder = append(blockOrder, *e.RoundReceived)
 		}
-		btxs = append(btxs, e.Transactions()...)
+		//key rotations are applied by the consensus, not by the application
+		for _, tx := range e.Transactions() {
+			if types.IsKeyRotation(tx) {
+				cg.applyKeyRotation(tx, *e.RoundReceived)
+				continue
+			}
+			btxs = append(btxs, tx)
+		}
+		cg.removePendingRotations(e)
 		blockMap[*e.RoundReceived] = btxs
 	}
 
@@ -986,15 +1034,24 @@ func (cg *CometGraph) KnownEvents() map[int]int {
 	return cg.Store.KnownEvents()
 }
 
-func (cg *CometGraph) Reset(roots map[string]types.Root) error {
+//Reset restarts the CometGraph from the roots of a Frame, with the keys of
+//the Frame, which the comets of the Frame may be signed with
+func (cg *CometGraph) Reset(roots map[string]types.Root, keys map[int][]types.KeyChange) error {
+	if err := cg.Keys.Load(keys); err != nil {
+		return err
+	}
 	if err := cg.Store.Reset(roots); err != nil {
 		return err
 	}
+	if err := cg.saveKeys(); err != nil {
+		return err
+	}
 
 	cg.UndeterminedEvents = []string{}
 	cg.UndecidedRounds = []int{}
 	cg.PendingLoadedEvents = 0
 	cg.topologicalIndex = 0
+	cg.pendingRotations = make(map[int][]string)
 
 	cacheSize := cg.Store.CacheSize()
 	cg.ancestorCache = common.NewLRU(cacheSize, nil)
@@ -1106,6 +1163,7 @@ func (cg *CometGraph) GetFrame() (types.Frame, error) {
 	frame := types.Frame{
 		Roots:  roots,
 		Comets: events,
+		Keys:   cg.Keys.Snapshot(),
 	}
 
 	return frame, nil
@@ -1126,26 +1184,37 @@ func (cg *CometGraph) Bootstrap() error {
 
 		//Insert the Comets in the Sequentia
 		for _, e := range topologicalEvents {
-			if err := cg.InsertComet(e, true); err != nil {
+			err := cg.InsertComet(e, true)
+			if err == ErrInvalidSignature && cg.RotationPending(e) {
+				//The Comet may be signed with a rotated key, known once the
+				//Comets before it are ordered
+				if err := cg.runConsensus(); err != nil {
+					return err
+				}
+				err = cg.InsertComet(e, true)
+			}
+			if err != nil {
 				return err
 			}
 		}
 
 		//Compute the consensus order of Events
-		if err := cg.DivideRounds(); err != nil {
-			return err
-		}
-		if err := cg.DecideFame(); err != nil {
-			return err
-		}
-		if err := cg.FindOrder(); err != nil {
-			return err
-		}
+		return cg.runConsensus()
 	}
 
 	return nil
 }
 
+func (cg *CometGraph) runConsensus() error {
+	if err := cg.DivideRounds(); err != nil {
+		return err
+	}
+	if err := cg.DecideFame(); err != nil {
+		return err
+	}
+	return cg.FindOrder()
+}
+
 func middleBit(ehex string) bool {
 	hash, err := hex.DecodeString(ehex[2:])
 	if err != nil {
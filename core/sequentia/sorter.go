@@ -39,9 +39,9 @@ func (b ConsensusSorter) Less(i, j int) bool {
 	}
 
 	w := b.GetPseudoRandomNumber(*b.a[i].RoundReceived)
-	wsi, _, _ := crypto.DecodeSignature(b.a[i].Signature)
+	wsi := crypto.SignatureValue(b.a[i].Signature)
 	wsi = wsi.Xor(wsi, w)
-	wsj, _, _ := crypto.DecodeSignature(b.a[j].Signature)
+	wsj := crypto.SignatureValue(b.a[j].Signature)
 	wsj = wsj.Xor(wsj, w)
 	return wsi.Cmp(wsj) < 0
 }
@@ -0,0 +1,76 @@
+package sequentia
+
+import (
+	"math/big"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//TestConsensusSorterMixedKeys orders the comets of ed25519 and secp256k1
+//participants received in the same round at the same time
+func TestConsensusSorterMixedKeys(t *testing.T) {
+	var keys []crypto.PrivKey
+	for i := 0; i < 4; i++ {
+		ecdsaKey, err := crypto.GenerateKey()
+		if err != nil {
+			t.Fatal(err)
+		}
+		edKey, err := crypto.GenerateEd25519Key()
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys = append(keys, crypto.Secp256k1PrivKey{PrivateKey: ecdsaKey}, edKey)
+	}
+
+	timestamp := time.Now().UTC()
+	round := 3
+	var comets []types.Comet
+	for i, key := range keys {
+		comet := types.NewComet([][]byte{{byte(i)}}, nil, []string{"", ""}, key.PubKey(), 0)
+		if err := comet.SignWith(key); err != nil {
+			t.Fatal(err)
+		}
+		//both types draw 256 bits, neither is ordered first
+		if v := crypto.SignatureValue(comet.Signature); v.Sign() == 0 || v.BitLen() > 256 {
+			t.Fatalf("%s: signature value %x", key.Type(), v)
+		}
+		comet.RoundReceived = &round
+		comet.ConsensusTimestamp = timestamp
+		comets = append(comets, comet)
+	}
+
+	info := types.NewRoundInfo()
+	info.SetFame("a1b2c3", true)
+	w := info.PseudoRandomNumber()
+	value := func(c types.Comet) *big.Int {
+		v := crypto.SignatureValue(c.Signature)
+		return v.Xor(v, w)
+	}
+	sorted := func(comets []types.Comet) []types.Comet {
+		s := NewConsensusSorter(append([]types.Comet{}, comets...))
+		s.r[round] = *info
+		sort.Sort(s)
+		return s.a
+	}
+
+	res := sorted(comets)
+	for i := 1; i < len(res); i++ {
+		if value(res[i-1]).Cmp(value(res[i])) >= 0 {
+			t.Fatalf("comets %d and %d out of order", i-1, i)
+		}
+	}
+	//the order does not depend on the order the comets are given in
+	reversed := make([]types.Comet, len(comets))
+	for i, c := range comets {
+		reversed[len(comets)-1-i] = c
+	}
+	for i, c := range sorted(reversed) {
+		if c.Signature != res[i].Signature {
+			t.Fatalf("comet %d differs with the comets reversed", i)
+		}
+	}
+}
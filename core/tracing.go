@@ -0,0 +1,179 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/common/trace"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//Names of the spans recorded by the node, the proxy records tx.submit,
+//tx.submit_queue, state.apply_tx and receipt.write
+const (
+	SpanTxPool         = "tx.pool"         //transaction pool to self-comet
+	SpanGossipSync     = "gossip.sync"     //push of a comet to a peer
+	SpanConsensusOrder = "consensus.order" //comet insertion to round received
+	SpanBlockCreate    = "block.create"    //round received to block commit
+	SpanBlockCommit    = "block.commit"    //CommitBlock of the proxy
+)
+
+//txTraceExpiry is how long the timing of a stage is kept waiting for the next
+//one. The transactions rejected by the proxy never reach a comet and the
+//comets of a fork are never ordered, their timings are dropped after it.
+const txTraceExpiry = 10 * time.Minute
+
+//txTracer keeps the timings needed by the spans of the sampled transactions,
+//from one stage to the next. It is only fed when a Tracer is set.
+type txTracer struct {
+	sync.Mutex
+	pooled     map[string]time.Time   //[tx hash] => entry in the transaction pool
+	comets     map[string]tracedComet //[comet hash] => comet carrying sampled txs
+	ordered    map[string]time.Time   //[tx hash] => consensus ordering
+	lastExpiry time.Time
+}
+
+type tracedComet struct {
+	txHashes [][]byte
+	inserted time.Time
+}
+
+func newTxTracer() *txTracer {
+	return &txTracer{
+		pooled:  make(map[string]time.Time),
+		comets:  make(map[string]tracedComet),
+		ordered: make(map[string]time.Time),
+	}
+}
+
+//expire drops the timings older than txTraceExpiry, at most once every
+//txTraceExpiry
+func (t *txTracer) expire(now time.Time) {
+	if now.Sub(t.lastExpiry) < txTraceExpiry {
+		return
+	}
+	t.lastExpiry = now
+	deadline := now.Add(-txTraceExpiry)
+	for hash, pooled := range t.pooled {
+		if pooled.Before(deadline) {
+			delete(t.pooled, hash)
+		}
+	}
+	for hash, tc := range t.comets {
+		if tc.inserted.Before(deadline) {
+			delete(t.comets, hash)
+		}
+	}
+	for hash, ordered := range t.ordered {
+		if ordered.Before(deadline) {
+			delete(t.ordered, hash)
+		}
+	}
+}
+
+//sampledTxs returns the hashes of the sampled transactions. The hash of a
+//transaction is the Keccak256 of its RLP encoding, as computed by the proxy.
+func sampledTxs(txs [][]byte) [][]byte {
+	if !trace.Enabled() {
+		return nil
+	}
+	var hashes [][]byte
+	for _, tx := range txs {
+		hash := crypto.Keccak256(tx)
+		if trace.Sampled(hash) {
+			hashes = append(hashes, hash)
+		}
+	}
+	return hashes
+}
+
+func (t *txTracer) txsPooled(txs [][]byte) {
+	t.Lock()
+	defer t.Unlock()
+	now := time.Now()
+	t.expire(now)
+	for _, hash := range sampledTxs(txs) {
+		t.pooled[string(hash)] = now
+	}
+}
+
+func (t *txTracer) cometInserted(comet types.Comet, self bool) {
+	t.Lock()
+	defer t.Unlock()
+	hashes := sampledTxs(comet.Transactions())
+	if len(hashes) == 0 {
+		return
+	}
+	now := time.Now()
+	t.expire(now)
+	t.comets[comet.Hex()] = tracedComet{txHashes: hashes, inserted: now}
+	if !self {
+		return
+	}
+	for _, hash := range hashes {
+		if pooled, ok := t.pooled[string(hash)]; ok {
+			delete(t.pooled, string(hash))
+			trace.Record(hash, SpanTxPool, pooled, now,
+				trace.String("comet", comet.Hex()),
+				trace.Int("comet_index", comet.Index()))
+		}
+	}
+}
+
+func (t *txTracer) cometsPushed(peerAddr string, comets []types.Comet, start, end time.Time) {
+	t.Lock()
+	defer t.Unlock()
+	for _, comet := range comets {
+		tc, ok := t.comets[comet.Hex()]
+		if !ok {
+			continue
+		}
+		for _, hash := range tc.txHashes {
+			trace.Record(hash, SpanGossipSync, start, end,
+				trace.String("peer", peerAddr),
+				trace.String("comet", comet.Hex()))
+		}
+	}
+}
+
+func (t *txTracer) cometOrdered(comet types.Comet) {
+	t.Lock()
+	defer t.Unlock()
+	tc, ok := t.comets[comet.Hex()]
+	if !ok {
+		return
+	}
+	delete(t.comets, comet.Hex())
+	now := time.Now()
+	t.expire(now)
+	roundReceived := -1
+	if comet.RoundReceived != nil {
+		roundReceived = *comet.RoundReceived
+	}
+	for _, hash := range tc.txHashes {
+		t.ordered[string(hash)] = now
+		trace.Record(hash, SpanConsensusOrder, tc.inserted, now,
+			trace.String("comet", comet.Hex()),
+			trace.String("creator", comet.Creator()),
+			trace.Int("round_received", roundReceived))
+	}
+}
+
+//blockCommitted records the spans of the transactions of a block committed
+//between start and end
+func (t *txTracer) blockCommitted(block types.Block, start, end time.Time) {
+	t.Lock()
+	defer t.Unlock()
+	for _, hash := range sampledTxs(block.Transactions()) {
+		attrs := []trace.Attribute{
+			trace.Int("block", block.Index()),
+			trace.Int("round_received", block.RoundReceived()),
+		}
+		if ordered, ok := t.ordered[string(hash)]; ok {
+			delete(t.ordered, string(hash))
+			trace.Record(hash, SpanBlockCreate, ordered, start, attrs...)
+		}
+		trace.Record(hash, SpanBlockCommit, start, end, attrs...)
+	}
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTxTracerExpire(t *testing.T) {
+	tracer := newTxTracer()
+	now := time.Now()
+	old, recent := now.Add(-txTraceExpiry-time.Second), now.Add(-time.Second)
+	tracer.pooled["old"], tracer.pooled["recent"] = old, recent
+	tracer.comets["old"] = tracedComet{inserted: old}
+	tracer.comets["recent"] = tracedComet{inserted: recent}
+	tracer.ordered["old"], tracer.ordered["recent"] = old, recent
+
+	tracer.expire(now)
+	for name, m := range map[string]int{"pooled": len(tracer.pooled), "comets": len(tracer.comets), "ordered": len(tracer.ordered)} {
+		if m != 1 {
+			t.Fatalf("%d %s timings after the expiry, expected 1", m, name)
+		}
+	}
+	if _, ok := tracer.pooled["recent"]; !ok {
+		t.Fatal("expired a recent timing")
+	}
+
+	//the next sweep waits for txTraceExpiry
+	tracer.pooled["old"] = old
+	tracer.expire(now.Add(time.Minute))
+	if _, ok := tracer.pooled["old"]; !ok {
+		t.Fatal("swept before txTraceExpiry")
+	}
+	tracer.expire(now.Add(txTraceExpiry))
+	if len(tracer.pooled) != 0 || len(tracer.comets) != 0 || len(tracer.ordered) != 0 {
+		t.Fatalf("timings left after txTraceExpiry: %d pooled, %d comets, %d ordered",
+			len(tracer.pooled), len(tracer.comets), len(tracer.ordered))
+	}
+}
@@ -0,0 +1,176 @@
+package genesis
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/common/math"
+	"github.com/paradigm-network/paradigm/storage"
+)
+
+const (
+	//FileName is the genesis file, read from the keystore directory
+	FileName = "genesis.json"
+
+	DefaultBlockGasLimit = 1000000000000000000
+)
+
+//storeKey is the key of the hash of the genesis applied to a Store
+var storeKey = []byte("genesis")
+
+//Account is the initial state of an account
+type Account struct {
+	Code    string            `json:"code,omitempty"`
+	Storage map[string]string `json:"storage,omitempty"`
+	Balance string            `json:"balance"`
+}
+
+//Alloc maps the hex addresses to the initial state of their accounts
+type Alloc map[string]Account
+
+type ChainConfig struct {
+	ChainID uint64 `json:"chainId"` //replay protection chain id
+}
+
+//Consensus holds the parameters all the participants must agree on
+type Consensus struct {
+	BlockGasLimit uint64 `json:"blockGasLimit"`
+}
+
+//Genesis is the initial state of a chain. Its hash identifies the chain: peers
+//only gossip with peers of the same genesis.
+type Genesis struct {
+	Config       ChainConfig `json:"config"`
+	Participants []string    `json:"participants"` //public keys of the initial participants, as in participants.json
+	Alloc        Alloc       `json:"alloc"`
+	Consensus    Consensus   `json:"consensus"`
+}
+
+//Load reads the genesis file of dir. Without one, it returns an empty Genesis,
+//to be completed like a file without any key.
+func Load(dir string) (*Genesis, error) {
+	var g Genesis
+	contents, err := ioutil.ReadFile(filepath.Join(dir, FileName))
+	if os.IsNotExist(err) {
+		return &g, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(contents, &g); err != nil {
+		return nil, fmt.Errorf("%s: %s", FileName, err)
+	}
+	return &g, nil
+}
+
+//Write writes the genesis file of dir
+func (g *Genesis) Write(dir string) error {
+	data, err := json.MarshalIndent(g, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, FileName), data, 0644)
+}
+
+//Complete fills the keys missing from the genesis file, with the chain id of
+//the node configuration, the public keys of participants.json and the default
+//consensus parameters, and normalizes the others so that the hash does not
+//depend on the formatting of the file. Participants listed by the file must
+//be the ones of participants.json.
+func (g *Genesis) Complete(chainID uint64, participants []string) error {
+	if g.Config.ChainID == 0 {
+		g.Config.ChainID = chainID
+	}
+	if g.Consensus.BlockGasLimit == 0 {
+		g.Consensus.BlockGasLimit = DefaultBlockGasLimit
+	}
+
+	peers := normalizePubKeys(participants)
+	if len(g.Participants) == 0 {
+		g.Participants = peers
+	} else {
+		g.Participants = normalizePubKeys(g.Participants)
+		if strings.Join(g.Participants, ",") != strings.Join(peers, ",") {
+			return fmt.Errorf("the participants of %s are not the ones of participants.json", FileName)
+		}
+	}
+
+	alloc := make(Alloc, len(g.Alloc))
+	for addr, account := range g.Alloc {
+		if !common.IsHexAddress(addr) {
+			return fmt.Errorf("invalid address %q in alloc", addr)
+		}
+		balance, ok := math.ParseBig256(account.Balance)
+		if !ok {
+			return fmt.Errorf("invalid balance %q of %s in alloc", account.Balance, addr)
+		}
+		account.Balance = balance.String()
+		account.Code = strings.ToLower(strings.TrimPrefix(account.Code, "0x"))
+		if len(account.Storage) > 0 {
+			storage := make(map[string]string, len(account.Storage))
+			for key, value := range account.Storage {
+				storage[common.HexToHash(key).Hex()] = common.HexToHash(value).Hex()
+			}
+			account.Storage = storage
+		}
+		alloc[common.HexToAddress(addr).Hex()] = account
+	}
+	g.Alloc = alloc
+	return nil
+}
+
+func normalizePubKeys(pubKeys []string) []string {
+	res := make([]string, len(pubKeys))
+	for i, pub := range pubKeys {
+		res[i] = "0x" + strings.ToUpper(strings.TrimPrefix(strings.TrimPrefix(pub, "0x"), "0X"))
+	}
+	sort.Strings(res)
+	return res
+}
+
+//Hash is the identifier of the chain, the Keccak256 of the JSON encoding of
+//the completed genesis
+func (g *Genesis) Hash() common.Hash {
+	data, err := json.Marshal(g)
+	if err != nil {
+		panic(err) //the genesis only holds marshallable types
+	}
+	return crypto.Keccak256Hash(data)
+}
+
+//Stored returns the hash of the genesis applied to the store, false when none
+//was applied yet
+func Stored(store storage.Store) (common.Hash, bool) {
+	data, _ := store.Get(storeKey)
+	if len(data) == 0 {
+		return common.Hash{}, false
+	}
+	return common.BytesToHash(data), true
+}
+
+//MarkApplied records that the genesis of hash was applied to the store
+func MarkApplied(store storage.Store, hash common.Hash) error {
+	return store.Put(Marker(hash))
+}
+
+//Marker returns the key and value MarkApplied writes, for the commit applying
+//the genesis to write them along with the state
+func Marker(hash common.Hash) (key, value []byte) {
+	return storeKey, hash.Bytes()
+}
+
+//Check returns an error when the store was initialized with another genesis
+func Check(store storage.Store, hash common.Hash) error {
+	if stored, ok := Stored(store); ok && stored != hash {
+		return fmt.Errorf("the database was initialized with genesis %s, %s is %s",
+			stored.Hex(), FileName, hash.Hex())
+	}
+	return nil
+}
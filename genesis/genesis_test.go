@@ -0,0 +1,52 @@
+package genesis
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCompleteAndHash(t *testing.T) {
+	participants := []string{"0xBB01", "0xaa02"}
+
+	var a Genesis
+	if err := json.Unmarshal([]byte(`{
+		"alloc": {"0x629007eb99ff5c3539ada8a5800847eacfc25727": {"Balance": "0x10"}}
+	}`), &a); err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Complete(7, participants); err != nil {
+		t.Fatal(err)
+	}
+	if a.Config.ChainID != 7 || a.Consensus.BlockGasLimit != DefaultBlockGasLimit {
+		t.Fatalf("the missing keys should be filled, got %+v", a)
+	}
+	if len(a.Participants) != 2 || a.Participants[0] != "0xAA02" {
+		t.Fatalf("the participants should be normalized and sorted, got %v", a.Participants)
+	}
+
+	//The same genesis, formatted differently
+	var b Genesis
+	if err := json.Unmarshal([]byte(`{
+		"config": {"chainId": 7},
+		"participants": ["0xAA02", "0xbb01"],
+		"alloc": {"0x629007EB99FF5C3539ADA8A5800847EACFC25727": {"balance": "16"}}
+	}`), &b); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Complete(1, participants); err != nil {
+		t.Fatal(err)
+	}
+	if a.Hash() != b.Hash() {
+		t.Fatal("equivalent genesis files should have the same hash")
+	}
+
+	b.Config.ChainID = 8
+	if a.Hash() == b.Hash() {
+		t.Fatal("the chain id should change the hash")
+	}
+
+	c := Genesis{Participants: []string{"0xAA02", "0xCC03"}}
+	if err := c.Complete(1, participants); err == nil {
+		t.Fatal("participants other than the ones of participants.json should be rejected")
+	}
+}
@@ -56,6 +56,7 @@ func (m *defaultMailbox) PostUserMessage(message interface{}) {
 	}
 	m.userMailbox.Push(message)
 	atomic.AddInt32(&m.userMessages, 1)
+	userMailboxDepth.Inc()
 	m.schedule()
 }
 
@@ -65,6 +66,7 @@ func (m *defaultMailbox) PostSystemMessage(message interface{}) {
 	}
 	m.systemMailbox.Push(message)
 	atomic.AddInt32(&m.sysMessages, 1)
+	systemMailboxDepth.Inc()
 	m.schedule()
 }
 
@@ -121,6 +123,7 @@ func (m *defaultMailbox) run() {
 		// keep processing system messages until queue is empty
 		if msg = m.systemMailbox.Pop(); msg != nil {
 			atomic.AddInt32(&m.sysMessages, -1)
+			systemMailboxDepth.Dec()
 			switch msg.(type) {
 			case *SuspendMailbox:
 				m.suspended = true
@@ -142,6 +145,7 @@ func (m *defaultMailbox) run() {
 
 		if msg = m.userMailbox.Pop(); msg != nil {
 			atomic.AddInt32(&m.userMessages, -1)
+			userMailboxDepth.Dec()
 			m.invoker.InvokeUserMessage(msg)
 			for _, ms := range m.mailboxStats {
 				ms.MessageReceived(msg)
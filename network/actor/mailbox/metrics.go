@@ -0,0 +1,15 @@
+package mailbox
+
+import (
+	"github.com/paradigm-network/paradigm/common/metrics"
+)
+
+//mailboxDepth sums the messages queued in all the mailboxes of the process
+var mailboxDepth = metrics.NewGaugeVec("paradigm_actor_mailbox_messages",
+	"Number of messages waiting in the actor mailboxes.",
+	"kind")
+
+var (
+	userMailboxDepth   = mailboxDepth.WithLabelValues("user")
+	systemMailboxDepth = mailboxDepth.WithLabelValues("system")
+)
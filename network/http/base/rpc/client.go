@@ -0,0 +1,76 @@
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+//Client calls the methods of a JSON-RPC 2.0 server over HTTP POST, the node
+//RPC server as well as the /rpc endpoint of the proxy
+type Client struct {
+	url    string
+	http   *http.Client
+	lastID uint64
+}
+
+func NewClient(url string) *Client {
+	return &Client{
+		url:  url,
+		http: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type clientResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result"`
+	Error   *Error          `json:"error"`
+}
+
+//Call calls method with positional params and decodes its result into
+//result. A null result leaves result untouched. The errors of the method are
+//returned as *Error.
+func (c *Client) Call(result interface{}, method string, params ...interface{}) error {
+	if params == nil {
+		params = []interface{}{}
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": jsonRPCVersion,
+		"id":      atomic.AddUint64(&c.lastID, 1),
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.http.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", resp.Status, bytes.TrimSpace(data))
+	}
+
+	var res clientResponse
+	if err := json.Unmarshal(data, &res); err != nil {
+		return err
+	}
+	if res.Error != nil {
+		return res.Error
+	}
+	if result == nil || len(res.Result) == 0 || bytes.Equal(res.Result, []byte("null")) {
+		return nil
+	}
+	return json.Unmarshal(res.Result, result)
+}
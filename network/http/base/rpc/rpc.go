@@ -1,101 +1,302 @@
 package rpc
 
 import (
+	"bytes"
 	"encoding/json"
+	"fmt"
+	"io/ioutil"
 	"net/http"
+	"reflect"
 	"sync"
-	"io/ioutil"
+
 	"github.com/rs/zerolog/log"
-	"github.com/paradigm-network/paradigm/core"
 )
 
-func init() {
-	mainMux.m = make(map[string]func(w http.ResponseWriter, r *http.Request))
+const (
+	jsonRPCVersion = "2.0"
+
+	//maximum size of a request body, batches included
+	maxRequestSize = 5 * 1024 * 1024
+)
+
+//Standard JSON-RPC 2.0 error codes. CodeServerError is used for the errors
+//returned by the methods themselves.
+const (
+	CodeParseError     = -32700
+	CodeInvalidRequest = -32600
+	CodeMethodNotFound = -32601
+	CodeInvalidParams  = -32602
+	CodeInternalError  = -32603
+	CodeServerError    = -32000
+)
+
+//Error is a JSON-RPC 2.0 error object. Methods can return an *Error to choose
+//the code sent to the client.
+type Error struct {
+	Code    int         `json:"code"`
+	Message string      `json:"message"`
+	Data    interface{} `json:"data,omitempty"`
 }
 
-//an instance of the multiplexer
-var mainMux ServeMux
+func (e *Error) Error() string {
+	return e.Message
+}
+
+type request struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
 
-//multiplexer that keeps track of every function to be called on specific rpc call
-type ServeMux struct {
+//isNotification reports whether the request has no id, in which case the
+//client expects no response.
+func (r *request) isNotification() bool {
+	return r.ID == nil
+}
+
+type response struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *Error          `json:"error,omitempty"`
+}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+//method is a registered function along with the types of its arguments
+type method struct {
+	fn        reflect.Value
+	args      []reflect.Type
+	hasResult bool
+}
+
+//Server answers JSON-RPC 2.0 requests sent over HTTP POST. It supports batch
+//requests and notifications.
+type Server struct {
 	sync.RWMutex
-	m map[string]func(w http.ResponseWriter, r *http.Request)
-	defaultFunction func(http.ResponseWriter, *http.Request)
+	methods map[string]*method
+}
 
-	node *core.Node
+func NewServer() *Server {
+	return &Server{
+		methods: make(map[string]*method),
+	}
 }
 
-// this is the function that should be called in order to answer an rpc call
-// should be registered like "http.HandleFunc("/", httpjsonrpc.Handle)"
-func Handle(w http.ResponseWriter, r *http.Request) {
+//Register makes fn callable as name. fn must return either an error or a
+//result and an error. Its arguments are decoded from the positional params of
+//the request, missing trailing params being left to their zero value. A
+//function with a single struct argument can also be called with named params.
+func (s *Server) Register(name string, fn interface{}) error {
+	fv := reflect.ValueOf(fn)
+	ft := fv.Type()
+	if ft.Kind() != reflect.Func {
+		return fmt.Errorf("rpc: %s is not a function", name)
+	}
+	if ft.IsVariadic() {
+		return fmt.Errorf("rpc: %s must not be variadic", name)
+	}
+	switch {
+	case ft.NumOut() == 1 && ft.Out(0) == errorType:
+	case ft.NumOut() == 2 && ft.Out(1) == errorType:
+	default:
+		return fmt.Errorf("rpc: %s must return an error, or a result and an error", name)
+	}
+
+	m := &method{fn: fv, hasResult: ft.NumOut() == 2}
+	for i := 0; i < ft.NumIn(); i++ {
+		m.args = append(m.args, ft.In(i))
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.methods[name] = m
+	return nil
+}
 
-	mainMux.RLock()
-	defer mainMux.RUnlock()
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method == "OPTIONS" {
 		w.Header().Add("Access-Control-Allow-Headers", "Content-Type")
 		w.Header().Set("content-type", "application/json;charset=utf-8")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
 		return
 	}
-
 	//JSON RPC commands should be POSTs
 	if r.Method != "POST" {
-		if mainMux.defaultFunction != nil {
-			log.Info().Msg("HTTP JSON RPC Handle - Method!=\"POST\"")
-			mainMux.defaultFunction(w, r)
-			return
-		} else {
-			log.Warn().Msg("HTTP JSON RPC Handle - Method!=\"POST\"")
-			return
-		}
-	}
-
-	//check if there is Request Body to read
-	if r.Body == nil {
-		if mainMux.defaultFunction != nil {
-			log.Info().Msg("HTTP JSON RPC Handle - Request body is nil")
-			mainMux.defaultFunction(w, r)
-			return
-		} else {
-			log.Warn().Msg("HTTP JSON RPC Handle - Request body is nil")
-			return
-		}
+		http.Error(w, "JSON-RPC requests must be POSTs", http.StatusMethodNotAllowed)
+		return
 	}
 
-	//read the body of the request
-	body, err := ioutil.ReadAll(r.Body)
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
 	if err != nil {
-		log.Error().Msg("HTTP JSON RPC Handle - ioutil.ReadAll error")
+		log.Error().Err(err).Msg("HTTP JSON RPC Handle - reading request body")
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
 		return
 	}
-	request := make(map[string]interface{})
-	err = json.Unmarshal(body, &request)
-	if err != nil {
-		log.Error().Msg("HTTP JSON RPC Handle - json.Unmarshal error")
+
+	res := s.handleBody(body)
+	if res == nil {
+		//only notifications
+		w.WriteHeader(http.StatusNoContent)
 		return
 	}
-	if request["method"] == nil {
-		log.Error().Msg("HTTP JSON RPC Handle - method not found")
-		return
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	if err := json.NewEncoder(w).Encode(res); err != nil {
+		log.Error().Err(err).Msg("HTTP JSON RPC Handle - writing response")
 	}
-	method, ok := request["method"].(string)
+}
+
+//handleBody returns the response to a single request or a batch, or nil when
+//there is nothing to answer.
+func (s *Server) handleBody(body []byte) interface{} {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '[' {
+		var req request
+		if err := json.Unmarshal(body, &req); err != nil {
+			return errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()})
+		}
+		if res := s.handle(&req); res != nil {
+			return res
+		}
+		return nil
+	}
+
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return errorResponse(nil, &Error{Code: CodeParseError, Message: err.Error()})
+	}
+	if len(batch) == 0 {
+		return errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: "empty batch"})
+	}
+	responses := []*response{}
+	for _, raw := range batch {
+		var req request
+		if err := json.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, errorResponse(nil, &Error{Code: CodeInvalidRequest, Message: err.Error()}))
+			continue
+		}
+		if res := s.handle(&req); res != nil {
+			responses = append(responses, res)
+		}
+	}
+	if len(responses) == 0 {
+		return nil
+	}
+	return responses
+}
+
+//handle calls the method of the request. It returns nil for notifications.
+func (s *Server) handle(req *request) *response {
+	res := s.call(req)
+	if req.isNotification() {
+		return nil
+	}
+	return res
+}
+
+func (s *Server) call(req *request) *response {
+	if req.Version != jsonRPCVersion || req.Method == "" {
+		return errorResponse(req.ID, &Error{Code: CodeInvalidRequest, Message: "invalid request"})
+	}
+
+	s.RLock()
+	m, ok := s.methods[req.Method]
+	s.RUnlock()
 	if !ok {
-		log.Error().Msg("HTTP JSON RPC Handle - method is not string")
-		return
+		log.Warn().Str("method", req.Method).Msg("HTTP JSON RPC Handle - No function to call")
+		return errorResponse(req.ID, &Error{
+			Code:    CodeMethodNotFound,
+			Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method),
+		})
 	}
-	//get the corresponding function
-	_, ok = mainMux.m[method]
-	if ok {
-		return
-	} else {
-		//if the function does not exist
-		log.Warn().Msg("HTTP JSON RPC Handle - No function to call for ")
+
+	args, rpcErr := m.parseArgs(req.Params)
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr)
+	}
+
+	result, rpcErr := m.invoke(args)
+	if rpcErr != nil {
+		return errorResponse(req.ID, rpcErr)
 	}
+	return &response{Version: jsonRPCVersion, ID: req.ID, Result: result}
 }
 
-//a function to register functions to be called for specific rpc calls
-func HandleFunc(pattern string, handler func(w http.ResponseWriter, r *http.Request)) {
-	mainMux.Lock()
-	defer mainMux.Unlock()
-	mainMux.m[pattern] = handler
+func (m *method) parseArgs(params json.RawMessage) ([]reflect.Value, *Error) {
+	args := make([]reflect.Value, len(m.args))
+	for i, t := range m.args {
+		args[i] = reflect.New(t)
+	}
+
+	params = bytes.TrimSpace(params)
+	switch {
+	case len(params) == 0 || bytes.Equal(params, []byte("null")):
+	case params[0] == '[':
+		var raw []json.RawMessage
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+		if len(raw) > len(m.args) {
+			return nil, &Error{
+				Code:    CodeInvalidParams,
+				Message: fmt.Sprintf("too many arguments, want at most %d", len(m.args)),
+			}
+		}
+		for i, r := range raw {
+			if err := json.Unmarshal(r, args[i].Interface()); err != nil {
+				return nil, &Error{
+					Code:    CodeInvalidParams,
+					Message: fmt.Sprintf("invalid argument %d: %v", i, err),
+				}
+			}
+		}
+	case params[0] == '{' && len(m.args) == 1:
+		if err := json.Unmarshal(params, args[0].Interface()); err != nil {
+			return nil, &Error{Code: CodeInvalidParams, Message: err.Error()}
+		}
+	default:
+		return nil, &Error{Code: CodeInvalidParams, Message: "params must be an array"}
+	}
+
+	for i := range args {
+		args[i] = args[i].Elem()
+	}
+	return args, nil
+}
+
+func (m *method) invoke(args []reflect.Value) (result interface{}, rpcErr *Error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().Interface("panic", r).Msg("HTTP JSON RPC Handle - method panicked")
+			rpcErr = &Error{Code: CodeInternalError, Message: fmt.Sprintf("%v", r)}
+		}
+	}()
+
+	out := m.fn.Call(args)
+	errValue := out[len(out)-1]
+	if !errValue.IsNil() {
+		err := errValue.Interface().(error)
+		if e, ok := err.(*Error); ok {
+			return nil, e
+		}
+		return nil, &Error{Code: CodeServerError, Message: err.Error()}
+	}
+	if m.hasResult {
+		result = out[0].Interface()
+	}
+	//a successful response must carry a result
+	if result == nil {
+		result = json.RawMessage("null")
+	}
+	return result, nil
+}
+
+func errorResponse(id json.RawMessage, err *Error) *response {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &response{Version: jsonRPCVersion, ID: id, Error: err}
 }
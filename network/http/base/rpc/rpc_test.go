@@ -0,0 +1,146 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type echoArgs struct {
+	Text  string `json:"text"`
+	Times int    `json:"times"`
+}
+
+func newTestServer(t *testing.T) *Server {
+	s := NewServer()
+	methods := map[string]interface{}{
+		"add": func(a, b int) (int, error) { return a + b, nil },
+		"echo": func(args echoArgs) (string, error) {
+			return strings.Repeat(args.Text, args.Times), nil
+		},
+		"fail":   func() error { return errors.New("boom") },
+		"denied": func() error { return &Error{Code: 42, Message: "denied"} },
+	}
+	for name, fn := range methods {
+		if err := s.Register(name, fn); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s
+}
+
+func post(t *testing.T, s *Server, body string) (int, string) {
+	req := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestRegister(t *testing.T) {
+	s := NewServer()
+	if err := s.Register("bad", func() int { return 0 }); err == nil {
+		t.Error("expected an error for a function without error result")
+	}
+	if err := s.Register("bad", 42); err == nil {
+		t.Error("expected an error for a non function")
+	}
+}
+
+func TestCall(t *testing.T) {
+	s := newTestServer(t)
+	tests := []struct {
+		body     string
+		response string
+	}{
+		{`{"jsonrpc":"2.0","id":1,"method":"add","params":[1,2]}`,
+			`{"jsonrpc":"2.0","id":1,"result":3}`},
+		{`{"jsonrpc":"2.0","id":"a","method":"echo","params":{"text":"ab","times":2}}`,
+			`{"jsonrpc":"2.0","id":"a","result":"abab"}`},
+		{`{"jsonrpc":"2.0","id":2,"method":"add","params":[1]}`,
+			`{"jsonrpc":"2.0","id":2,"result":1}`},
+		{`{"jsonrpc":"2.0","id":3,"method":"add","params":["x"]}`,
+			`{"jsonrpc":"2.0","id":3,"error":{"code":-32602,"message":"invalid argument 0: json: cannot unmarshal string into Go value of type int"}}`},
+		{`{"jsonrpc":"2.0","id":4,"method":"nope"}`,
+			`{"jsonrpc":"2.0","id":4,"error":{"code":-32601,"message":"the method nope does not exist/is not available"}}`},
+		{`{"jsonrpc":"2.0","id":5,"method":"fail"}`,
+			`{"jsonrpc":"2.0","id":5,"error":{"code":-32000,"message":"boom"}}`},
+		{`{"jsonrpc":"2.0","id":6,"method":"denied"}`,
+			`{"jsonrpc":"2.0","id":6,"error":{"code":42,"message":"denied"}}`},
+		{`{"id":7,"method":"add"}`,
+			`{"jsonrpc":"2.0","id":7,"error":{"code":-32600,"message":"invalid request"}}`},
+		{`{`,
+			`{"jsonrpc":"2.0","id":null,"error":{"code":-32700,"message":"unexpected end of JSON input"}}`},
+	}
+	for i, test := range tests {
+		code, body := post(t, s, test.body)
+		if code != http.StatusOK || body != test.response {
+			t.Errorf("test %d: expected %s, got %d %s", i, test.response, code, body)
+		}
+	}
+}
+
+func TestNotification(t *testing.T) {
+	s := newTestServer(t)
+	code, body := post(t, s, `{"jsonrpc":"2.0","method":"fail"}`)
+	if code != http.StatusNoContent || body != "" {
+		t.Errorf("expected no content, got %d %s", code, body)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	s := newTestServer(t)
+	_, body := post(t, s, `[
+		{"jsonrpc":"2.0","id":1,"method":"add","params":[1,2]},
+		{"jsonrpc":"2.0","method":"add","params":[3,4]},
+		42,
+		{"jsonrpc":"2.0","id":2,"method":"nope"}
+	]`)
+
+	var responses []response
+	if err := json.Unmarshal([]byte(body), &responses); err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %s", body)
+	}
+	if string(responses[0].ID) != "1" || responses[0].Error != nil {
+		t.Errorf("unexpected first response %s", body)
+	}
+	if responses[1].Error == nil || responses[1].Error.Code != CodeInvalidRequest {
+		t.Errorf("expected invalid request, got %s", body)
+	}
+	if string(responses[2].ID) != "2" || responses[2].Error.Code != CodeMethodNotFound {
+		t.Errorf("expected method not found, got %s", body)
+	}
+
+	code, _ := post(t, s, `[{"jsonrpc":"2.0","method":"add"}]`)
+	if code != http.StatusNoContent {
+		t.Errorf("expected no content for a batch of notifications, got %d", code)
+	}
+	_, body = post(t, s, `[]`)
+	if !strings.Contains(body, `"code":-32600`) {
+		t.Errorf("expected invalid request for an empty batch, got %s", body)
+	}
+}
+
+func TestClient(t *testing.T) {
+	ts := httptest.NewServer(newTestServer(t))
+	defer ts.Close()
+	c := NewClient(ts.URL)
+
+	var sum int
+	if err := c.Call(&sum, "add", 1, 2); err != nil || sum != 3 {
+		t.Fatalf("add: %d, %v", sum, err)
+	}
+
+	err := c.Call(nil, "denied")
+	if rpcErr, ok := err.(*Error); !ok || rpcErr.Code != 42 {
+		t.Fatalf("expected the error of the method, got %v", err)
+	}
+	if err := c.Call(nil, "nope"); err == nil {
+		t.Fatal("expected an error for an unknown method")
+	}
+}
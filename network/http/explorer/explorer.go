@@ -0,0 +1,224 @@
+package explorer
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/paradigm-network/paradigm/network/http/service"
+	"github.com/rs/zerolog/log"
+)
+
+//Prefix is the path under which the explorer endpoints are served
+const Prefix = "/explorer"
+
+//NewRouter returns the read-only endpoints exposing the blocks and the
+//consensus data held by the node's Store.
+func NewRouter(s *service.Service) *mux.Router {
+	r := mux.NewRouter().PathPrefix(Prefix).Subrouter()
+	r.HandleFunc("/blocks", makeHandler(s, blocksHandler)).Methods("GET")
+	r.HandleFunc("/comets/{hash}", makeHandler(s, cometHandler)).Methods("GET")
+	r.HandleFunc("/rounds/{index}", makeHandler(s, roundHandler)).Methods("GET")
+	r.HandleFunc("/participants", makeHandler(s, participantsHandler)).Methods("GET")
+	r.HandleFunc("/participants/{participant}/events", makeHandler(s, participantEventsHandler)).Methods("GET")
+	r.HandleFunc("/consensus", makeHandler(s, consensusHandler)).Methods("GET")
+	r.HandleFunc("/graph", graphHandler(s)).Methods("GET")
+	return r
+}
+
+func makeHandler(s *service.Service, fn func(*service.Service, *http.Request) (interface{}, int, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		res, status, err := fn(s, r)
+		if err != nil {
+			log.Error().Err(err).Str("path", r.URL.Path).Msg("Explorer request")
+			http.Error(w, err.Error(), status)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if err := json.NewEncoder(w).Encode(res); err != nil {
+			log.Error().Err(err).Msg("Encoding explorer response")
+		}
+	}
+}
+
+/*
+GET /explorer/blocks?offset={offset}&limit={limit}
+example: /explorer/blocks?limit=5
+returns: JSON JsonBlockList
+
+This endpoint lists the blocks, newest first. offset defaults to 0 and limit to
+20, with a maximum of 100.
+*/
+func blocksHandler(s *service.Service, r *http.Request) (interface{}, int, error) {
+	offset, err := queryInt(r, "offset", 0)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	limit, err := queryInt(r, "limit", service.DefaultPageSize)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	res, err := s.GetBlocks(offset, limit)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return res, http.StatusOK, nil
+}
+
+/*
+GET /explorer/comets/{hash}
+example: /explorer/comets/0x1D9A...
+returns: JSON JsonCometDetail
+
+This endpoint returns a comet along with its self-parent and other-parent.
+*/
+func cometHandler(s *service.Service, r *http.Request) (interface{}, int, error) {
+	res, err := s.GetCometWithParents(mux.Vars(r)["hash"])
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	return res, http.StatusOK, nil
+}
+
+/*
+GET /explorer/rounds/{index}
+example: /explorer/rounds/12
+returns: JSON JsonRound
+
+This endpoint returns the witnesses of a round, sorted by creator, with their
+fame: Undefined until it is decided, then True or False.
+*/
+func roundHandler(s *service.Service, r *http.Request) (interface{}, int, error) {
+	index, err := strconv.Atoi(mux.Vars(r)["index"])
+	if err != nil {
+		return nil, http.StatusBadRequest, fmt.Errorf("invalid round index: %v", err)
+	}
+	res, err := s.GetRoundWitnesses(index)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	return res, http.StatusOK, nil
+}
+
+/*
+GET /explorer/participants
+returns: JSON []JsonParticipant
+
+This endpoint lists the participants with the index of the last comet known
+from each of them, and their root.
+*/
+func participantsHandler(s *service.Service, r *http.Request) (interface{}, int, error) {
+	res, err := s.GetParticipantList()
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return res, http.StatusOK, nil
+}
+
+/*
+GET /explorer/participants/{participant}/events?from={index}
+example: /explorer/participants/2/events?from=100
+returns: JSON JsonParticipantEvents
+
+This endpoint lists the comets created by a participant, given by id or by
+public key, from the index from on (0 by default).
+*/
+func participantEventsHandler(s *service.Service, r *http.Request) (interface{}, int, error) {
+	from, err := queryInt(r, "from", 0)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	res, err := s.GetParticipantEvents(mux.Vars(r)["participant"], from)
+	if err != nil {
+		return nil, http.StatusNotFound, err
+	}
+	return res, http.StatusOK, nil
+}
+
+/*
+GET /explorer/consensus?from={index}&limit={limit}
+example: /explorer/consensus?from=1200&limit=50
+returns: JSON JsonConsensusEvents
+
+This endpoint returns a window of the consensus order. Only the most recent
+consensus comets are kept in memory: from defaults to, and is moved up to, the
+oldest of them. limit defaults to 20, with a maximum of 100.
+*/
+func consensusHandler(s *service.Service, r *http.Request) (interface{}, int, error) {
+	from, err := queryInt(r, "from", 0)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	limit, err := queryInt(r, "limit", service.DefaultPageSize)
+	if err != nil {
+		return nil, http.StatusBadRequest, err
+	}
+	res, err := s.GetConsensusEvents(from, limit)
+	if err != nil {
+		return nil, http.StatusInternalServerError, err
+	}
+	return res, http.StatusOK, nil
+}
+
+/*
+GET /explorer/graph?from={round}&to={round}&format={dot|json}
+example: /explorer/graph?from=40&to=45&format=dot
+returns: Graphviz DOT or JSON sequentia.GraphExport
+
+This endpoint exports the comets of a range of rounds along with their parent
+edges. to defaults to the last round and from to the 10 rounds up to to, with
+a maximum of 50 rounds. The format defaults to dot: render it with
+`dot -Tsvg`.
+*/
+func graphHandler(s *service.Service) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		from, err := queryInt(r, "from", -1)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		to, err := queryInt(r, "to", -1)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format != "" && format != "dot" && format != "json" {
+			http.Error(w, "format must be dot or json", http.StatusBadRequest)
+			return
+		}
+
+		export, err := s.ExportGraph(from, to)
+		if err != nil {
+			log.Error().Err(err).Msg("Exporting graph")
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		if format == "json" {
+			w.Header().Set("Content-Type", "application/json")
+			err = json.NewEncoder(w).Encode(export)
+		} else {
+			w.Header().Set("Content-Type", "text/vnd.graphviz")
+			err = export.WriteDOT(w)
+		}
+		if err != nil {
+			log.Error().Err(err).Msg("Writing graph")
+		}
+	}
+}
+
+func queryInt(r *http.Request, name string, def int) (int, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid %s: %s", name, value)
+	}
+	return n, nil
+}
@@ -0,0 +1,51 @@
+package health
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/paradigm-network/paradigm/core"
+	"github.com/paradigm-network/paradigm/network/http/service"
+	"github.com/rs/zerolog/log"
+)
+
+//Prefix is the path under which the probes are served
+const Prefix = "/health"
+
+//NewRouter returns the liveness and readiness probes of the node
+func NewRouter(s *service.Service) *mux.Router {
+	r := mux.NewRouter().PathPrefix(Prefix).Subrouter()
+	r.HandleFunc("/live", makeHandler(s.GetLiveness)).Methods("GET")
+	r.HandleFunc("/ready", makeHandler(s.GetReadiness)).Methods("GET")
+	return r
+}
+
+/*
+GET /health/live
+GET /health/ready
+returns: JSON core.Health
+
+Both endpoints answer 200 when the probe succeeds and 503 otherwise, with the
+machine-readable reason of the failure: shutdown, store_unavailable,
+catching_up, gossip_stalled or stale_block.
+*/
+func makeHandler(probe func() (core.Health, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		health, err := probe()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-cache")
+		if health.OK {
+			w.WriteHeader(http.StatusOK)
+		} else {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		if err := json.NewEncoder(w).Encode(health); err != nil {
+			log.Error().Err(err).Msg("Encoding health response")
+		}
+	}
+}
@@ -2,50 +2,72 @@ package jsonrpc
 
 import (
 	"fmt"
-	"github.com/paradigm-network/paradigm/config"
 	"net/http"
+
 	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/common/metrics"
+	"github.com/paradigm-network/paradigm/config"
 	"github.com/paradigm-network/paradigm/network/http/base/rpc"
+	"github.com/paradigm-network/paradigm/network/http/explorer"
+	"github.com/paradigm-network/paradigm/network/http/health"
 	"github.com/paradigm-network/paradigm/network/http/service"
 )
 
+//NewRPCServer returns a JSON-RPC server exposing the methods of the Service
+func NewRPCServer(s *service.Service) (*rpc.Server, error) {
+	server := rpc.NewServer()
+	methods := map[string]interface{}{
+		"GetStats":     s.GetStats,
+		"GetBlock":     s.GetBlock,
+		"GetComet":     s.GetComet,
+		"GetRound":     s.GetRound,
+		"GetLastRound": s.GetLastRound,
+		"GetPeers":     s.GetPeers,
+
+		"GetParticipantStats": s.GetParticipantStats,
+		"GetLiveness":         s.GetLiveness,
+		"GetReadiness":        s.GetReadiness,
+
+		"GetBlocks":            s.GetBlocks,
+		"GetCometWithParents":  s.GetCometWithParents,
+		"GetRoundWitnesses":    s.GetRoundWitnesses,
+		"GetParticipantList":   s.GetParticipantList,
+		"GetParticipantEvents": s.GetParticipantEvents,
+		"GetConsensusEvents":   s.GetConsensusEvents,
+		"ExportGraph":          s.ExportGraph,
+	}
+	for name, fn := range methods {
+		if err := server.Register(name, fn); err != nil {
+			return nil, err
+		}
+	}
+	return server, nil
+}
+
+//StartRPCServer serves the JSON-RPC API, the Prometheus metrics under /metrics,
+//the explorer endpoints under /explorer and the probes under /health, on
+//conf.RpcAddr. It blocks until the listener fails.
 func StartRPCServer(conf *config.Config, s *service.Service) error {
 
 	logger := log.GetLogger("jsonrpc")
-	logger.Info().Msg("RPCServer starting")
+	logger.Info().Str("addr", conf.RpcAddr).Msg("RPCServer starting")
 
-	http.HandleFunc("/", rpc.Handle)
+	server, err := NewRPCServer(s)
+	if err != nil {
+		return err
+	}
 
-	rpc.HandleFunc("GetStatus", s.GetStats)
-	rpc.HandleFunc("GetBlock", s.GetBlock)
+	//a mux of its own, the proxy API already uses the default one
+	mux := http.NewServeMux()
+	mux.Handle("/", server)
+	mux.Handle("/metrics", metrics.Handler())
+	mux.Handle(explorer.Prefix+"/", explorer.NewRouter(s))
+	mux.Handle(health.Prefix+"/", health.NewRouter(s))
 
-	err := http.ListenAndServe(conf.RpcAddr, nil)
+	err = http.ListenAndServe(conf.RpcAddr, mux)
 	if err != nil {
-		logger.Error().Msg("Service serve error.")
+		logger.Error().Err(err).Msg("Service serve error.")
 		return fmt.Errorf("ListenAndServe error:%s", err)
 	}
 	return nil
 }
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
-
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/paradigm-network/paradigm/core/sequentia"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//Methods and types backing the explorer endpoints. They only read the Store.
+
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+
+	DefaultGraphRounds = 10
+	MaxGraphRounds     = 50
+)
+
+type JsonBlock struct {
+	Index         int    `json:"index"`
+	Hash          string `json:"hash"`
+	RoundReceived int    `json:"roundReceived"`
+	StateHash     string `json:"stateHash"`
+	Transactions  int    `json:"transactions"`
+	Signatures    int    `json:"signatures"`
+}
+
+type JsonBlockList struct {
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+	Blocks []JsonBlock `json:"blocks"`
+}
+
+type JsonComet struct {
+	Hash             string    `json:"hash"`
+	Creator          string    `json:"creator"`
+	CreatorID        int       `json:"creatorId"` //-1 when the creator is not a participant
+	Index            int       `json:"index"`
+	SelfParent       string    `json:"selfParent"`
+	OtherParent      string    `json:"otherParent"`
+	Timestamp        time.Time `json:"timestamp"`
+	TopologicalIndex int       `json:"topologicalIndex"`
+	RoundReceived    *int      `json:"roundReceived"`
+	Transactions     int       `json:"transactions"`
+	BlockSignatures  int       `json:"blockSignatures"`
+}
+
+//JsonCometDetail is a comet along with its parents. A parent is missing when
+//the comet has none, or when it is a root the Store does not hold.
+type JsonCometDetail struct {
+	JsonComet
+	SelfParentComet  *JsonComet `json:"selfParentComet"`
+	OtherParentComet *JsonComet `json:"otherParentComet"`
+}
+
+type JsonWitness struct {
+	Hash      string `json:"hash"`
+	Creator   string `json:"creator"`
+	CreatorID int    `json:"creatorId"` //-1 when the creator is unknown
+	Famous    string `json:"famous"` //Undefined, True or False
+}
+
+type JsonRound struct {
+	Index            int           `json:"index"`
+	LastRound        int           `json:"lastRound"`
+	Events           int           `json:"events"`
+	WitnessesDecided bool          `json:"witnessesDecided"`
+	Witnesses        []JsonWitness `json:"witnesses"`
+}
+
+type JsonParticipant struct {
+	ID        int        `json:"id"`
+	PubKey    string     `json:"pubKey"` //key of the slot, the creator of the comets
+	Key       string     `json:"key"`    //key it signs with, PubKey until it rotates its key
+	LastIndex int        `json:"lastIndex"`
+	Root      types.Root `json:"root"`
+}
+
+type JsonParticipantEvent struct {
+	Index int    `json:"index"`
+	Hash  string `json:"hash"`
+}
+
+type JsonParticipantEvents struct {
+	ID     int                    `json:"id"`
+	PubKey string                 `json:"pubKey"`
+	From   int                    `json:"from"`
+	Events []JsonParticipantEvent `json:"events"`
+}
+
+//JsonConsensusEvents is a window of the consensus order: Events[i] is the
+//consensus comet number First+i.
+type JsonConsensusEvents struct {
+	Total  int      `json:"total"`
+	First  int      `json:"first"`
+	Events []string `json:"events"`
+}
+
+//GetBlocks lists the blocks newest first
+func (s *Service) GetBlocks(offset, limit int) (JsonBlockList, error) {
+	offset, limit = pageBounds(offset, limit)
+	res := JsonBlockList{
+		Total:  s.node.GetLastBlockIndex() + 1,
+		Offset: offset,
+		Limit:  limit,
+		Blocks: []JsonBlock{},
+	}
+	for index := res.Total - 1 - offset; index >= 0 && len(res.Blocks) < limit; index-- {
+		block, err := s.node.GetBlock(index)
+		if err != nil {
+			return res, err
+		}
+		hash, err := block.Hash()
+		if err != nil {
+			return res, err
+		}
+		res.Blocks = append(res.Blocks, JsonBlock{
+			Index:         block.Index(),
+			Hash:          fmt.Sprintf("0x%X", hash),
+			RoundReceived: block.RoundReceived(),
+			StateHash:     fmt.Sprintf("0x%X", block.StateHash()),
+			Transactions:  len(block.Transactions()),
+			Signatures:    len(block.Signatures),
+		})
+	}
+	return res, nil
+}
+
+//GetCometWithParents returns a comet and its parents
+func (s *Service) GetCometWithParents(hash string) (JsonCometDetail, error) {
+	comet, err := s.node.GetComet(hash)
+	if err != nil {
+		return JsonCometDetail{}, err
+	}
+	participants := s.node.GetParticipants()
+	res := JsonCometDetail{JsonComet: newJsonComet(comet, participants)}
+	if p, err := s.node.GetComet(comet.SelfParent()); err == nil {
+		jp := newJsonComet(p, participants)
+		res.SelfParentComet = &jp
+	}
+	if p, err := s.node.GetComet(comet.OtherParent()); err == nil {
+		jp := newJsonComet(p, participants)
+		res.OtherParentComet = &jp
+	}
+	return res, nil
+}
+
+//GetRoundWitnesses returns a round with the fame of its witnesses
+func (s *Service) GetRoundWitnesses(roundIndex int) (JsonRound, error) {
+	round, err := s.node.GetRound(roundIndex)
+	if err != nil {
+		return JsonRound{}, err
+	}
+	participants := s.node.GetParticipants()
+	res := JsonRound{
+		Index:            roundIndex,
+		LastRound:        s.node.GetLastRound(),
+		Events:           len(round.Events),
+		WitnessesDecided: round.WitnessesDecided(),
+		Witnesses:        []JsonWitness{},
+	}
+	for hash, event := range round.Events {
+		if !event.Witness {
+			continue
+		}
+		witness := JsonWitness{Hash: hash, CreatorID: -1, Famous: event.Famous.String()}
+		if comet, err := s.node.GetComet(hash); err == nil {
+			witness.Creator = comet.Creator()
+			witness.CreatorID = creatorID(participants, witness.Creator)
+		}
+		res.Witnesses = append(res.Witnesses, witness)
+	}
+	sort.Slice(res.Witnesses, func(i, j int) bool {
+		return res.Witnesses[i].CreatorID < res.Witnesses[j].CreatorID
+	})
+	return res, nil
+}
+
+//GetParticipantList returns the participants with their last known comet and
+//their root
+func (s *Service) GetParticipantList() ([]JsonParticipant, error) {
+	known := s.node.GetKnownEvents()
+	keys := s.node.GetParticipantKeys()
+	res := []JsonParticipant{}
+	for pubKey, id := range s.node.GetParticipants() {
+		root, err := s.node.GetRoot(pubKey)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, JsonParticipant{
+			ID:        id,
+			PubKey:    pubKey,
+			Key:       keys[id],
+			LastIndex: known[id],
+			Root:      root,
+		})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].ID < res[j].ID })
+	return res, nil
+}
+
+//GetParticipantEvents returns the comets created by a participant, from index
+//from on. The participant is given by id or by public key.
+func (s *Service) GetParticipantEvents(participant string, from int) (JsonParticipantEvents, error) {
+	pubKey, id, err := s.findParticipant(participant)
+	if err != nil {
+		return JsonParticipantEvents{}, err
+	}
+	if from < 0 {
+		from = 0
+	}
+	hashes, err := s.node.GetParticipantEvents(pubKey, from-1)
+	if err != nil {
+		return JsonParticipantEvents{}, err
+	}
+	res := JsonParticipantEvents{
+		ID:     id,
+		PubKey: pubKey,
+		From:   from,
+		Events: []JsonParticipantEvent{},
+	}
+	for i, hash := range hashes {
+		res.Events = append(res.Events, JsonParticipantEvent{Index: from + i, Hash: hash})
+	}
+	return res, nil
+}
+
+//GetConsensusEvents returns up to limit consensus comets, starting at the
+//consensus comet number from. Only the most recent consensus comets are
+//cached, from is moved up to the oldest one available.
+func (s *Service) GetConsensusEvents(from, limit int) (JsonConsensusEvents, error) {
+	_, limit = pageBounds(0, limit)
+	events, total := s.node.GetConsensusEvents()
+	first := total - len(events)
+	if from < first {
+		from = first
+	}
+	res := JsonConsensusEvents{
+		Total:  total,
+		First:  from,
+		Events: []string{},
+	}
+	start := from - first
+	if start >= len(events) {
+		return res, nil
+	}
+	end := start + limit
+	if end > len(events) {
+		end = len(events)
+	}
+	res.Events = append(res.Events, events[start:end]...)
+	return res, nil
+}
+
+//ExportGraph exports the rounds fromRound to toRound of the CometGraph. A
+//negative toRound stands for the last round, and a negative fromRound for the
+//DefaultGraphRounds rounds up to toRound. At most MaxGraphRounds are exported.
+func (s *Service) ExportGraph(fromRound, toRound int) (*sequentia.GraphExport, error) {
+	if toRound < 0 {
+		toRound = s.node.GetLastRound()
+	}
+	if fromRound < 0 {
+		fromRound = toRound - DefaultGraphRounds + 1
+	}
+	if toRound-fromRound >= MaxGraphRounds {
+		return nil, fmt.Errorf("cannot export more than %d rounds", MaxGraphRounds)
+	}
+	return s.node.ExportGraph(fromRound, toRound)
+}
+
+//findParticipant returns the slot key and the id of participant, an id or any
+//key the participant signed with
+func (s *Service) findParticipant(participant string) (pubKey string, id int, err error) {
+	target, ok := s.node.GetParticipantID(participant)
+	if !ok {
+		if target, err = strconv.Atoi(participant); err != nil {
+			return "", 0, fmt.Errorf("unknown participant %s", participant)
+		}
+	}
+	for pk, id := range s.node.GetParticipants() {
+		if id == target {
+			return pk, id, nil
+		}
+	}
+	return "", 0, fmt.Errorf("unknown participant %s", participant)
+}
+
+//creatorID returns the id of the participant creator, or -1 when it is not a
+//participant
+func creatorID(participants map[string]int, creator string) int {
+	if id, ok := participants[creator]; ok {
+		return id
+	}
+	return -1
+}
+
+func newJsonComet(comet types.Comet, participants map[string]int) JsonComet {
+	creator := comet.Creator()
+	return JsonComet{
+		Hash:             comet.Hex(),
+		Creator:          creator,
+		CreatorID:        creatorID(participants, creator),
+		Index:            comet.Index(),
+		SelfParent:       comet.SelfParent(),
+		OtherParent:      comet.OtherParent(),
+		Timestamp:        comet.Body.Timestamp,
+		TopologicalIndex: comet.TopologicalIndex,
+		RoundReceived:    comet.RoundReceived,
+		Transactions:     len(comet.Transactions()),
+		BlockSignatures:  len(comet.Body.BlockSignatures),
+	}
+}
+
+//pageBounds applies the default and maximum page sizes
+func pageBounds(offset, limit int) (int, int) {
+	if offset < 0 {
+		offset = 0
+	}
+	if limit <= 0 {
+		limit = DefaultPageSize
+	}
+	if limit > MaxPageSize {
+		limit = MaxPageSize
+	}
+	return offset, limit
+}
@@ -1,12 +1,13 @@
 package service
 
 import (
-	"net/http"
-	"encoding/json"
-	"strconv"
 	"github.com/paradigm-network/paradigm/core"
+	"github.com/paradigm-network/paradigm/network/peer"
+	"github.com/paradigm-network/paradigm/types"
 )
 
+//Service exposes the state of the node to the JSON-RPC server. Every exported
+//method is meant to be registered as a JSON-RPC method.
 type Service struct {
 	node *core.Node
 }
@@ -19,27 +20,38 @@ func NewService(node *core.Node) *Service {
 	return &service
 }
 
-func (s *Service) GetStats(w http.ResponseWriter, r *http.Request) {
-	stats := s.node.GetStats()
+func (s *Service) GetStats() (map[string]string, error) {
+	return s.node.GetStats(), nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stats)
+func (s *Service) GetBlock(blockIndex int) (types.Block, error) {
+	return s.node.GetBlock(blockIndex)
 }
 
-func (s *Service) GetBlock(w http.ResponseWriter, r *http.Request) {
-	param := r.URL.Path[len("/block/"):]
-	blockIndex, err := strconv.Atoi(param)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+func (s *Service) GetComet(hash string) (types.Comet, error) {
+	return s.node.GetComet(hash)
+}
 
-	block, err := s.node.GetBlock(blockIndex)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
+func (s *Service) GetRound(roundIndex int) (types.RoundInfo, error) {
+	return s.node.GetRound(roundIndex)
+}
+
+func (s *Service) GetLastRound() (int, error) {
+	return s.node.GetLastRound(), nil
+}
+
+func (s *Service) GetPeers() ([]peer.Peer, error) {
+	return s.node.GetPeers(), nil
+}
+
+func (s *Service) GetParticipantStats() ([]core.ParticipantStats, error) {
+	return s.node.GetParticipantStats(), nil
+}
+
+func (s *Service) GetLiveness() (core.Health, error) {
+	return s.node.Liveness(), nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(block)
+func (s *Service) GetReadiness() (core.Health, error) {
+	return s.node.Readiness(), nil
 }
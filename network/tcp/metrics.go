@@ -0,0 +1,25 @@
+package tcp
+
+import (
+	"github.com/paradigm-network/paradigm/common/metrics"
+)
+
+var (
+	rpcDuration = metrics.NewHistogramVec("paradigm_transport_rpc_duration_seconds",
+		"Round trip duration of the gossip RPCs sent to a peer.",
+		metrics.DefBuckets, "peer", "rpc")
+	rpcErrors = metrics.NewCounterVec("paradigm_transport_rpc_errors_total",
+		"Number of gossip RPCs sent to a peer that failed.",
+		"peer", "rpc")
+)
+
+func rpcName(rpcType uint8) string {
+	switch rpcType {
+	case rpcSync:
+		return "sync"
+	case rpcEagerSync:
+		return "eager_sync"
+	default:
+		return "unknown"
+	}
+}
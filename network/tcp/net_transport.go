@@ -211,7 +211,16 @@ func (n *NetworkTransport) returnConn(conn *netConn) {
 }
 
 // genericnetwork.RPC handles a simple request/response network.RPC.
-func (n *NetworkTransport) genericRPC(target string, rpcType uint8, args interface{}, resp interface{}) error {
+func (n *NetworkTransport) genericRPC(target string, rpcType uint8, args interface{}, resp interface{}) (err error) {
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			rpcErrors.WithLabelValues(target, rpcName(rpcType)).Inc()
+			return
+		}
+		rpcDuration.WithLabelValues(target, rpcName(rpcType)).ObserveSince(start)
+	}()
+
 	// Get a conn
 	conn, err := n.getConn(target, n.timeout)
 	if err != nil {
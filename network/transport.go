@@ -2,6 +2,7 @@ package network
 
 import (
 	"io"
+	"github.com/paradigm-network/paradigm/common"
 	"github.com/paradigm-network/paradigm/types"
 )
 
@@ -24,23 +25,28 @@ func (r *RPC) Respond(resp interface{}, err error) {
 }
 
 
+//The requests and the SyncResponse carry the genesis hash of the sender, nodes
+//of different chains refuse to gossip
 type SyncRequest struct {
-	FromID int
-	Known  map[int]int
+	FromID      int
+	GenesisHash common.Hash
+	Known       map[int]int
 }
 
 type SyncResponse struct {
-	FromID    int
-	SyncLimit bool
-	Events    []types.WireEvent
-	Known     map[int]int
+	FromID      int
+	GenesisHash common.Hash
+	SyncLimit   bool
+	Events      []types.WireEvent
+	Known       map[int]int
 }
 
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 type EagerSyncRequest struct {
-	FromID int
-	Events []types.WireEvent
+	FromID      int
+	GenesisHash common.Hash
+	Events      []types.WireEvent
 }
 
 type EagerSyncResponse struct {
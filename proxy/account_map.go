@@ -1,7 +0,0 @@
-package proxy
-
-type AccountMap map[string]struct {
-	Code    string
-	Storage map[string]string
-	Balance string
-}
\ No newline at end of file
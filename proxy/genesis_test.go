@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/log"
+	"github.com/paradigm-network/paradigm/genesis"
+	"github.com/paradigm-network/paradigm/storage"
+)
+
+//newTestState opens the State of the Badger store of dir, created when
+//missing
+func newTestState(t *testing.T, dir string) (*State, *storage.BadgerStore) {
+	log.InitRotateWriter(filepath.Join(filepath.Dir(dir), "test.log"))
+	store, err := storage.NewBadgerStore(map[string]int{}, 10, dir)
+	if err != nil {
+		if store, err = storage.LoadBadgerStore(10, dir); err != nil {
+			t.Fatal(err)
+		}
+	}
+	state, err := NewState(store, big.NewInt(1), big.NewInt(genesis.DefaultBlockGasLimit), false)
+	if err != nil {
+		store.Close()
+		t.Fatal(err)
+	}
+	return state, store
+}
+
+func TestGenesisAppliedOnce(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "badger")
+
+	addr := common.HexToAddress("0x629007eb99ff5c3539ada8a5800847eacfc25727")
+	gen := &genesis.Genesis{Alloc: genesis.Alloc{addr.Hex(): {Balance: "0x10"}}}
+	if err := gen.Complete(1, []string{"0x01"}); err != nil {
+		t.Fatal(err)
+	}
+
+	//applied by the first start, then by none of the restarts
+	for i := 0; i < 3; i++ {
+		state, store := newTestState(t, path)
+		m := &Service{state: state, genesis: gen}
+		if err := m.createGenesisAccounts(); err != nil {
+			store.Close()
+			t.Fatalf("start %d: %s", i, err)
+		}
+		if i == 0 {
+			if hash, ok := genesis.Stored(store); !ok || hash != gen.Hash() {
+				t.Fatal("genesis not marked by its commit")
+			}
+		}
+		balance := state.GetBalance(addr)
+		store.Close()
+		if balance.Int64() != 16 {
+			t.Fatalf("start %d: balance %s, expected 16", i, balance)
+		}
+	}
+
+	//refused with another genesis
+	other := &genesis.Genesis{Alloc: genesis.Alloc{addr.Hex(): {Balance: "0x20"}}}
+	if err := other.Complete(1, []string{"0x01"}); err != nil {
+		t.Fatal(err)
+	}
+	state, store := newTestState(t, path)
+	defer store.Close()
+	m := &Service{state: state, genesis: other}
+	if err := m.createGenesisAccounts(); err == nil {
+		t.Fatal("applied another genesis")
+	}
+}
+
+func TestGenesisStoreWithoutMarker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-genesis")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "badger")
+
+	addr := common.HexToAddress("0x629007eb99ff5c3539ada8a5800847eacfc25727")
+	gen := &genesis.Genesis{Alloc: genesis.Alloc{addr.Hex(): {Balance: "0x10"}}}
+	if err := gen.Complete(1, []string{"0x01"}); err != nil {
+		t.Fatal(err)
+	}
+
+	//a store of a node before the genesis marker, the allocations applied
+	state, store := newTestState(t, path)
+	state.was.stateDB.AddBalance(addr, big.NewInt(16))
+	if _, err := state.commit(); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	state, store = newTestState(t, path)
+	defer store.Close()
+	m := &Service{state: state, genesis: gen}
+	if err := m.createGenesisAccounts(); err != nil {
+		t.Fatal(err)
+	}
+	if balance := state.GetBalance(addr); balance.Int64() != 16 {
+		t.Fatalf("balance %s, expected 16", balance)
+	}
+	if hash, ok := genesis.Stored(store); !ok || hash != gen.Hash() {
+		t.Fatal("genesis not marked")
+	}
+}
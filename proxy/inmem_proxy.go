@@ -2,19 +2,23 @@ package proxy
 
 import (
 	"bytes"
+	"math/big"
+
+	"github.com/paradigm-network/paradigm/common"
 	"github.com/paradigm-network/paradigm/common/log"
 	"github.com/paradigm-network/paradigm/common/rlp"
 	"github.com/paradigm-network/paradigm/config"
+	"github.com/paradigm-network/paradigm/genesis"
+	"github.com/paradigm-network/paradigm/signer"
 	"github.com/paradigm-network/paradigm/storage"
 	"github.com/paradigm-network/paradigm/types"
 	"github.com/rs/zerolog"
-	"sync/atomic"
-	"time"
 )
 
 type AppProxy interface {
 	SubmitCh() chan []byte
 	CommitBlock(block types.Block) ([]byte, error)
+	GenesisHash() common.Hash
 }
 
 //InmemProxy is used for testing
@@ -26,14 +30,18 @@ type InmemAppProxy struct {
 	store                 storage.Store
 	service               *Service
 	state                 *State
+	genesisHash           common.Hash
 }
 
-var ops int64 = 0
-
-func NewInmemAppProxy(config *config.Config, store storage.Store) *InmemAppProxy {
+//NewInmemAppProxy creates the proxy of a chain of the completed genesis gen.
+//txSigner, when not nil, signs the transactions of the accounts the keystore
+//and the HD wallets do not hold.
+func NewInmemAppProxy(config *config.Config, store storage.Store, gen *genesis.Genesis, txSigner signer.TxSigner) *InmemAppProxy {
 	logger := log.GetLogger("InMemProxy")
 	submitCh := make(chan []byte)
-	state, err := NewState(store)
+	chainID := new(big.Int).SetUint64(gen.Config.ChainID)
+	blockGasLimit := new(big.Int).SetUint64(gen.Consensus.BlockGasLimit)
+	state, err := NewState(store, chainID, blockGasLimit, config.AllowUnprotectedTxs)
 	if err != nil {
 		logger.Error().Err(err).Msg("Create AppProxy error")
 		return nil
@@ -43,7 +51,9 @@ func NewInmemAppProxy(config *config.Config, store storage.Store) *InmemAppProxy
 		config.SequentiaAddress,
 		config.PwdFile,
 		state,
-		submitCh)
+		submitCh,
+		gen,
+		txSigner)
 	proxy := &InmemAppProxy{
 		stateHash:             []byte{},
 		committedTransactions: [][]byte{},
@@ -52,16 +62,9 @@ func NewInmemAppProxy(config *config.Config, store storage.Store) *InmemAppProxy
 		submitCh:              submitCh,
 		state:                 state,
 		store:                 store,
+		genesisHash:           gen.Hash(),
 	}
 	proxy.Run()
-
-	go func() {
-		for {
-			logger.Info().Int64("Current TPS ", atomic.LoadInt64(&ops)).Msg("Proxy TPS")
-			time.Sleep(time.Second)
-			atomic.StoreInt64(&ops, 0)
-		}
-	}()
 	return proxy
 }
 
@@ -82,9 +85,6 @@ func (iap *InmemAppProxy) commit(block types.Block) ([]byte, error) {
 			Msg("commit block txs seq")
 	}
 	stateHash, err := iap.state.ProcessBlock(block)
-	if err == nil {
-		atomic.AddInt64(&ops,int64(len(block.Transactions())))
-	}
 	return stateHash.Bytes(), err
 
 }
@@ -104,6 +104,10 @@ func (p *InmemAppProxy) CommitBlock(block types.Block) (stateHash []byte, err er
 	return p.commit(block)
 }
 
+func (p *InmemAppProxy) GenesisHash() common.Hash {
+	return p.genesisHash
+}
+
 //------------------------------------------------------------------------------
 
 func (p *InmemAppProxy) SubmitTx(tx []byte) {
@@ -113,3 +117,8 @@ func (p *InmemAppProxy) SubmitTx(tx []byte) {
 func (p *InmemAppProxy) GetCommittedTransactions() [][]byte {
 	return p.committedTransactions
 }
+
+//PublishComet publishes the insertion of comet to the WebSocket clients
+func (p *InmemAppProxy) PublishComet(comet types.Comet, self bool) {
+	p.state.PublishComet(comet, self)
+}
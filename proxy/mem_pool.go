@@ -21,9 +21,11 @@ func NewTxPool(state *state.StateDB,
 	gasLimit *big.Int) *TxPool {
 
 	return &TxPool{
-		stateDB:  state,
-		signer:   signer,
-		gasLimit: gasLimit,
+		stateDB:      state,
+		signer:       signer,
+		gasLimit:     gasLimit,
+		totalUsedGas: big.NewInt(0),
+		gp:           new(GasPool).AddGas(gasLimit),
 	}
 }
 
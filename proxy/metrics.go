@@ -0,0 +1,12 @@
+package proxy
+
+import (
+	"github.com/paradigm-network/paradigm/common/metrics"
+)
+
+var (
+	txsApplied = metrics.NewCounter("paradigm_transactions_applied_total",
+		"Number of transactions applied successfully to the state.")
+	txsFailed = metrics.NewCounter("paradigm_transactions_failed_total",
+		"Number of transactions that could not be applied or whose execution failed.")
+)
@@ -0,0 +1,416 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/types"
+	"github.com/paradigm-network/paradigm/version"
+)
+
+//Ethereum compatible JSON-RPC methods, served on the same /rpc endpoint as the
+//paradigm_* methods so that existing wallets and web3 libraries can be pointed
+//at the proxy.
+
+const (
+	latestBlockTag   = "latest"
+	pendingBlockTag  = "pending"
+	earliestBlockTag = "earliest"
+)
+
+// CallArgs represents the arguments of eth_call and eth_estimateGas, encoded
+// the way web3 libraries send them.
+type CallArgs struct {
+	From     common.Address  `json:"from"`
+	To       *common.Address `json:"to"`
+	Gas      *hexutil.Big    `json:"gas"`
+	GasPrice *hexutil.Big    `json:"gasPrice"`
+	Value    *hexutil.Big    `json:"value"`
+	Data     hexutil.Bytes   `json:"data"`
+	Input    hexutil.Bytes   `json:"input"`
+}
+
+func (args CallArgs) toMessage(defaultGas *big.Int) TxMessage {
+	gas := defaultGas
+	if args.Gas != nil {
+		gas = args.Gas.ToInt()
+	}
+	gasPrice := big.NewInt(0)
+	if args.GasPrice != nil {
+		gasPrice = args.GasPrice.ToInt()
+	}
+	value := big.NewInt(0)
+	if args.Value != nil {
+		value = args.Value.ToInt()
+	}
+	data := args.Data
+	if len(args.Input) > 0 {
+		data = args.Input
+	}
+	return NewTxMessage(args.From, args.To, 0, value, gas, gasPrice, data, false)
+}
+
+// RPCTransaction is the web3 representation of a transaction
+type RPCTransaction struct {
+	BlockHash        common.Hash     `json:"blockHash"`
+	BlockNumber      *hexutil.Big    `json:"blockNumber"`
+	From             common.Address  `json:"from"`
+	Gas              *hexutil.Big    `json:"gas"`
+	GasPrice         *hexutil.Big    `json:"gasPrice"`
+	Hash             common.Hash     `json:"hash"`
+	Input            hexutil.Bytes   `json:"input"`
+	Nonce            hexutil.Uint64  `json:"nonce"`
+	To               *common.Address `json:"to"`
+	TransactionIndex hexutil.Uint    `json:"transactionIndex"`
+	Value            *hexutil.Big    `json:"value"`
+	V                *hexutil.Big    `json:"v"`
+	R                *hexutil.Big    `json:"r"`
+	S                *hexutil.Big    `json:"s"`
+}
+
+// RPCReceipt is the web3 representation of a transaction receipt
+type RPCReceipt struct {
+	BlockHash         common.Hash     `json:"blockHash"`
+	BlockNumber       *hexutil.Big    `json:"blockNumber"`
+	TransactionHash   common.Hash     `json:"transactionHash"`
+	TransactionIndex  hexutil.Uint    `json:"transactionIndex"`
+	From              common.Address  `json:"from"`
+	To                *common.Address `json:"to"`
+	GasUsed           *hexutil.Big    `json:"gasUsed"`
+	CumulativeGasUsed *hexutil.Big    `json:"cumulativeGasUsed"`
+	ContractAddress   *common.Address `json:"contractAddress"`
+	Logs              []*RPCLog       `json:"logs"`
+	LogsBloom         types.Bloom     `json:"logsBloom"`
+	Root              hexutil.Bytes   `json:"root,omitempty"`
+	Status            hexutil.Uint    `json:"status"`
+}
+
+// RPCLog is the web3 representation of a log
+type RPCLog struct {
+	Address          common.Address `json:"address"`
+	Topics           []common.Hash  `json:"topics"`
+	Data             hexutil.Bytes  `json:"data"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	LogIndex         hexutil.Uint   `json:"logIndex"`
+	Removed          bool           `json:"removed"`
+}
+
+// RPCBlock is the web3 representation of a block. Transactions holds either
+// the hashes or the full RPCTransactions.
+type RPCBlock struct {
+	Number        *hexutil.Big   `json:"number"`
+	Hash          common.Hash    `json:"hash"`
+	ParentHash    common.Hash    `json:"parentHash"`
+	StateRoot     hexutil.Bytes  `json:"stateRoot"`
+	RoundReceived hexutil.Uint64 `json:"roundReceived"`
+	Transactions  []interface{}  `json:"transactions"`
+}
+
+func newRPCTransaction(tx *types.Transaction, from common.Address, entry *TxLookupEntry) *RPCTransaction {
+	v, r, s := tx.RawSignatureValues()
+	result := &RPCTransaction{
+		From:     from,
+		Gas:      (*hexutil.Big)(tx.Gas()),
+		GasPrice: (*hexutil.Big)(tx.GasPrice()),
+		Hash:     tx.Hash(),
+		Input:    hexutil.Bytes(tx.Data()),
+		Nonce:    hexutil.Uint64(tx.Nonce()),
+		To:       tx.To(),
+		Value:    (*hexutil.Big)(tx.Value()),
+		V:        (*hexutil.Big)(v),
+		R:        (*hexutil.Big)(r),
+		S:        (*hexutil.Big)(s),
+	}
+	if entry != nil {
+		result.BlockHash = entry.BlockHash
+		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(entry.BlockIndex))
+		result.TransactionIndex = hexutil.Uint(entry.Index)
+	}
+	return result
+}
+
+func newRPCLogs(logs []*types.Log) []*RPCLog {
+	result := make([]*RPCLog, len(logs))
+	for i, log := range logs {
+		result[i] = &RPCLog{
+			Address:          log.Address,
+			Topics:           log.Topics,
+			Data:             log.Data,
+			BlockNumber:      hexutil.Uint64(log.BlockNumber),
+			TransactionHash:  log.TxHash,
+			TransactionIndex: hexutil.Uint(log.TxIndex),
+			BlockHash:        log.BlockHash,
+			LogIndex:         hexutil.Uint(log.Index),
+			Removed:          log.Removed,
+		}
+		if result[i].Topics == nil {
+			result[i].Topics = []common.Hash{}
+		}
+	}
+	return result
+}
+
+//resolveBlockNumber turns a block tag or hex quantity into a block index
+func (m *Service) resolveBlockNumber(tag string) (int, error) {
+	switch tag {
+	case "", latestBlockTag, pendingBlockTag:
+		return m.state.GetLastBlockIndex(), nil
+	case earliestBlockTag:
+		return 0, nil
+	}
+	number, err := hexutil.DecodeUint64(tag)
+	if err != nil {
+		return 0, &jsonRPCError{Code: rpcInvalidParams, Message: fmt.Sprintf("invalid block number %q", tag)}
+	}
+	return int(number), nil
+}
+
+//checkLatestState makes sure a state query targets the last committed State.
+//Historical states are not kept around.
+func (m *Service) checkLatestState(tag string) error {
+	index, err := m.resolveBlockNumber(tag)
+	if err != nil {
+		return err
+	}
+	if tag != earliestBlockTag && index == m.state.GetLastBlockIndex() {
+		return nil
+	}
+	return &jsonRPCError{Code: rpcInvalidParams, Message: "only the latest state is available"}
+}
+
+func ethSendRawTransaction(m *Service, params json.RawMessage) (interface{}, error) {
+	var rawTx hexutil.Bytes
+	if err := parseParams(params, &rawTx); err != nil {
+		return nil, err
+	}
+	tx, err := m.submitRawTransaction(rawTx)
+	if err != nil {
+		return nil, err
+	}
+	return tx.Hash(), nil
+}
+
+func ethGetBalance(m *Service, params json.RawMessage) (interface{}, error) {
+	var address common.Address
+	var tag string
+	if err := parseParams(params, &address, &tag); err != nil {
+		return nil, err
+	}
+	if err := m.checkLatestState(tag); err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(m.state.GetBalance(address)), nil
+}
+
+func ethGetTransactionCount(m *Service, params json.RawMessage) (interface{}, error) {
+	var address common.Address
+	var tag string
+	if err := parseParams(params, &address, &tag); err != nil {
+		return nil, err
+	}
+	if tag == pendingBlockTag {
+		return hexutil.Uint64(m.state.GetPoolNonce(address)), nil
+	}
+	if err := m.checkLatestState(tag); err != nil {
+		return nil, err
+	}
+	return hexutil.Uint64(m.state.GetNonce(address)), nil
+}
+
+func ethGetTransactionByHash(m *Service, params json.RawMessage) (interface{}, error) {
+	var txHash common.Hash
+	if err := parseParams(params, &txHash); err != nil {
+		return nil, err
+	}
+	//unknown transactions are reported as null, like pending ones
+	tx, err := m.state.GetTransaction(txHash)
+	if err != nil {
+		return nil, nil
+	}
+	from, err := types.Sender(m.state.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	entry, _ := m.state.GetTxLookupEntry(txHash)
+	return newRPCTransaction(tx, from, entry), nil
+}
+
+func ethGetTransactionReceipt(m *Service, params json.RawMessage) (interface{}, error) {
+	var txHash common.Hash
+	if err := parseParams(params, &txHash); err != nil {
+		return nil, err
+	}
+	//no receipt until the transaction has been applied
+	receipt, err := m.state.GetReceipt(txHash)
+	if err != nil {
+		return nil, nil
+	}
+	tx, err := m.state.GetTransaction(txHash)
+	if err != nil {
+		return nil, err
+	}
+	from, err := types.Sender(m.state.signer, tx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RPCReceipt{
+		TransactionHash:   txHash,
+		From:              from,
+		To:                tx.To(),
+		GasUsed:           (*hexutil.Big)(receipt.GasUsed),
+		CumulativeGasUsed: (*hexutil.Big)(receipt.CumulativeGasUsed),
+		Logs:              newRPCLogs(receipt.Logs),
+		LogsBloom:         receipt.Bloom,
+		Root:              receipt.PostState,
+		Status:            hexutil.Uint(receipt.Status),
+	}
+	if receipt.ContractAddress != (common.Address{}) {
+		result.ContractAddress = &receipt.ContractAddress
+	}
+	if entry, err := m.state.GetTxLookupEntry(txHash); err == nil {
+		result.BlockHash = entry.BlockHash
+		result.BlockNumber = (*hexutil.Big)(new(big.Int).SetUint64(entry.BlockIndex))
+		result.TransactionIndex = hexutil.Uint(entry.Index)
+	}
+	return result, nil
+}
+
+func ethGetLogs(m *Service, params json.RawMessage) (interface{}, error) {
+	var args FilterArgs
+	if err := parseParams(params, &args); err != nil {
+		return nil, err
+	}
+	query, err := m.toFilterQuery(args)
+	if err != nil {
+		if _, ok := err.(*jsonRPCError); !ok {
+			err = &jsonRPCError{Code: rpcInvalidParams, Message: err.Error()}
+		}
+		return nil, err
+	}
+	logs, err := m.state.FilterLogs(query)
+	if err != nil {
+		return nil, err
+	}
+	return newRPCLogs(logs), nil
+}
+
+func ethBlockNumber(m *Service, params json.RawMessage) (interface{}, error) {
+	index := m.state.GetLastBlockIndex()
+	if index < 0 {
+		index = 0
+	}
+	return hexutil.Uint64(index), nil
+}
+
+func ethGetBlockByNumber(m *Service, params json.RawMessage) (interface{}, error) {
+	var tag string
+	var fullTx bool
+	if err := parseParams(params, &tag, &fullTx); err != nil {
+		return nil, err
+	}
+	index, err := m.resolveBlockNumber(tag)
+	if err != nil {
+		return nil, err
+	}
+	//blocks that were not applied yet are reported as null
+	if index < 0 || index > m.state.GetLastBlockIndex() {
+		return nil, nil
+	}
+	block, err := m.state.GetBlock(index)
+	if err != nil {
+		return nil, nil
+	}
+	blockHash, err := m.state.GetBlockHash(index)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RPCBlock{
+		Number:        (*hexutil.Big)(big.NewInt(int64(index))),
+		Hash:          blockHash,
+		StateRoot:     block.StateHash(),
+		RoundReceived: hexutil.Uint64(block.RoundReceived()),
+		Transactions:  []interface{}{},
+	}
+	if index > 0 {
+		result.ParentHash, _ = m.state.GetBlockHash(index - 1)
+	}
+	for i, txBytes := range block.Transactions() {
+		var tx types.Transaction
+		if err := rlp.Decode(bytes.NewReader(txBytes), &tx); err != nil {
+			return nil, err
+		}
+		if !fullTx {
+			result.Transactions = append(result.Transactions, tx.Hash())
+			continue
+		}
+		from, err := types.Sender(m.state.signer, &tx)
+		if err != nil {
+			return nil, err
+		}
+		entry := &TxLookupEntry{BlockHash: blockHash, BlockIndex: uint64(index), Index: uint64(i)}
+		result.Transactions = append(result.Transactions, newRPCTransaction(&tx, from, entry))
+	}
+	return result, nil
+}
+
+func ethCall(m *Service, params json.RawMessage) (interface{}, error) {
+	var args CallArgs
+	var tag string
+	if err := parseParams(params, &args, &tag); err != nil {
+		return nil, err
+	}
+	if err := m.checkLatestState(tag); err != nil {
+		return nil, err
+	}
+	data, _, _, err := m.state.Call(args.toMessage(defaultGas))
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(data), nil
+}
+
+func ethEstimateGas(m *Service, params json.RawMessage) (interface{}, error) {
+	var args CallArgs
+	if err := parseParams(params, &args); err != nil {
+		return nil, err
+	}
+	gas, err := m.state.EstimateGas(args.toMessage(nil))
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(gas), nil
+}
+
+func ethGasPrice(m *Service, params json.RawMessage) (interface{}, error) {
+	return (*hexutil.Big)(big.NewInt(0)), nil
+}
+
+func ethAccounts(m *Service, params json.RawMessage) (interface{}, error) {
+	addresses := []common.Address{}
+	for _, account := range m.accounts() {
+		addresses = append(addresses, account.Address)
+	}
+	return addresses, nil
+}
+
+func ethChainId(m *Service, params json.RawMessage) (interface{}, error) {
+	return (*hexutil.Big)(m.state.ChainID()), nil
+}
+
+func netVersion(m *Service, params json.RawMessage) (interface{}, error) {
+	return m.state.ChainID().String(), nil
+}
+
+func web3ClientVersion(m *Service, params json.RawMessage) (interface{}, error) {
+	return "Paradigm/v" + version.Version, nil
+}
@@ -0,0 +1,51 @@
+package proxy
+
+import (
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/event"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//ChainEvent is published every time the State committed a block
+type ChainEvent struct {
+	BlockIndex    int
+	BlockHash     common.Hash
+	StateRoot     common.Hash
+	RoundReceived int
+	Transactions  []*types.Transaction
+	Receipts      []*types.Receipt
+	Logs          []*types.Log
+}
+
+//CometEvent is published every time the node inserted a comet in its
+//CometGraph, Self when the node created it
+type CometEvent struct {
+	Comet types.Comet
+	Self  bool
+}
+
+//chainEventBus carries the ChainEvents and the CometEvents to their
+//subscribers. Publishing waits until every subscriber took the event, so
+//subscribers must drain their channel promptly: the WebSocket connections hand
+//the events over to their send queue, and drop the clients that fall behind.
+type chainEventBus struct {
+	chain  event.Feed
+	comets event.Feed
+}
+
+//SubscribeChainEvents delivers the blocks committed from now on to ch, until
+//the subscription is unsubscribed
+func (s *State) SubscribeChainEvents(ch chan<- ChainEvent) event.Subscription {
+	return s.chainEvents.chain.Subscribe(ch)
+}
+
+//SubscribeCometEvents delivers the comets inserted from now on to ch, until
+//the subscription is unsubscribed
+func (s *State) SubscribeCometEvents(ch chan<- CometEvent) event.Subscription {
+	return s.chainEvents.comets.Subscribe(ch)
+}
+
+//PublishComet publishes the insertion of comet by the node
+func (s *State) PublishComet(comet types.Comet, self bool) {
+	s.chainEvents.comets.Send(CometEvent{Comet: comet, Self: self})
+}
@@ -0,0 +1,149 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func TestChainEventBus(t *testing.T) {
+	var s State
+	chain := make(chan ChainEvent, 1)
+	comets := make(chan CometEvent, 1)
+	chainSub := s.SubscribeChainEvents(chain)
+	cometSub := s.SubscribeCometEvents(comets)
+
+	s.chainEvents.chain.Send(ChainEvent{BlockIndex: 1})
+	if ev := <-chain; ev.BlockIndex != 1 {
+		t.Fatalf("expected block 1, got %d", ev.BlockIndex)
+	}
+	s.PublishComet(types.Comet{Body: types.CometBody{Index: 2}}, true)
+	if ev := <-comets; ev.Comet.Index() != 2 || !ev.Self {
+		t.Fatalf("comet event %+v", ev)
+	}
+
+	//no delivery after unsubscribing
+	chainSub.Unsubscribe()
+	cometSub.Unsubscribe()
+	if n := s.chainEvents.chain.Send(ChainEvent{BlockIndex: 2}); n != 0 {
+		t.Fatalf("sent to %d subscribers", n)
+	}
+	s.PublishComet(types.Comet{}, false)
+	select {
+	case ev := <-comets:
+		t.Fatalf("comet event %+v after unsubscribing", ev)
+	default:
+	}
+}
+
+func TestProcessBlockPublishesUnlocked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state, store := newTestState(t, filepath.Join(dir, "badger"))
+	defer store.Close()
+
+	//nobody reads chain until the Call went through
+	chain := make(chan ChainEvent)
+	sub := state.SubscribeChainEvents(chain)
+	defer sub.Unsubscribe()
+
+	processed := make(chan error, 1)
+	go func() {
+		_, err := state.ProcessBlock(types.NewBlock(0, 1, nil))
+		processed <- err
+	}()
+
+	//wait until ProcessBlock is blocked on the subscriber
+	time.Sleep(100 * time.Millisecond)
+	called := make(chan error, 1)
+	go func() {
+		to := common.HexToAddress("0x01")
+		msg := NewTxMessage(common.HexToAddress("0x02"), &to, 0, big.NewInt(0),
+			big.NewInt(21000), big.NewInt(0), nil, false)
+		_, _, _, err := state.Call(msg)
+		called <- err
+	}()
+	select {
+	case err := <-called:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Call blocked by a slow chain subscriber")
+	}
+
+	if ev := <-chain; ev.BlockIndex != 0 || ev.RoundReceived != 1 {
+		t.Fatalf("chain event %+v", ev)
+	}
+	if err := <-processed; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWebSocketNewComets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-ws")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state, store := newTestState(t, filepath.Join(dir, "badger"))
+	defer store.Close()
+	m := &Service{state: state}
+
+	ts := httptest.NewServer(http.HandlerFunc(m.wsHandler))
+	defer ts.Close()
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(ts.URL, "http"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	if err := conn.WriteJSON(map[string]interface{}{
+		"jsonrpc": "2.0", "id": 1, "method": "eth_subscribe", "params": []string{"newComets"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var res struct {
+		Result string
+		Error  *jsonRPCError
+	}
+	if err := conn.ReadJSON(&res); err != nil || res.Error != nil {
+		t.Fatalf("subscribe: %v %v", err, res.Error)
+	}
+
+	tx := []byte("tx")
+	comet := types.Comet{Body: types.CometBody{Creator: []byte{1}, Parents: []string{"a", "b"}, Index: 4, Transactions: [][]byte{tx}}}
+	state.PublishComet(comet, false)
+
+	var notification struct {
+		Params struct {
+			Subscription string
+			Result       RPCComet
+		}
+	}
+	if err := conn.ReadJSON(&notification); err != nil {
+		t.Fatal(err)
+	}
+	got := notification.Params.Result
+	if notification.Params.Subscription != res.Result || got.Hash != comet.Hex() || got.Index != 4 || got.Self {
+		t.Fatalf("notification %+v", notification.Params)
+	}
+	if len(got.Transactions) != 1 || got.Transactions[0] != crypto.Keccak256Hash(tx) {
+		t.Fatalf("transactions %v", got.Transactions)
+	}
+}
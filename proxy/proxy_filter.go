@@ -0,0 +1,302 @@
+package proxy
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//Logs are indexed per block, and the blooms of the blocks are folded into
+//MIP-mapped blooms: one bloom for every range of MIPMapLevels[i] blocks. A
+//filter query walks the levels from the coarsest down and skips every range
+//whose bloom cannot match, so only the logs of matching blocks are decoded.
+
+var (
+	mipmapPrefix     = []byte("mipmap-log-bloom-")
+	blockBloomPrefix = []byte("blockbloom-")
+	blockLogsPrefix  = []byte("blocklogs-")
+
+	errInvalidBlockRange = errors.New("invalid block range")
+)
+
+//FilterQuery selects the logs emitted between FromBlock and ToBlock, included.
+//A log matches when it was emitted by one of Addresses (any if empty) and
+//every position of Topics matches: an empty position is a wildcard, otherwise
+//the topic at that position must be one of the listed hashes.
+type FilterQuery struct {
+	FromBlock int
+	ToBlock   int
+	Addresses []common.Address
+	Topics    [][]common.Hash
+}
+
+//FilterLogs returns the logs matching the query, in block order.
+func (s *State) FilterLogs(query FilterQuery) ([]*types.Log, error) {
+	logs := []*types.Log{}
+	//no block was processed yet
+	if query.ToBlock < 0 {
+		return logs, nil
+	}
+	if query.FromBlock < 0 || query.ToBlock < query.FromBlock {
+		return nil, errInvalidBlockRange
+	}
+	err := s.mipFind(uint64(query.FromBlock), uint64(query.ToBlock), 0, query, func(number uint64) error {
+		blockLogs, err := s.getBlockLogs(number, query)
+		logs = append(logs, blockLogs...)
+		return err
+	})
+	return logs, err
+}
+
+//mipFind calls found for every block of [start, end] whose bloom matches the
+//query, skipping the ranges of MIPMapLevels[depth] blocks that cannot match.
+func (s *State) mipFind(start, end uint64, depth int, query FilterQuery, found func(uint64) error) error {
+	if depth == len(MIPMapLevels) {
+		for number := start; number <= end; number++ {
+			if !query.matchBloom(s.getBloom(blockBloomKey(number))) {
+				continue
+			}
+			if err := found(number); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	level := MIPMapLevels[depth]
+	for num := start / level * level; num <= end; num += level {
+		if !query.matchBloom(s.getBloom(mipmapKey(num, level))) {
+			continue
+		}
+		from, to := num, num+level-1
+		if from < start {
+			from = start
+		}
+		if to > end {
+			to = end
+		}
+		if err := s.mipFind(from, to, depth+1, query, found); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//getBloom returns an empty bloom for ranges without any log
+func (s *State) getBloom(key []byte) types.Bloom {
+	data, _ := s.db.Get(key)
+	return types.BytesToBloom(data)
+}
+
+func (s *State) getBlockLogs(number uint64, query FilterQuery) ([]*types.Log, error) {
+	data, err := s.db.Get(blockLogsKey(number))
+	if err != nil {
+		s.logger.Error().Err(err).Uint64("block", number).Msg("GetBlockLogs")
+		return nil, err
+	}
+	var storageLogs []*types.LogForStorage
+	if err := rlp.DecodeBytes(data, &storageLogs); err != nil {
+		s.logger.Error().Err(err).Msg("Decoding Logs")
+		return nil, err
+	}
+	var logs []*types.Log
+	for _, log := range storageLogs {
+		if query.matchLog((*types.Log)(log)) {
+			logs = append(logs, (*types.Log)(log))
+		}
+	}
+	return logs, nil
+}
+
+func (q FilterQuery) matchBloom(bloom types.Bloom) bool {
+	if len(q.Addresses) > 0 {
+		included := false
+		for _, addr := range q.Addresses {
+			if bloom.TestBytes(addr[:]) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	for _, sub := range q.Topics {
+		included := len(sub) == 0
+		for _, topic := range sub {
+			if bloom.TestBytes(topic[:]) {
+				included = true
+				break
+			}
+		}
+		if !included {
+			return false
+		}
+	}
+	//an empty query matches every block that has logs
+	return bloom != types.Bloom{}
+}
+
+func (q FilterQuery) matchLog(log *types.Log) bool {
+	if len(q.Addresses) > 0 && !containsAddress(q.Addresses, log.Address) {
+		return false
+	}
+	if len(q.Topics) > len(log.Topics) {
+		return false
+	}
+	for i, sub := range q.Topics {
+		if len(sub) > 0 && !containsHash(sub, log.Topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func containsAddress(addresses []common.Address, addr common.Address) bool {
+	for _, a := range addresses {
+		if a == addr {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHash(hashes []common.Hash, hash common.Hash) bool {
+	for _, h := range hashes {
+		if h == hash {
+			return true
+		}
+	}
+	return false
+}
+
+//writeLogIndex stores the logs of the block and their bloom, and folds that
+//bloom into the MIP-mapped blooms covering the block. Blocks without logs are
+//not written at all.
+func (was *WriteAheadState) writeLogIndex() error {
+	if was.blockIndex < 0 || len(was.allLogs) == 0 {
+		return nil
+	}
+	number := uint64(was.blockIndex)
+
+	storageLogs := make([]*types.LogForStorage, len(was.allLogs))
+	for i, log := range was.allLogs {
+		storageLogs[i] = (*types.LogForStorage)(log)
+	}
+	data, err := rlp.EncodeToBytes(storageLogs)
+	if err != nil {
+		return err
+	}
+	if err := was.db.Put(blockLogsKey(number), data); err != nil {
+		return err
+	}
+
+	bloom := types.BytesToBloom(types.LogsBloom(was.allLogs).Bytes())
+	if err := was.db.Put(blockBloomKey(number), bloom.Bytes()); err != nil {
+		return err
+	}
+
+	for _, level := range MIPMapLevels {
+		key := mipmapKey(number, level)
+		data, _ := was.db.Get(key)
+		mip := types.BytesToBloom(data)
+		for i := range mip {
+			mip[i] |= bloom[i]
+		}
+		if err := was.db.Put(key, mip.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func mipmapKey(number, level uint64) []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key, level)
+	binary.BigEndian.PutUint64(key[8:], number/level*level)
+	return append(append([]byte{}, mipmapPrefix...), key...)
+}
+
+func blockBloomKey(number uint64) []byte {
+	return append(append([]byte{}, blockBloomPrefix...), encodeBlockIndex(int(number))...)
+}
+
+func blockLogsKey(number uint64) []byte {
+	return append(append([]byte{}, blockLogsPrefix...), encodeBlockIndex(int(number))...)
+}
+
+//------------------------------------------------------------------------------
+
+//FilterArgs is the JSON form of a FilterQuery, shared by POST /logs and
+//eth_getLogs. Blocks are tags or hex numbers, address is a single address or
+//an array, and every topic position is null, a single hash or an array.
+type FilterArgs struct {
+	FromBlock string          `json:"fromBlock"`
+	ToBlock   string          `json:"toBlock"`
+	Address   json.RawMessage `json:"address"`
+	Topics    []interface{}   `json:"topics"`
+}
+
+func (m *Service) toFilterQuery(args FilterArgs) (FilterQuery, error) {
+	var query FilterQuery
+	var err error
+
+	if query.FromBlock, err = m.resolveBlockNumber(args.FromBlock); err != nil {
+		return query, err
+	}
+	if query.ToBlock, err = m.resolveBlockNumber(args.ToBlock); err != nil {
+		return query, err
+	}
+
+	if len(args.Address) > 0 && string(args.Address) != "null" {
+		var addr common.Address
+		if err := json.Unmarshal(args.Address, &addr); err == nil {
+			query.Addresses = []common.Address{addr}
+		} else if err := json.Unmarshal(args.Address, &query.Addresses); err != nil {
+			return query, fmt.Errorf("invalid address: %v", err)
+		}
+	}
+
+	for i, t := range args.Topics {
+		var sub []common.Hash
+		switch topic := t.(type) {
+		case nil:
+		case string:
+			hash, err := decodeTopic(topic)
+			if err != nil {
+				return query, fmt.Errorf("invalid topic %d: %v", i, err)
+			}
+			sub = append(sub, hash)
+		case []interface{}:
+			for _, alt := range topic {
+				str, ok := alt.(string)
+				if !ok {
+					return query, fmt.Errorf("invalid topic %d", i)
+				}
+				hash, err := decodeTopic(str)
+				if err != nil {
+					return query, fmt.Errorf("invalid topic %d: %v", i, err)
+				}
+				sub = append(sub, hash)
+			}
+		default:
+			return query, fmt.Errorf("invalid topic %d", i)
+		}
+		query.Topics = append(query.Topics, sub)
+	}
+	return query, nil
+}
+
+func decodeTopic(s string) (common.Hash, error) {
+	b, err := hexutil.Decode(s)
+	if err != nil || len(b) != common.HashLength {
+		return common.Hash{}, fmt.Errorf("hex string must be %d bytes", common.HashLength)
+	}
+	return common.BytesToHash(b), nil
+}
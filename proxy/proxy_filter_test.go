@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func TestFilterQueryMatch(t *testing.T) {
+	addr := common.BytesToAddress([]byte{1})
+	topic0 := common.BytesToHash([]byte{2})
+	topic1 := common.BytesToHash([]byte{3})
+	log := &types.Log{Address: addr, Topics: []common.Hash{topic0, topic1}}
+	bloom := types.BytesToBloom(types.LogsBloom([]*types.Log{log}).Bytes())
+
+	tests := []struct {
+		query FilterQuery
+		match bool
+	}{
+		{FilterQuery{}, true},
+		{FilterQuery{Addresses: []common.Address{addr}}, true},
+		{FilterQuery{Addresses: []common.Address{common.BytesToAddress([]byte{4})}}, false},
+		{FilterQuery{Topics: [][]common.Hash{nil, {topic1}}}, true},
+		{FilterQuery{Topics: [][]common.Hash{{topic1, topic0}}}, true},
+		{FilterQuery{Topics: [][]common.Hash{{common.BytesToHash([]byte{5})}}}, false},
+	}
+	for i, test := range tests {
+		if match := test.query.matchBloom(bloom); match != test.match {
+			t.Errorf("test %d: expected bloom match %v, got %v", i, test.match, match)
+		}
+		if match := test.query.matchLog(log); match != test.match {
+			t.Errorf("test %d: expected log match %v, got %v", i, test.match, match)
+		}
+	}
+
+	//topics at the wrong position pass the bloom but not the log check
+	query := FilterQuery{Topics: [][]common.Hash{{topic1}}}
+	if !query.matchBloom(bloom) || query.matchLog(log) {
+		t.Error("expected topic position to be checked on logs only")
+	}
+	if (FilterQuery{}).matchBloom(types.Bloom{}) {
+		t.Error("expected empty bloom not to match")
+	}
+}
@@ -5,15 +5,23 @@ import (
 	"encoding/json"
 	"fmt"
 	"github.com/paradigm-network/paradigm/accounts"
-	"github.com/paradigm-network/paradigm/accounts/keystore"
 	"github.com/paradigm-network/paradigm/common"
 	"github.com/paradigm-network/paradigm/common/hexutil"
 	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/common/trace"
 	"github.com/paradigm-network/paradigm/types"
+	"github.com/gorilla/mux"
 	"github.com/rs/zerolog/log"
 	"io/ioutil"
 	"math/big"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultTxsPageSize = 20
+	maxTxsPageSize     = 100
 )
 
 /*
@@ -55,7 +63,7 @@ func accountsHandler(w http.ResponseWriter, r *http.Request, m *Service) {
 
 	var al JsonAccountList
 
-	for _, account := range m.keyStore.Accounts() {
+	for _, account := range m.accounts() {
 		balance := m.state.GetBalance(account.Address)
 		nonce := m.state.GetNonce(account.Address)
 		al.Accounts = append(al.Accounts,
@@ -76,6 +84,213 @@ func accountsHandler(w http.ResponseWriter, r *http.Request, m *Service) {
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(js)
 }
+/*
+POST /account/{address}/unlock
+header: Authorization: Bearer <api_token>
+data: JSON JsonUnlockArgs
+returns: JSON JsonAccountLock
+
+Unlocks a keystore account for Duration seconds, 0 until the node stops. The
+API token is the content of the api_token file of the data directory. A
+failed unlock leaves the account locked.
+*/
+func unlockHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	address := common.HexToAddress(mux.Vars(r)["address"])
+	var args JsonUnlockArgs
+	if err := json.NewDecoder(r.Body).Decode(&args); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if args.Duration < 0 {
+		http.Error(w, "the duration should not be negative", http.StatusBadRequest)
+		return
+	}
+	account, err := m.keyStore.Find(accounts.Account{Address: address})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	//Locked first, TimedUnlock does not shorten an unlock until the node stops
+	m.keyStore.Lock(address)
+	err = m.keyStore.TimedUnlock(account, args.Passphrase, time.Duration(args.Duration)*time.Second)
+	entry := auditEntry{Action: "unlock", Remote: r.RemoteAddr, Account: address, Duration: args.Duration}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	if err := m.audit.record(entry); err != nil {
+		log.Error().Err(err).Msg("Writing audit log")
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	log.Info().Str("address", address.Hex()).Int("duration", args.Duration).Msg("Unlocked account")
+	writeAccountLock(w, JsonAccountLock{Address: address, Locked: false, Duration: args.Duration})
+}
+
+/*
+POST /account/{address}/lock
+header: Authorization: Bearer <api_token>
+returns: JSON JsonAccountLock
+*/
+func lockHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	address := common.HexToAddress(mux.Vars(r)["address"])
+	if _, err := m.keyStore.Find(accounts.Account{Address: address}); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	m.keyStore.Lock(address)
+	if err := m.audit.record(auditEntry{Action: "lock", Remote: r.RemoteAddr, Account: address}); err != nil {
+		log.Error().Err(err).Msg("Writing audit log")
+	}
+	log.Info().Str("address", address.Hex()).Msg("Locked account")
+	writeAccountLock(w, JsonAccountLock{Address: address, Locked: true})
+}
+
+func writeAccountLock(w http.ResponseWriter, res JsonAccountLock) {
+	js, err := json.Marshal(res)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+/*
+POST /call
+data: JSON SendTxArgs
+returns: JSON JsonCallRes
+
+This endpoint allows executing READONLY transactions. The message is applied to
+a copy of the last committed State and nothing it does is persisted. The data
+does NOT need to be signed and the Nonce is ignored.
+*/
+func callHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	decoder := json.NewDecoder(r.Body)
+	var txArgs SendTxArgs
+	err := decoder.Decode(&txArgs)
+	if err != nil {
+		log.Error().Err(err).Msg("Decoding JSON txArgs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+	log.Info().Interface("txArgs", txArgs).Msg("POST call")
+
+	if txArgs.Gas == nil {
+		txArgs.Gas = defaultGas
+	}
+	data, _, _, err := m.state.Call(prepareCallMessage(txArgs))
+	if err != nil {
+		log.Error().Err(err).Msg("Executing Call")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := JsonCallRes{Data: hexutil.Encode(data)}
+	js, err := json.Marshal(res)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+/*
+POST /estimate
+data: JSON SendTxArgs
+returns: JSON JsonEstimateGasRes
+
+This endpoint returns the lowest gas limit with which the transaction would
+execute successfully on the last committed State. When the Gas field is set, it
+is used as the upper bound of the search.
+*/
+func estimateGasHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	decoder := json.NewDecoder(r.Body)
+	var txArgs SendTxArgs
+	err := decoder.Decode(&txArgs)
+	if err != nil {
+		log.Error().Err(err).Msg("Decoding JSON txArgs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer r.Body.Close()
+	log.Info().Interface("txArgs", txArgs).Msg("POST estimate")
+
+	gas, err := m.state.EstimateGas(prepareCallMessage(txArgs))
+	if err != nil {
+		log.Error().Err(err).Msg("Estimating Gas")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := JsonEstimateGasRes{Gas: gas}
+	js, err := json.Marshal(res)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+/*
+POST /logs
+data: JSON FilterArgs
+returns: JSON array of Logs
+
+ex: {"fromBlock": "0x0", "toBlock": "latest", "address": "0x...",
+     "topics": [null, ["0x...", "0x..."]]}
+
+This endpoint returns the logs emitted between fromBlock and toBlock by the
+given address(es) and matching the topics, position by position. A null topic
+matches anything.
+*/
+func logsHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	decoder := json.NewDecoder(r.Body)
+	var args FilterArgs
+	err := decoder.Decode(&args)
+	if err != nil {
+		log.Error().Err(err).Msg("Decoding JSON FilterArgs")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	defer r.Body.Close()
+	log.Info().Interface("filter", args).Msg("POST logs")
+
+	query, err := m.toFilterQuery(args)
+	if err != nil {
+		log.Error().Err(err).Msg("Parsing filter")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	logs, err := m.state.FilterLogs(query)
+	if err != nil {
+		log.Error().Err(err).Msg("Filtering logs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	js, err := json.Marshal(logs)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
 /*
 POST /tx
 data: JSON SendTxArgs
@@ -99,6 +314,7 @@ One should use the /receipt endpoint to retrieve the corresponding receipt and
 verify if/how the State was modified.
 */
 func transactionHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	start := time.Now()
 	decoder := json.NewDecoder(r.Body)
 	var txArgs SendTxArgs
 	err := decoder.Decode(&txArgs)
@@ -109,7 +325,17 @@ func transactionHandler(w http.ResponseWriter, r *http.Request, m *Service) {
 	}
 	defer r.Body.Close()
 	log.Info().Interface("txArgs", txArgs).Msg("POST tx .1 ")
-	tx, err := prepareTransaction(txArgs, m.state, m.keyStore)
+	tx, err := prepareTransaction(txArgs, m.state, m.signTx)
+	entry := auditEntry{Action: "sign", Remote: r.RemoteAddr, Account: txArgs.From}
+	if err != nil {
+		entry.Error = err.Error()
+	} else {
+		hash, nonce := tx.Hash(), tx.Nonce()
+		entry.Tx, entry.To, entry.Nonce, entry.Value = &hash, tx.To(), &nonce, tx.Value().String()
+	}
+	if err := m.audit.record(entry); err != nil {
+		log.Error().Err(err).Msg("Writing audit log")
+	}
 	if err != nil {
 		log.Error().Err(err).Msg("Preparing Transaction")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -130,9 +356,8 @@ func transactionHandler(w http.ResponseWriter, r *http.Request, m *Service) {
 		return
 	}
 
-	log.Info().Msg("submitting tx")
-	m.submitCh <- data
-	log.Info().Msg("submitted tx")
+	m.forwardTx(tx.Hash(), data)
+	trace.Since(tx.Hash().Bytes(), SpanTxSubmit, start, trace.String("tx", tx.Hash().Hex()))
 
 	res := JsonTxRes{TxHash: tx.Hash().Hex()}
 	js, err := json.Marshal(res)
@@ -184,27 +409,70 @@ func rawTransactionHandler(w http.ResponseWriter, r *http.Request, m *Service) {
 	}
 	log.Info().Bytes("raw tx bytes", rawTxBytes)
 
+	t, err := m.submitRawTransaction(rawTxBytes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
+	res := JsonTxRes{TxHash: t.Hash().Hex()}
+	js, err := json.Marshal(res)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshalling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
 
-	var t types.Transaction
-	if err := rlp.Decode(bytes.NewReader(rawTxBytes), &t); err != nil {
-		log.Error().Err(err).Msg("Decoding Transaction")
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+
+}
+
+/*
+POST /keyrotation
+data: STRING Hex representation of an encoded key rotation, as made by
+	  paradigm rotate-key
+returns: JSON JsonTxRes
+
+Forwards a key rotation to the consensus. The new key of the participant signs
+its comets and blocks some rounds after the rotation is committed. Whether the
+rotation is signed by the current key of the participant is only checked by
+the consensus, the TxHash of the response identifies the rotation in the logs.
+*/
+func keyRotationHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Reading request body")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	data, err := hexutil.Decode(string(bytes.TrimSpace(body)))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	log.Info().Str("hash", t.Hash().Hex()).Msg("Decoded tx")
 
-	if err := m.state.CheckTx(&t); err != nil {
-		log.Error().Err(err).Msg("Checking Transaction")
+	var rotation types.KeyRotation
+	if err := rotation.Unmarshal(data); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hash, err := rotation.Hash()
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	txHash := common.BytesToHash(hash)
+	log.Info().
+		Str("participant", rotation.ParticipantHex()).
+		Str("key", rotation.NewKeyHex()).
+		Str("hash", txHash.Hex()).
+		Msg("POST keyrotation")
 
-	log.Info().Msg("submitting tx")
-	m.submitCh <- rawTxBytes
-	log.Info().Msg("submitted tx")
+	m.forwardTx(txHash, data)
 
-	res := JsonTxRes{TxHash: t.Hash().Hex()}
-	js, err := json.Marshal(res)
+	js, err := json.Marshal(JsonTxRes{TxHash: txHash.Hex()})
 	if err != nil {
 		log.Error().Err(err).Msg("Marshalling JSON response")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -213,7 +481,6 @@ func rawTransactionHandler(w http.ResponseWriter, r *http.Request, m *Service) {
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Write(js)
-
 }
 
 /*
@@ -240,17 +507,121 @@ func transactionReceiptHandler(w http.ResponseWriter, r *http.Request, m *Servic
 		return
 	}
 
-	signer := types.NewBasicSigner()
-	from, err := types.Sender(signer, tx)
+	from, err := types.Sender(m.state.signer, tx)
 	if err != nil {
 		log.Error().Err(err).Msg("Getting Tx Sender")
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
+	js, err := json.Marshal(newJsonReceipt(tx, from, receipt))
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+/*
+GET /account/{address}/txs?offset={offset}&limit={limit}
+example: /account/0x50bd8a037442af4cdf631495bcaa5443de19685d/txs?limit=10
+returns: JSON JsonAccountTxs
+
+This endpoint lists the transactions sent or received by the account, newest
+first, along with their receipts. offset defaults to 0 and limit to 20, with a
+maximum of 100.
+*/
+func accountTxsHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	address := common.HexToAddress(mux.Vars(r)["address"])
+	offset, err := queryUint(r, "offset", 0)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit, err := queryUint(r, "limit", defaultTxsPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if limit > maxTxsPageSize {
+		limit = maxTxsPageSize
+	}
+	log.Info().Str("address", address.Hex()).Uint64("offset", offset).Uint64("limit", limit).Msg("GET account txs")
+
+	hashes, err := m.state.GetAddressTxs(address, offset, limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Getting account txs")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	res := JsonAccountTxs{
+		Address:      address.Hex(),
+		Total:        m.state.GetAddressTxCount(address),
+		Offset:       offset,
+		Limit:        limit,
+		Transactions: []JsonAccountTx{},
+	}
+	for _, hash := range hashes {
+		accountTx, err := m.getJsonAccountTx(hash)
+		if err != nil {
+			log.Error().Err(err).Str("hash", hash.Hex()).Msg("Getting account tx")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		res.Transactions = append(res.Transactions, accountTx)
+	}
+
+	js, err := json.Marshal(res)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+func (m *Service) getJsonAccountTx(hash common.Hash) (JsonAccountTx, error) {
+	tx, err := m.state.GetTransaction(hash)
+	if err != nil {
+		return JsonAccountTx{}, err
+	}
+	receipt, err := m.state.GetReceipt(hash)
+	if err != nil {
+		return JsonAccountTx{}, err
+	}
+	entry, err := m.state.GetTxLookupEntry(hash)
+	if err != nil {
+		return JsonAccountTx{}, err
+	}
+	from, err := types.Sender(m.state.signer, tx)
+	if err != nil {
+		return JsonAccountTx{}, err
+	}
+	return JsonAccountTx{
+		Hash:       hash,
+		BlockIndex: entry.BlockIndex,
+		TxIndex:    entry.Index,
+		From:       from,
+		To:         tx.To(),
+		Value:      tx.Value(),
+		Nonce:      tx.Nonce(),
+		Gas:        tx.Gas(),
+		GasPrice:   tx.GasPrice(),
+		Data:       hexutil.Encode(tx.Data()),
+		Receipt:    newJsonReceipt(tx, from, receipt),
+	}, nil
+}
+
+func newJsonReceipt(tx *types.Transaction, from common.Address, receipt *types.Receipt) JsonReceipt {
 	jsonReceipt := JsonReceipt{
 		Root:              common.BytesToHash(receipt.PostState),
-		TransactionHash:   txHash,
+		TransactionHash:   tx.Hash(),
 		From:              from,
 		To:                tx.To(),
 		GasUsed:           receipt.GasUsed,
@@ -263,19 +634,55 @@ func transactionReceiptHandler(w http.ResponseWriter, r *http.Request, m *Servic
 	if receipt.Logs == nil {
 		jsonReceipt.Logs = []*types.Log{}
 	}
+	return jsonReceipt
+}
 
-	js, err := json.Marshal(jsonReceipt)
+//queryUint reads an optional unsigned integer from the query string
+func queryUint(r *http.Request, name string, def uint64) (uint64, error) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return def, nil
+	}
+	n, err := strconv.ParseUint(value, 10, 64)
 	if err != nil {
-		log.Error().Err(err).Msg("Marshaling JSON response")
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return 0, fmt.Errorf("invalid %s: %v", name, err)
 	}
+	return n, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	w.Write(js)
+//submitRawTransaction checks a signed, RLP encoded, transaction against the
+//TxPool and forwards it to the node.
+func (m *Service) submitRawTransaction(rawTxBytes []byte) (*types.Transaction, error) {
+	start := time.Now()
+	var t types.Transaction
+	if err := rlp.Decode(bytes.NewReader(rawTxBytes), &t); err != nil {
+		log.Error().Err(err).Msg("Decoding Transaction")
+		return nil, err
+	}
+	log.Info().Str("hash", t.Hash().Hex()).Msg("Decoded tx")
+
+	if err := m.state.CheckTx(&t); err != nil {
+		log.Error().Err(err).Msg("Checking Transaction")
+		return nil, err
+	}
+
+	m.forwardTx(t.Hash(), rawTxBytes)
+	trace.Since(t.Hash().Bytes(), SpanTxSubmit, start, trace.String("tx", t.Hash().Hex()))
+
+	return &t, nil
 }
 
-func prepareTransaction(args SendTxArgs, state *State, ks *keystore.KeyStore) (*types.Transaction, error) {
+//forwardTx hands a checked transaction to the node. It blocks until the node
+//reads it.
+func (m *Service) forwardTx(txHash common.Hash, data []byte) {
+	start := time.Now()
+	log.Info().Msg("submitting tx")
+	m.submitCh <- data
+	log.Info().Msg("submitted tx")
+	trace.Since(txHash.Bytes(), SpanTxSubmitQueue, start)
+}
+
+func prepareTransaction(args SendTxArgs, state *State, signTx func(common.Address, *types.Transaction, *big.Int) (*types.Transaction, error)) (*types.Transaction, error) {
 	var err error
 	args, err = prepareSendTxArgs(args)
 	if err != nil {
@@ -304,22 +711,28 @@ func prepareTransaction(args SendTxArgs, state *State, ks *keystore.KeyStore) (*
 			common.FromHex(args.Data))
 	}
 
-	signer := types.NewBasicSigner()
+	return signTx(args.From, tx, state.ChainID())
+}
 
-	account, err := ks.Find(accounts.Account{Address: args.From})
-	if err != nil {
-		return nil, err
+//prepareCallMessage turns args into an unsigned message for readonly execution.
+//Gas is left untouched so that callers can decide on their own default.
+func prepareCallMessage(args SendTxArgs) TxMessage {
+	gasPrice := args.GasPrice
+	if gasPrice == nil {
+		gasPrice = big.NewInt(0)
 	}
-	signature, err := ks.SignHash(account, signer.Hash(tx).Bytes())
-	if err != nil {
-		return nil, err
-	}
-	signedTx, err := tx.WithSignature(signer, signature)
-	if err != nil {
-		return nil, err
+	value := args.Value
+	if value == nil {
+		value = big.NewInt(0)
 	}
-
-	return signedTx, nil
+	return NewTxMessage(args.From,
+		args.To,
+		0,
+		value,
+		args.Gas,
+		gasPrice,
+		common.FromHex(args.Data),
+		false)
 }
 
 func prepareSendTxArgs(args SendTxArgs) (SendTxArgs, error) {
@@ -346,6 +759,17 @@ type JsonAccountList struct {
 	Accounts []JsonAccount `json:"accounts"`
 }
 
+type JsonUnlockArgs struct {
+	Passphrase string `json:"passphrase"`
+	Duration   int    `json:"duration"` //seconds, 0 until the node stops
+}
+
+type JsonAccountLock struct {
+	Address  common.Address `json:"address"`
+	Locked   bool           `json:"locked"`
+	Duration int            `json:"duration,omitempty"`
+}
+
 // SendTxArgs represents the arguments to sumbit a new transaction into the transaction pool.
 type SendTxArgs struct {
 	From     common.Address  `json:"from"`
@@ -361,10 +785,36 @@ type JsonCallRes struct {
 	Data string `json:"data"`
 }
 
+type JsonEstimateGasRes struct {
+	Gas *big.Int `json:"gas"`
+}
+
 type JsonTxRes struct {
 	TxHash string `json:"txHash"`
 }
 
+type JsonAccountTxs struct {
+	Address      string          `json:"address"`
+	Total        uint64          `json:"total"`
+	Offset       uint64          `json:"offset"`
+	Limit        uint64          `json:"limit"`
+	Transactions []JsonAccountTx `json:"transactions"`
+}
+
+type JsonAccountTx struct {
+	Hash       common.Hash     `json:"hash"`
+	BlockIndex uint64          `json:"blockIndex"`
+	TxIndex    uint64          `json:"txIndex"`
+	From       common.Address  `json:"from"`
+	To         *common.Address `json:"to"`
+	Value      *big.Int        `json:"value"`
+	Nonce      uint64          `json:"nonce"`
+	Gas        *big.Int        `json:"gas"`
+	GasPrice   *big.Int        `json:"gasPrice"`
+	Data       string          `json:"data"`
+	Receipt    JsonReceipt     `json:"receipt"`
+}
+
 type JsonReceipt struct {
 	Root              common.Hash     `json:"root"`
 	TransactionHash   common.Hash     `json:"transactionHash"`
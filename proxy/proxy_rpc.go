@@ -0,0 +1,225 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/rs/zerolog/log"
+)
+
+const jsonRPCVersion = "2.0"
+
+//Standard JSON-RPC 2.0 error codes
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcInternalError  = -32603
+)
+
+type jsonRPCRequest struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *jsonRPCError) Error() string {
+	return e.Message
+}
+
+type jsonRPCResponse struct {
+	Version string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *jsonRPCError   `json:"error,omitempty"`
+}
+
+//rpcMethod executes a JSON-RPC call with the raw params of the request
+type rpcMethod func(m *Service, params json.RawMessage) (interface{}, error)
+
+var rpcMethods = map[string]rpcMethod{
+	"paradigm_call":        rpcCall,
+	"paradigm_estimateGas": rpcEstimateGas,
+
+	"eth_sendRawTransaction":    ethSendRawTransaction,
+	"eth_getBalance":            ethGetBalance,
+	"eth_getTransactionCount":   ethGetTransactionCount,
+	"eth_getTransactionByHash":  ethGetTransactionByHash,
+	"eth_getTransactionReceipt": ethGetTransactionReceipt,
+	"eth_getLogs":               ethGetLogs,
+	"eth_blockNumber":           ethBlockNumber,
+	"eth_getBlockByNumber":      ethGetBlockByNumber,
+	"eth_call":                  ethCall,
+	"eth_estimateGas":           ethEstimateGas,
+	"eth_gasPrice":              ethGasPrice,
+	"eth_accounts":              ethAccounts,
+	"eth_chainId":               ethChainId,
+	"net_version":               netVersion,
+	"web3_clientVersion":        web3ClientVersion,
+}
+
+/*
+POST /rpc
+data: JSON-RPC 2.0 request, or an array of requests for a batch
+returns: JSON-RPC 2.0 response, or an array of responses for a batch
+
+Methods:
+	paradigm_call        [SendTxArgs] => hex encoded return data
+	paradigm_estimateGas [SendTxArgs] => hex encoded gas
+
+	plus the subset of the Ethereum eth_*, net_* and web3_* methods listed in
+	rpcMethods (see proxy_eth_api.go)
+*/
+func rpcHandler(w http.ResponseWriter, r *http.Request, m *Service) {
+	defer r.Body.Close()
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		log.Error().Err(err).Msg("Reading request body")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	var resp interface{}
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		resp = m.handleRPCBatch(body)
+	} else {
+		var req jsonRPCRequest
+		if err := json.Unmarshal(body, &req); err != nil {
+			resp = rpcErrorResponse(nil, &jsonRPCError{Code: rpcParseError, Message: err.Error()})
+		} else {
+			resp = m.handleRPC(req)
+		}
+	}
+
+	js, err := json.Marshal(resp)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
+
+//handleRPCBatch answers every request of a batch, in order
+func (m *Service) handleRPCBatch(body []byte) interface{} {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		return rpcErrorResponse(nil, &jsonRPCError{Code: rpcParseError, Message: err.Error()})
+	}
+	if len(batch) == 0 {
+		return rpcErrorResponse(nil, &jsonRPCError{Code: rpcInvalidRequest, Message: "empty batch"})
+	}
+	responses := make([]*jsonRPCResponse, 0, len(batch))
+	for _, raw := range batch {
+		var req jsonRPCRequest
+		if err := json.Unmarshal(raw, &req); err != nil {
+			responses = append(responses, rpcErrorResponse(nil, &jsonRPCError{Code: rpcInvalidRequest, Message: err.Error()}))
+			continue
+		}
+		responses = append(responses, m.handleRPC(req))
+	}
+	return responses
+}
+
+func (m *Service) handleRPC(req jsonRPCRequest) *jsonRPCResponse {
+	if req.Version != jsonRPCVersion || req.Method == "" {
+		return rpcErrorResponse(req.ID, &jsonRPCError{Code: rpcInvalidRequest, Message: "invalid request"})
+	}
+	log.Info().Str("method", req.Method).Msg("POST rpc")
+
+	method, ok := rpcMethods[req.Method]
+	if !ok {
+		return rpcErrorResponse(req.ID, &jsonRPCError{
+			Code:    rpcMethodNotFound,
+			Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method),
+		})
+	}
+
+	result, err := method(m, req.Params)
+	if err != nil {
+		rpcErr, ok := err.(*jsonRPCError)
+		if !ok {
+			rpcErr = &jsonRPCError{Code: rpcInternalError, Message: err.Error()}
+		}
+		return rpcErrorResponse(req.ID, rpcErr)
+	}
+
+	js, err := json.Marshal(result)
+	if err != nil {
+		return rpcErrorResponse(req.ID, &jsonRPCError{Code: rpcInternalError, Message: err.Error()})
+	}
+	return &jsonRPCResponse{Version: jsonRPCVersion, ID: req.ID, Result: js}
+}
+
+func rpcErrorResponse(id json.RawMessage, err *jsonRPCError) *jsonRPCResponse {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	return &jsonRPCResponse{Version: jsonRPCVersion, ID: id, Error: err}
+}
+
+//parseParams decodes positional params into args. Trailing args are optional.
+func parseParams(params json.RawMessage, args ...interface{}) error {
+	var raw []json.RawMessage
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &raw); err != nil {
+			return &jsonRPCError{Code: rpcInvalidParams, Message: "params must be an array"}
+		}
+	}
+	if len(raw) > len(args) {
+		return &jsonRPCError{
+			Code:    rpcInvalidParams,
+			Message: fmt.Sprintf("too many arguments, want at most %d", len(args)),
+		}
+	}
+	for i, r := range raw {
+		if err := json.Unmarshal(r, args[i]); err != nil {
+			return &jsonRPCError{
+				Code:    rpcInvalidParams,
+				Message: fmt.Sprintf("invalid argument %d: %v", i, err),
+			}
+		}
+	}
+	return nil
+}
+
+func rpcCall(m *Service, params json.RawMessage) (interface{}, error) {
+	var args SendTxArgs
+	if err := parseParams(params, &args); err != nil {
+		return nil, err
+	}
+	if args.Gas == nil {
+		args.Gas = defaultGas
+	}
+	data, _, _, err := m.state.Call(prepareCallMessage(args))
+	if err != nil {
+		return nil, err
+	}
+	return hexutil.Bytes(data), nil
+}
+
+func rpcEstimateGas(m *Service, params json.RawMessage) (interface{}, error) {
+	var args SendTxArgs
+	if err := parseParams(params, &args); err != nil {
+		return nil, err
+	}
+	gas, err := m.state.EstimateGas(prepareCallMessage(args))
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(gas), nil
+}
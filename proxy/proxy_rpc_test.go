@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+)
+
+func TestParseParams(t *testing.T) {
+	var address common.Address
+	var tag string
+	params := json.RawMessage(`["0x0000000000000000000000000000000000000001"]`)
+	if err := parseParams(params, &address, &tag); err != nil {
+		t.Fatal(err)
+	}
+	if address != common.BytesToAddress([]byte{1}) || tag != "" {
+		t.Fatalf("unexpected params %x %q", address, tag)
+	}
+
+	err := parseParams(json.RawMessage(`[1, 2]`), &tag)
+	if rpcErr, ok := err.(*jsonRPCError); !ok || rpcErr.Code != rpcInvalidParams {
+		t.Fatalf("expected invalid params error, got %v", err)
+	}
+}
+
+func TestHandleRPCBatch(t *testing.T) {
+	m := &Service{state: &State{chainID: big.NewInt(1)}}
+	body := []byte(`[
+		{"jsonrpc":"2.0","id":1,"method":"net_version"},
+		{"jsonrpc":"2.0","id":2,"method":"unknown_method"},
+		{"jsonrpc":"1.0","id":3,"method":"net_version"}
+	]`)
+	responses, ok := m.handleRPCBatch(body).([]*jsonRPCResponse)
+	if !ok || len(responses) != 3 {
+		t.Fatalf("expected 3 responses, got %v", responses)
+	}
+	if responses[0].Error != nil || string(responses[0].Result) != `"1"` {
+		t.Fatalf("unexpected net_version response %+v", responses[0])
+	}
+	if responses[1].Error == nil || responses[1].Error.Code != rpcMethodNotFound {
+		t.Fatalf("expected method not found, got %+v", responses[1])
+	}
+	if responses[2].Error == nil || responses[2].Error.Code != rpcInvalidRequest {
+		t.Fatalf("expected invalid request, got %+v", responses[2])
+	}
+
+	resp, ok := m.handleRPCBatch([]byte(`[]`)).(*jsonRPCResponse)
+	if !ok || resp.Error == nil || resp.Error.Code != rpcInvalidRequest {
+		t.Fatalf("expected invalid request for empty batch, got %v", resp)
+	}
+}
@@ -1,17 +1,21 @@
 package proxy
 
 import (
-	"encoding/json"
 	"github.com/rs/zerolog/log"
 
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/hdwallet"
 	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/genesis"
+	"github.com/paradigm-network/paradigm/signer"
+	"github.com/paradigm-network/paradigm/types"
 
-	"io/ioutil"
+	"fmt"
 	"math/big"
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
 	"sync"
 
 	"github.com/gorilla/mux"
@@ -21,23 +25,32 @@ var defaultGas = big.NewInt(90000)
 
 type Service struct {
 	sync.Mutex
-	state    *State
-	submitCh chan []byte
-	dataDir  string
-	apiAddr  string
-	keyStore *keystore.KeyStore
-	pwdFile  string
+	state     *State
+	submitCh  chan []byte
+	dataDir   string
+	apiAddr   string
+	keyStore  *keystore.KeyStore
+	hdWallets *accounts.Manager //HD wallets of dataDir/hdwallet
+	txSigner  signer.TxSigner //remote accounts, may be nil
+	pwdFile   string
+	apiToken  string //authenticates the lock and unlock requests
+	audit     *auditLog
+	genesis   *genesis.Genesis
 }
 
 func NewService(dataDir, apiAddr, pwdFile string,
 	state *State,
-	submitCh chan []byte) *Service {
+	submitCh chan []byte,
+	gen *genesis.Genesis,
+	txSigner signer.TxSigner) *Service {
 	return &Service{
 		dataDir:  dataDir,
 		apiAddr:  apiAddr,
 		pwdFile:  pwdFile,
 		state:    state,
-		submitCh: submitCh,}
+		submitCh: submitCh,
+		genesis:  gen,
+		txSigner: txSigner}
 }
 
 func (m *Service) Run() {
@@ -45,6 +58,8 @@ func (m *Service) Run() {
 
 	m.checkErr(m.unlockAccounts())
 
+	m.checkErr(m.openAudit())
+
 	m.checkErr(m.createGenesisAccounts())
 	log.Info().Msg("Serving api...")
 	go m.serveAPI()
@@ -59,64 +74,148 @@ func (m *Service) makeKeyStore() error {
 
 	m.keyStore = keystore.NewKeyStore(keydir)
 
+	hub, err := hdwallet.NewHub(filepath.Join(m.dataDir, "hdwallet"))
+	if err != nil {
+		return err
+	}
+	m.hdWallets = accounts.NewManager(hub)
+
 	return nil
 }
 
+//unlockAccounts unlocks the keystore accounts as set by the unlock policy,
+//and opens the HD wallets with the pwd file
 func (m *Service) unlockAccounts() error {
+	policy, err := loadUnlockPolicy(filepath.Join(m.dataDir, UnlockPolicyFile))
+	if err != nil {
+		return err
+	}
 
-	if len(m.keyStore.Accounts()) == 0 {
-		return nil
+	for _, ac := range m.keyStore.Accounts() {
+		p := policy.Of(ac.Address)
+		if p.Locked {
+			log.Info().Str("address", ac.Address.Hex()).Msg("Account left locked")
+			continue
+		}
+		pwdFile := p.PasswordFile
+		if pwdFile == "" {
+			pwdFile = m.pwdFile
+		}
+		pwd, err := ReadPassword(pwdFile)
+		if err != nil {
+			log.Error().Err(err).Str("address", ac.Address.Hex()).Msg("Reading password file")
+			return err
+		}
+		if err := m.keyStore.TimedUnlock(ac, pwd, p.duration()); err != nil {
+			return fmt.Errorf("unlocking %s: %s", ac.Address.Hex(), err)
+		}
+		log.Info().Str("address", ac.Address.Hex()).Dur("duration", p.duration()).Msg("Unlocked account")
 	}
 
-	pwd, err := m.readPwd()
+	if len(m.hdWallets.Wallets()) == 0 {
+		return nil
+	}
+	pwd, err := ReadPassword(m.pwdFile)
 	if err != nil {
 		log.Error().Err(err).Msg("Reading PwdFile")
 		return err
 	}
-
-	for _, ac := range m.keyStore.Accounts() {
-		log.Info().Str("address",ac.Address.Hex()).Str("pwd", string(pwd)).Msg("Unlocked account")
-		if err := m.keyStore.Unlock(ac, string(pwd)); err != nil {
+	for _, w := range m.hdWallets.Wallets() {
+		if err := w.Open(pwd); err != nil {
 			return err
 		}
-		log.Info().Str("address", ac.Address.Hex()).Msg("Unlocked account")
+		log.Info().Str("wallet", w.URL().Path).Int("accounts", len(w.Accounts())).Msg("Opened HD wallet")
 	}
 	return nil
 }
 
-func (m *Service) createGenesisAccounts() error {
-	genesisFile := filepath.Join(m.dataDir, "genesis.json")
-
-	if _, err := os.Stat(genesisFile); os.IsNotExist(err) {
-		return nil
-	}
-
-	contents, err := ioutil.ReadFile(genesisFile)
+//openAudit loads the API token of the lock and unlock requests and opens the
+//audit log
+func (m *Service) openAudit() error {
+	token, err := signer.LoadToken(filepath.Join(m.dataDir, APITokenFile))
 	if err != nil {
 		return err
 	}
+	m.apiToken = token
+	m.audit, err = newAuditLog(filepath.Join(m.dataDir, AuditLogFile))
+	return err
+}
+
+//accounts returns the accounts of the keystore, then the ones of the HD
+//wallets, then the ones of the remote signer
+func (m *Service) accounts() []accounts.Account {
+	res := m.keyStore.Accounts()
+	for _, w := range m.hdWallets.Wallets() {
+		res = append(res, w.Accounts()...)
+	}
+	if m.txSigner != nil {
+		for _, addr := range m.txSigner.Accounts() {
+			res = append(res, accounts.Account{Address: addr})
+		}
+	}
+	return res
+}
 
-	var genesis struct {
-		Alloc AccountMap
+//signTx signs tx with the account of from, from the keystore, the HD wallets
+//or the remote signer
+func (m *Service) signTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	account := accounts.Account{Address: from}
+	if ks, err := m.keyStore.Find(account); err == nil {
+		return m.keyStore.SignTx(ks, tx, chainID)
+	}
+	w, err := m.hdWallets.Find(account)
+	if err == nil {
+		return w.SignTx(account, tx, chainID)
+	}
+	if m.txSigner != nil {
+		return m.txSigner.SignTx(from, tx, chainID)
 	}
+	return nil, err
+}
 
-	if err := json.Unmarshal(contents, &genesis); err != nil {
+//createGenesisAccounts applies the allocations of the genesis to a store that
+//has none applied yet. They are applied exactly once, a restart must not
+//credit the balances again.
+func (m *Service) createGenesisAccounts() error {
+	hash := m.genesis.Hash()
+	if err := genesis.Check(m.state.db, hash); err != nil {
 		return err
 	}
+	if _, ok := genesis.Stored(m.state.db); ok {
+		log.Info().Str("genesis", hash.Hex()).Msg("Genesis already applied")
+		return nil
+	}
+	//The stores of the nodes before the genesis marker applied the
+	//allocations without marking them
+	if m.state.HasHead() {
+		log.Warn().Str("genesis", hash.Hex()).Msg("Store without genesis marker, allocations assumed applied")
+		return genesis.MarkApplied(m.state.db, hash)
+	}
 
-	if err := m.state.CreateAccounts(genesis.Alloc); err != nil {
+	if err := m.state.CreateAccounts(m.genesis.Alloc, hash); err != nil {
 		return err
 	}
+	log.Info().Str("genesis", hash.Hex()).Int("accounts", len(m.genesis.Alloc)).Msg("Applied genesis")
 	return nil
 }
 
 func (m *Service) serveAPI() {
 	r := mux.NewRouter()
 	r.HandleFunc("/account/{address}", m.makeHandler(accountHandler)).Methods("GET")
+	r.HandleFunc("/account/{address}/txs", m.makeHandler(accountTxsHandler)).Methods("GET")
+	r.HandleFunc("/account/{address}/unlock", m.makeAuthHandler(unlockHandler)).Methods("POST")
+	r.HandleFunc("/account/{address}/lock", m.makeAuthHandler(lockHandler)).Methods("POST")
 	r.HandleFunc("/accounts", m.makeHandler(accountsHandler)).Methods("GET")
+	r.HandleFunc("/call", m.makeHandler(callHandler)).Methods("POST")
+	r.HandleFunc("/estimate", m.makeHandler(estimateGasHandler)).Methods("POST")
+	r.HandleFunc("/logs", m.makeHandler(logsHandler)).Methods("POST")
 	r.HandleFunc("/tx", m.makeHandler(transactionHandler)).Methods("POST")
 	r.HandleFunc("/rawtx", m.makeHandler(rawTransactionHandler)).Methods("POST")
+	r.HandleFunc("/keyrotation", m.makeHandler(keyRotationHandler)).Methods("POST")
 	r.HandleFunc("/tx/{tx_hash}", m.makeHandler(transactionReceiptHandler)).Methods("GET")
+	r.HandleFunc("/rpc", m.makeHandler(rpcHandler)).Methods("POST")
+	//not wrapped by makeHandler, WebSocket connections are long lived
+	r.HandleFunc("/ws", m.wsHandler).Methods("GET")
 	http.Handle("/", &CORSServer{r})
 	http.ListenAndServe(m.apiAddr, nil)
 }
@@ -148,6 +247,19 @@ func (m *Service) makeHandler(fn func(http.ResponseWriter, *http.Request, *Servi
 	}
 }
 
+//makeAuthHandler is makeHandler for the requests bearing the API token
+func (m *Service) makeAuthHandler(fn func(http.ResponseWriter, *http.Request, *Service)) http.HandlerFunc {
+	handler := m.makeHandler(fn)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !signer.Authorized(r, m.apiToken) {
+			log.Warn().Str("remote", r.RemoteAddr).Str("path", r.URL.Path).Msg("Unauthorized request")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		handler(w, r)
+	}
+}
+
 func (m *Service) checkErr(err error) {
 	if err != nil {
 		log.Error().Err(err).Msg("ERROR")
@@ -155,15 +267,3 @@ func (m *Service) checkErr(err error) {
 	}
 }
 
-func (m *Service) readPwd() (pwd string, err error) {
-	text, err := ioutil.ReadFile(m.pwdFile)
-	if err != nil {
-		return "", err
-	}
-	lines := strings.Split(string(text), "\n")
-	// Sanitise DOS line endings.
-	for i := range lines {
-		lines[i] = strings.TrimRight(lines[i], "\r")
-	}
-	return lines[0], nil
-}
@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"errors"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/hdwallet"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//testTxSigner is a remote signer holding a single account
+type testTxSigner struct {
+	ks      *keystore.KeyStore
+	account accounts.Account
+}
+
+func (s *testTxSigner) Accounts() []common.Address {
+	return []common.Address{s.account.Address}
+}
+
+func (s *testTxSigner) SignTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	if from != s.account.Address {
+		return nil, errors.New("unknown account")
+	}
+	return s.ks.SignTx(s.account, tx, chainID)
+}
+
+func TestServiceSignTx(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-signtx")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	newAccount := func(ks *keystore.KeyStore) accounts.Account {
+		account, err := ks.NewAccount("pwd")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ks.Unlock(account, "pwd"); err != nil {
+			t.Fatal(err)
+		}
+		return account
+	}
+	ks := keystore.NewKeyStoreWithScrypt(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	local := newAccount(ks)
+
+	hub, err := hdwallet.NewHubWithScrypt(filepath.Join(dir, "hdwallet"), keystore.LightScryptN, keystore.LightScryptP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wallet, _, err := hub.NewWallet("pwd", 128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hd := wallet.Accounts()[0]
+
+	remoteKs := keystore.NewKeyStoreWithScrypt(filepath.Join(dir, "remote"), keystore.LightScryptN, keystore.LightScryptP)
+	remote := &testTxSigner{ks: remoteKs, account: newAccount(remoteKs)}
+
+	m := &Service{keyStore: ks, hdWallets: accounts.NewManager(hub), txSigner: remote}
+	chainID := big.NewInt(3)
+	for name, from := range map[string]common.Address{
+		"keystore":  local.Address,
+		"hd wallet": hd.Address,
+		"remote":    remote.account.Address,
+	} {
+		tx := types.NewTransaction(0, common.HexToAddress("0x01"), big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+		signed, err := m.signTx(from, tx, chainID)
+		if err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		sender, err := types.Sender(types.MakeSigner(chainID), signed)
+		if err != nil || sender != from {
+			t.Fatalf("%s: sender %s, expected %s: %v", name, sender.Hex(), from.Hex(), err)
+		}
+	}
+
+	unknown := common.HexToAddress("0x02")
+	tx := types.NewTransaction(0, unknown, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil)
+	if _, err := m.signTx(unknown, tx, chainID); err == nil {
+		t.Fatal("signed with an unknown account")
+	}
+	//without a remote signer
+	m.txSigner = nil
+	if _, err := m.signTx(remote.account.Address, tx, chainID); err != accounts.ErrUnknownAccount {
+		t.Fatalf("signed with the account of a missing remote signer: %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"bytes"
+	"encoding/binary"
 	"fmt"
 	"github.com/paradigm-network/paradigm/common/log"
 	"github.com/paradigm-network/paradigm/storage"
@@ -9,22 +10,37 @@ import (
 	"github.com/rs/zerolog"
 	"math/big"
 	"sync"
+	"time"
 
 	"github.com/paradigm-network/paradigm/common"
 	"github.com/paradigm-network/paradigm/common/math"
 	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/common/trace"
+	"github.com/paradigm-network/paradigm/genesis"
 	"github.com/paradigm-network/paradigm/state"
 )
 
 var (
-	chainID        = big.NewInt(1)
-	gasLimit       = big.NewInt(1000000000000000000)
 	txMetaSuffix   = []byte{0x01}
 	receiptsPrefix = []byte("receipts-")
 	MIPMapLevels   = []uint64{1000000, 500000, 100000, 50000, 1000}
 	headTxKey      = []byte("LastTx")
+	headRootKey    = []byte("LastRoot")
+
+	headBlockKey    = []byte("LastBlock")
+	blockHashPrefix = []byte("blockhash-")
+	addressTxPrefix = []byte("addresstx-")
+	addressTxSeen   = []byte("addresstxseen-")
 )
 
+// TxLookupEntry is stored alongside every transaction and tells in which block,
+// and at which position in that block, the transaction was applied.
+type TxLookupEntry struct {
+	BlockHash  common.Hash
+	BlockIndex uint64
+	Index      uint64
+}
+
 type State struct {
 	db          storage.Store
 	commitMutex sync.Mutex
@@ -34,13 +50,26 @@ type State struct {
 	txPool   *TxPool
 	signer types.Signer
 	logger *zerolog.Logger
+
+	chainID             *big.Int
+	gasLimit            *big.Int //block gas limit of the genesis
+	allowUnprotectedTxs bool
+
+	chainEvents  chainEventBus
+	publishMutex sync.Mutex //keeps the ChainEvents in the order of the blocks
 }
 
-func NewState(store storage.Store) (*State, error) {
+//NewState creates a State whose transactions must be signed for chainID, and
+//whose blocks use at most gasLimit. Transactions without replay protection are
+//only accepted when allowUnprotectedTxs is set.
+func NewState(store storage.Store, chainID, gasLimit *big.Int, allowUnprotectedTxs bool) (*State, error) {
 	s := &State{
-		db:     store,
-		logger: log.GetLogger("proxy_state"),
-		signer: types.NewBasicSigner(),
+		db:                  store,
+		logger:              log.GetLogger("proxy_state"),
+		signer:              types.NewBasicSigner(chainID),
+		chainID:             chainID,
+		gasLimit:            gasLimit,
+		allowUnprotectedTxs: allowUnprotectedTxs,
 	}
 	if err := s.InitState(); err != nil {
 		return nil, err
@@ -54,47 +83,148 @@ func NewState(store storage.Store) (*State, error) {
 //------------------------------------------------------------------------------
 // Call is done on a copy of the state...we dont want any changes to be persisted
 // Call is a readonly operation
-//	func (s *State) Call(callMsg Message) ([]byte, error) {
-//	s.logger.Info().Msg("Call")
-//	s.commitMutex.Lock()
-//	defer s.commitMutex.Unlock()
-//
-//	// Apply the transaction to the current state (included in the env)
-//	res, _, _, err := ProcessMessage(callMsg, s.was.gp,s.was.stateDB.Copy())
-//	if err != nil {
-//		s.logger.Error().Err(err).Msg("Executing Call on WAS")
-//		return nil, err
-//	}
-//
-//	return res, err
-//}
+func (s *State) Call(callMsg Message) ([]byte, *big.Int, bool, error) {
+	s.logger.Info().Msg("Call")
+	s.commitMutex.Lock()
+	defer s.commitMutex.Unlock()
+
+	// Apply the message to a copy of the last committed state, with a gas pool
+	// of its own so that calls never eat into the gas of the pending block
+	gp := new(GasPool).AddGas(s.gasLimit)
+	res, gas, failed, err := ProcessMessage(callMsg, gp, s.statedb.Copy())
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Executing Call on committed state")
+		return nil, nil, false, err
+	}
+
+	return res, gas, failed, nil
+}
+
+// EstimateGas binary searches for the lowest gas limit that lets callMsg execute
+// successfully against the last committed state. The upper bound is the gas
+// supplied with the message, capped by what the sender can afford to pay for.
+func (s *State) EstimateGas(callMsg Message) (*big.Int, error) {
+	hi := s.gasLimit
+	if callMsg.Gas() != nil && callMsg.Gas().Sign() > 0 {
+		hi = callMsg.Gas()
+	}
+	if callMsg.GasPrice() != nil && callMsg.GasPrice().Sign() > 0 {
+		s.commitMutex.Lock()
+		balance := s.statedb.GetBalance(callMsg.From())
+		s.commitMutex.Unlock()
+		if callMsg.Value() != nil {
+			balance = new(big.Int).Sub(balance, callMsg.Value())
+		}
+		if balance.Sign() < 0 {
+			return nil, ErrInsufficientBalance
+		}
+		allowance := new(big.Int).Div(balance, callMsg.GasPrice())
+		if allowance.Cmp(hi) < 0 {
+			hi = allowance
+		}
+	}
+
+	executable := func(gas *big.Int) bool {
+		msg := NewTxMessage(callMsg.From(), callMsg.To(), callMsg.Nonce(),
+			callMsg.Value(), gas, callMsg.GasPrice(), callMsg.Data(), false)
+		_, _, failed, err := s.Call(msg)
+		return err == nil && !failed
+	}
+
+	// Make sure the message is executable at all before narrowing down
+	if !executable(hi) {
+		return nil, fmt.Errorf("gas required exceeds allowance (%s)", hi)
+	}
+
+	lo := new(big.Int)
+	hi = new(big.Int).Set(hi)
+	mid := new(big.Int)
+	for new(big.Int).Add(lo, common.Big1).Cmp(hi) < 0 {
+		mid.Add(lo, hi)
+		mid.Rsh(mid, 1)
+		if executable(mid) {
+			hi.Set(mid)
+		} else {
+			lo.Set(mid)
+		}
+	}
+
+	return hi, nil
+}
 
+//ProcessBlock applies the transactions of block and commits the resulting
+//state. The ChainEvent is published once commitMutex is released, so that a
+//slow subscriber never holds up the readers of the state.
 func (s *State) ProcessBlock(block types.Block) (common.Hash, error) {
 	fmt.Println("Process Block")
 	s.commitMutex.Lock()
-	defer s.commitMutex.Unlock()
+	ev, err := s.processBlock(block)
+	if err != nil {
+		s.commitMutex.Unlock()
+		return ev.StateRoot, err
+	}
+	//take publishMutex before releasing commitMutex, so the next block cannot
+	//be published before this one
+	s.publishMutex.Lock()
+	s.commitMutex.Unlock()
+	defer s.publishMutex.Unlock()
+
+	s.chainEvents.chain.Send(ev)
+	return ev.StateRoot, nil
+}
+
+//processBlock applies and commits block, and returns the ChainEvent to
+//publish. commitMutex must be held.
+func (s *State) processBlock(block types.Block) (ChainEvent, error) {
 	blockHashBytes, _ := block.Hash()
 	blockHash := common.BytesToHash(blockHashBytes)
+	s.was.blockIndex = block.Index()
+	s.was.blockHash = blockHash
 
 	for txIndex, txBytes := range block.Transactions() {
 		if err := s.applyTransaction(txBytes, txIndex, blockHash); err != nil {
-			return common.Hash{}, err
+			return ChainEvent{}, err
 		}
 	}
 
-	return s.commit()
+	was := s.was
+	root, err := s.commit()
+	if err != nil {
+		return ChainEvent{StateRoot: root}, err
+	}
+	return ChainEvent{
+		BlockIndex:    block.Index(),
+		BlockHash:     blockHash,
+		StateRoot:     root,
+		RoundReceived: block.RoundReceived(),
+		Transactions:  was.transactions,
+		Receipts:      was.receipts,
+		Logs:          was.allLogs,
+	}, nil
 }
 
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 
 //applyTransaction applies a transaction to the WAS
-func (s *State) applyTransaction(txBytes []byte, txIndex int, blockHash common.Hash) error {
+func (s *State) applyTransaction(txBytes []byte, txIndex int, blockHash common.Hash) (err error) {
+	start := time.Now()
+	defer func() {
+		if err != nil {
+			txsFailed.Inc()
+		}
+	}()
 
 	var t types.Transaction
 	if err := rlp.Decode(bytes.NewReader(txBytes), &t); err != nil {
 		s.logger.Error().Err(err).Msg("Decoding Transaction")
 		return err
 	}
+	defer func() {
+		trace.Since(t.Hash().Bytes(), SpanApplyTx, start,
+			trace.Int("block", s.was.blockIndex),
+			trace.Int("tx_index", txIndex),
+			trace.Bool("error", err != nil))
+	}()
 	s.logger.Info().Str("hash", t.Hash().Hex()).Str("tx", t.String()).Msg("Decoded tx")
 
 	msg, err := t.AsMessage(s.signer)
@@ -129,6 +259,9 @@ func (s *State) applyTransaction(txBytes []byte, txIndex int, blockHash common.H
 	//}
 	// Set the receipt logs and create a bloom for filtering
 	receipt.Logs = s.was.stateDB.GetLogs(t.Hash())
+	for _, log := range receipt.Logs {
+		log.BlockNumber = uint64(s.was.blockIndex)
+	}
 	receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
 
 	s.was.txIndex++
@@ -136,6 +269,11 @@ func (s *State) applyTransaction(txBytes []byte, txIndex int, blockHash common.H
 	s.was.receipts = append(s.was.receipts, receipt)
 	s.was.allLogs = append(s.was.allLogs, receipt.Logs...)
 
+	if failed {
+		txsFailed.Inc()
+	} else {
+		txsApplied.Inc()
+	}
 	s.logger.Info().Str("hash", t.Hash().Hex()).Msg("Applied tx to WAS")
 
 	return nil
@@ -164,9 +302,17 @@ func (s *State) commit() (common.Hash, error) {
 }
 
 func (s *State) CheckTx(tx *types.Transaction) error {
+	if !tx.Protected() && !s.allowUnprotectedTxs {
+		return types.ErrUnprotectedTx
+	}
 	return s.txPool.CheckTx(tx)
 }
 
+//ChainID returns the chain id transactions are signed for
+func (s *State) ChainID() *big.Int {
+	return new(big.Int).Set(s.chainID)
+}
+
 //GetPoolNonce returns an account's nonce from the txpool's ethState
 func (s *State) GetPoolNonce(addr common.Address) uint64 {
 	return s.txPool.stateDB.GetNonce(addr)
@@ -178,9 +324,11 @@ func (s *State) resetWAS() {
 	s.was = &WriteAheadState{
 		db:           s.db,
 		stateDB:      state,
+		signer:       s.signer,
 		txIndex:      0,
+		blockIndex:   -1,
 		totalUsedGas: big.NewInt(0),
-		gp:           new(GasPool).AddGas(gasLimit),
+		gp:           new(GasPool).AddGas(s.gasLimit),
 	}
 	s.logger.Info().Msg("Reset Write Ahead State")
 }
@@ -195,7 +343,10 @@ func (s *State) InitState() error {
 	tx := &types.Transaction{}
 	emptyTxHash := tx.Hash()
 	data, _ := s.db.Get(headTxKey)
-	if len(data) != 0 {
+	if root, _ := s.db.Get(headRootKey); len(root) != 0 {
+		rootHash = common.BytesToHash(root)
+		s.logger.Info().Str("root", rootHash.Hex()).Msg("Loading state from existing root")
+	} else if len(data) != 0 {
 		headTxHash = common.BytesToHash(data)
 		s.logger.Info().Str("head_tx", headTxHash.Hex()).Msg("Loading state from existing head")
 		if headTxHash == emptyTxHash {
@@ -226,15 +377,18 @@ func (s *State) InitState() error {
 	//cache wrapped state db.
 	s.statedb, err = state.New(rootHash, state.NewDatabase(s.db))
 	s.logger.Info().Str("root", rootHash.Hex()).Msg("Use root to initialise the state")
-	s.txPool = NewTxPool(s.statedb.Copy(), s.signer, gasLimit)
+	s.txPool = NewTxPool(s.statedb.Copy(), s.signer, s.gasLimit)
 
 	return err
 }
 
-func (s *State) CreateAccounts(accounts AccountMap) error {
+//CreateAccounts applies the allocations of the genesis of hash, and marks the
+//genesis applied in the same commit
+func (s *State) CreateAccounts(accounts genesis.Alloc, hash common.Hash) error {
 	s.commitMutex.Lock()
 	defer s.commitMutex.Unlock()
 
+	s.was.genesis = &hash
 	for addr, account := range accounts {
 		address := common.HexToAddress(addr)
 		s.was.stateDB.AddBalance(address, math.MustParseBig256(account.Balance))
@@ -250,6 +404,13 @@ func (s *State) CreateAccounts(accounts AccountMap) error {
 	return err
 }
 
+//HasHead returns whether a state was committed to the store
+func (s *State) HasHead() bool {
+	root, _ := s.db.Get(headRootKey)
+	data, _ := s.db.Get(headTxKey)
+	return len(root) != 0 || len(data) != 0
+}
+
 func (s *State) GetBalance(addr common.Address) *big.Int {
 	return s.statedb.GetBalance(addr)
 }
@@ -288,3 +449,99 @@ func (s *State) GetReceipt(txHash common.Hash) (*types.Receipt, error) {
 
 	return (*types.Receipt)(&receipt), nil
 }
+
+func (s *State) GetTxLookupEntry(txHash common.Hash) (*TxLookupEntry, error) {
+	data, err := s.db.Get(append(txHash.Bytes(), txMetaSuffix...))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("GetTxLookupEntry")
+		return nil, err
+	}
+	var entry TxLookupEntry
+	if err := rlp.DecodeBytes(data, &entry); err != nil {
+		s.logger.Error().Err(err).Msg("Decoding TxLookupEntry")
+		return nil, err
+	}
+
+	return &entry, nil
+}
+
+//GetLastBlockIndex returns the index of the last block applied to the State,
+//or -1 if no block was processed yet.
+func (s *State) GetLastBlockIndex() int {
+	data, err := s.db.Get(headBlockKey)
+	if err != nil || len(data) == 0 {
+		return -1
+	}
+	return int(binary.BigEndian.Uint64(data))
+}
+
+//GetBlockHash returns the hash under which the block was processed by the State.
+func (s *State) GetBlockHash(index int) (common.Hash, error) {
+	data, err := s.db.Get(blockHashKey(index))
+	if err != nil {
+		s.logger.Error().Err(err).Msg("GetBlockHash")
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(data), nil
+}
+
+func (s *State) GetBlock(index int) (types.Block, error) {
+	return s.db.GetBlock(index)
+}
+
+//GetAddressTxCount returns the number of transactions sent or received by addr
+func (s *State) GetAddressTxCount(addr common.Address) uint64 {
+	return readAddressTxCount(s.db, addr)
+}
+
+//GetAddressTxs returns up to limit hashes of the transactions sent or received
+//by addr, newest first, skipping the offset most recent ones.
+func (s *State) GetAddressTxs(addr common.Address, offset, limit uint64) ([]common.Hash, error) {
+	count := readAddressTxCount(s.db, addr)
+	hashes := []common.Hash{}
+	for i := offset; i < offset+limit && i < count; i++ {
+		data, err := s.db.Get(addressTxKey(addr, count-1-i))
+		if err != nil {
+			s.logger.Error().Err(err).Msg("GetAddressTxs")
+			return nil, err
+		}
+		hashes = append(hashes, common.BytesToHash(data))
+	}
+	return hashes, nil
+}
+
+func readAddressTxCount(db storage.Store, addr common.Address) uint64 {
+	data, err := db.Get(addressTxCountKey(addr))
+	if err != nil || len(data) == 0 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+func addressTxCountKey(addr common.Address) []byte {
+	return append(append([]byte{}, addressTxPrefix...), addr.Bytes()...)
+}
+
+func addressTxKey(addr common.Address, seq uint64) []byte {
+	return append(addressTxCountKey(addr), encodeUint64(seq)...)
+}
+
+//addressTxSeenKey marks a transaction as indexed in the history of addr
+func addressTxSeenKey(addr common.Address, hash common.Hash) []byte {
+	key := append(append([]byte{}, addressTxSeen...), addr.Bytes()...)
+	return append(key, hash.Bytes()...)
+}
+
+func blockHashKey(index int) []byte {
+	return append(append([]byte{}, blockHashPrefix...), encodeBlockIndex(index)...)
+}
+
+func encodeBlockIndex(index int) []byte {
+	return encodeUint64(uint64(index))
+}
+
+func encodeUint64(n uint64) []byte {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, n)
+	return enc
+}
@@ -3,11 +3,14 @@ package proxy
 import (
 	"github.com/paradigm-network/paradigm/common"
 	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/common/trace"
+	"github.com/paradigm-network/paradigm/genesis"
 	"github.com/paradigm-network/paradigm/types"
 	"github.com/paradigm-network/paradigm/state"
 	"github.com/paradigm-network/paradigm/storage"
 	"github.com/rs/zerolog/log"
 	"math/big"
+	"time"
 )
 
 // write ahead state, updated with each AppendTx
@@ -15,7 +18,10 @@ import (
 type WriteAheadState struct {
 	db      storage.Store
 	stateDB *state.StateDB
+	signer  types.Signer
 
+	blockIndex   int
+	blockHash    common.Hash
 	txIndex      int
 	transactions []*types.Transaction
 	receipts     []*types.Receipt
@@ -23,6 +29,8 @@ type WriteAheadState struct {
 
 	totalUsedGas *big.Int
 	gp           *GasPool
+
+	genesis *common.Hash //genesis applied by the commit, marked along with the head
 }
 
 func (was *WriteAheadState) Commit() (common.Hash, error) {
@@ -32,7 +40,7 @@ func (was *WriteAheadState) Commit() (common.Hash, error) {
 		log.Error().Err(err).Msg("Committing state")
 		return common.Hash{}, err
 	}
-	if err := was.writeHead(); err != nil {
+	if err := was.writeHead(hashArray); err != nil {
 		log.Error().Err(err).Msg("Writing head")
 		return common.Hash{}, err
 	}
@@ -44,19 +52,42 @@ func (was *WriteAheadState) Commit() (common.Hash, error) {
 		log.Error().Err(err).Msg("Writing receipts")
 		return common.Hash{}, err
 	}
+	if err := was.writeAddressIndex(); err != nil {
+		log.Error().Err(err).Msg("Writing address index")
+		return common.Hash{}, err
+	}
+	if err := was.writeLogIndex(); err != nil {
+		log.Error().Err(err).Msg("Writing log index")
+		return common.Hash{}, err
+	}
+	if err := was.writeBlock(); err != nil {
+		log.Error().Err(err).Msg("Writing block")
+		return common.Hash{}, err
+	}
 	return hashArray, nil
 }
 
-func (was *WriteAheadState) writeHead() error {
+//writeHead records the last transaction and the state root. The root is not
+//recoverable from the receipts when the last commit had no transaction, as the
+//genesis does.
+func (was *WriteAheadState) writeHead(root common.Hash) error {
 	head := &types.Transaction{}
 	if len(was.transactions) > 0 {
 		head = was.transactions[len(was.transactions)-1]
 	}
-	return was.db.Put(headTxKey, head.Hash().Bytes())
+	keys := [][]byte{headRootKey, headTxKey}
+	values := [][]byte{root.Bytes(), head.Hash().Bytes()}
+	//A crash must not leave the allocations of the genesis without its marker,
+	//they would be applied again
+	if was.genesis != nil {
+		key, value := genesis.Marker(*was.genesis)
+		keys, values = append(keys, key), append(values, value)
+	}
+	return was.db.PutAll(keys, values)
 }
 
 func (was *WriteAheadState) writeTransactions() error {
-	for _, tx := range was.transactions {
+	for i, tx := range was.transactions {
 		data, err := rlp.EncodeToBytes(tx)
 		if err != nil {
 			return err
@@ -64,12 +95,28 @@ func (was *WriteAheadState) writeTransactions() error {
 		if err := was.db.Put(tx.Hash().Bytes(), data); err != nil {
 			return err
 		}
+		//Genesis allocations are committed without a block
+		if was.blockIndex < 0 {
+			continue
+		}
+		meta, err := rlp.EncodeToBytes(TxLookupEntry{
+			BlockHash:  was.blockHash,
+			BlockIndex: uint64(was.blockIndex),
+			Index:      uint64(i),
+		})
+		if err != nil {
+			return err
+		}
+		if err := was.db.Put(append(tx.Hash().Bytes(), txMetaSuffix...), meta); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
 func (was *WriteAheadState) writeReceipts() error {
+	start := time.Now()
 	for _, receipt := range was.receipts {
 		storageReceipt := (*types.ReceiptForStorage)(receipt)
 		data, err := rlp.EncodeToBytes(storageReceipt)
@@ -80,5 +127,70 @@ func (was *WriteAheadState) writeReceipts() error {
 			return err
 		}
 	}
+	if trace.Enabled() {
+		end := time.Now()
+		for _, receipt := range was.receipts {
+			trace.Record(receipt.TxHash.Bytes(), SpanReceiptWrite, start, end,
+				trace.Int("block", was.blockIndex),
+				trace.Int("receipts", len(was.receipts)))
+		}
+	}
 	return nil
 }
+
+//writeAddressIndex appends the transactions of the block to the history of
+//their sender, recipient and created contract. Every address has a counter
+//and the entries are numbered in block/tx order. A transaction already in the
+//history of an address is skipped, so that replaying a block does not index it
+//twice, and the index of a block is written at once.
+func (was *WriteAheadState) writeAddressIndex() error {
+	if was.blockIndex < 0 {
+		return nil
+	}
+	var keys, values [][]byte
+	counts := make(map[common.Address]uint64)
+	for i, tx := range was.transactions {
+		from, err := types.Sender(was.signer, tx)
+		if err != nil {
+			return err
+		}
+		addresses := []common.Address{from}
+		if to := tx.To(); to != nil && *to != from {
+			addresses = append(addresses, *to)
+		}
+		if i < len(was.receipts) && was.receipts[i].ContractAddress != (common.Address{}) {
+			addresses = append(addresses, was.receipts[i].ContractAddress)
+		}
+
+		for _, addr := range addresses {
+			if _, err := was.db.Get(addressTxSeenKey(addr, tx.Hash())); err == nil {
+				continue
+			}
+			count, ok := counts[addr]
+			if !ok {
+				count = readAddressTxCount(was.db, addr)
+			}
+			keys = append(keys, addressTxKey(addr, count), addressTxSeenKey(addr, tx.Hash()))
+			values = append(values, tx.Hash().Bytes(), []byte{1})
+			counts[addr] = count + 1
+		}
+	}
+	for addr, count := range counts {
+		keys = append(keys, addressTxCountKey(addr))
+		values = append(values, encodeUint64(count))
+	}
+	if len(keys) == 0 {
+		return nil
+	}
+	return was.db.PutAll(keys, values)
+}
+
+func (was *WriteAheadState) writeBlock() error {
+	if was.blockIndex < 0 {
+		return nil
+	}
+	if err := was.db.Put(blockHashKey(was.blockIndex), was.blockHash.Bytes()); err != nil {
+		return err
+	}
+	return was.db.Put(headBlockKey, encodeBlockIndex(was.blockIndex))
+}
@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func TestWriteAddressIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-address-index")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	state, store := newTestState(t, filepath.Join(dir, "badger"))
+	defer store.Close()
+
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	from := crypto.PubkeyToAddress(key.PublicKey)
+	to := common.HexToAddress("0x02")
+	contract := common.HexToAddress("0x03")
+	signer := types.NewBasicSigner(big.NewInt(1))
+
+	sign := func(tx *types.Transaction) *types.Transaction {
+		signed, err := types.SignTx(tx, signer, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return signed
+	}
+	transfer := sign(types.NewTransaction(0, to, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil))
+	creation := sign(types.NewContractCreation(1, big.NewInt(0), big.NewInt(90000), big.NewInt(1), nil))
+	self := sign(types.NewTransaction(2, from, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil))
+
+	was := &WriteAheadState{
+		db:           store,
+		signer:       signer,
+		blockIndex:   1,
+		transactions: []*types.Transaction{transfer, creation, self},
+		receipts: []*types.Receipt{
+			{TxHash: transfer.Hash()},
+			{TxHash: creation.Hash(), ContractAddress: contract},
+			{TxHash: self.Hash()},
+		},
+	}
+
+	//the block is indexed once, however many times it is replayed
+	for i := 0; i < 2; i++ {
+		if err := was.writeAddressIndex(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := map[common.Address][]common.Hash{
+		from:     {self.Hash(), creation.Hash(), transfer.Hash()},
+		to:       {transfer.Hash()},
+		contract: {creation.Hash()},
+	}
+	for addr, hashes := range expected {
+		if count := state.GetAddressTxCount(addr); count != uint64(len(hashes)) {
+			t.Fatalf("%s: %d transactions, expected %d", addr.Hex(), count, len(hashes))
+		}
+		got, err := state.GetAddressTxs(addr, 0, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := range hashes {
+			if got[i] != hashes[i] {
+				t.Fatalf("%s: transaction %d is %s, expected %s", addr.Hex(), i, got[i].Hex(), hashes[i].Hex())
+			}
+		}
+	}
+
+	//the next block is appended after the replayed one
+	next := sign(types.NewTransaction(3, to, big.NewInt(1), big.NewInt(21000), big.NewInt(1), nil))
+	was.blockIndex = 2
+	was.transactions = []*types.Transaction{next}
+	was.receipts = []*types.Receipt{{TxHash: next.Hash()}}
+	if err := was.writeAddressIndex(); err != nil {
+		t.Fatal(err)
+	}
+	got, err := state.GetAddressTxs(to, 0, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.GetAddressTxCount(to) != 2 || got[0] != next.Hash() {
+		t.Fatalf("history of %s: %d transactions, newest %v", to.Hex(), state.GetAddressTxCount(to), got)
+	}
+}
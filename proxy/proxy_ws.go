@@ -0,0 +1,456 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/common/event"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/types"
+	"github.com/rs/zerolog/log"
+)
+
+//Subscription kinds of eth_subscribe
+const (
+	newHeadsSubscription   = "newHeads"
+	logsSubscription       = "logs"
+	txFinalitySubscription = "txFinality"
+	newCometsSubscription  = "newComets"
+)
+
+const (
+	wsMaxSubscriptions = 16   //per connection
+	wsMaxWatchedTxs    = 1000 //per txFinality subscription
+	wsSendQueueSize    = 256  //messages waiting to be written before the client is dropped
+	wsEventBuffer      = 64   //events waiting to be dispatched
+	wsMaxMessageSize   = 1024 * 1024
+
+	wsWriteTimeout = 10 * time.Second
+	wsPongTimeout  = 60 * time.Second
+	wsPingInterval = wsPongTimeout * 9 / 10
+)
+
+var wsUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	//the REST API already accepts any origin, see CORSServer
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+/*
+GET /ws
+upgrades to a WebSocket speaking JSON-RPC 2.0
+
+Besides every method of POST /rpc, the connection accepts:
+
+	eth_subscribe ["newHeads"]                 => subscription id
+	eth_subscribe ["logs", FilterArgs]         => subscription id
+	eth_subscribe ["txFinality", [tx hashes]]  => subscription id
+	eth_subscribe ["newComets"]                => subscription id
+	eth_unsubscribe [subscription id]          => bool
+
+and pushes {"jsonrpc":"2.0","method":"eth_subscription",
+"params":{"subscription":id,"result":...}} notifications. txFinality notifies
+once per transaction, as soon as it is part of a committed block, and ends when
+all of them are final. newComets notifies the comets the node inserts in its
+CometGraph, created or received through gossip.
+
+A connection holds at most wsMaxSubscriptions subscriptions. Clients that do
+not read their messages fast enough are disconnected.
+*/
+func (m *Service) wsHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := wsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Error().Err(err).Msg("Upgrading to WebSocket")
+		return
+	}
+	log.Info().Str("remote", r.RemoteAddr).Msg("WebSocket connected")
+
+	c := &wsConn{
+		m:      m,
+		conn:   conn,
+		send:   make(chan []byte, wsSendQueueSize),
+		done:   make(chan struct{}),
+		subs:   make(map[string]*wsSubscription),
+		events: make(chan ChainEvent, wsEventBuffer),
+		comets: make(chan CometEvent, wsEventBuffer),
+	}
+	c.eventSubs = []event.Subscription{
+		m.state.SubscribeChainEvents(c.events),
+		m.state.SubscribeCometEvents(c.comets),
+	}
+	go c.writeLoop()
+	go c.eventLoop()
+	c.readLoop()
+	c.close(websocket.CloseNormalClosure, "")
+	log.Info().Str("remote", r.RemoteAddr).Msg("WebSocket disconnected")
+}
+
+type wsSubscription struct {
+	id    string
+	kind  string
+	query FilterQuery
+	txs   map[common.Hash]struct{}
+}
+
+type wsNotification struct {
+	Version string             `json:"jsonrpc"`
+	Method  string             `json:"method"`
+	Params  wsNotificationBody `json:"params"`
+}
+
+type wsNotificationBody struct {
+	Subscription string      `json:"subscription"`
+	Result       interface{} `json:"result"`
+}
+
+//TxFinality is the txFinality notification of a transaction
+type TxFinality struct {
+	TransactionHash  common.Hash    `json:"transactionHash"`
+	BlockHash        common.Hash    `json:"blockHash"`
+	BlockNumber      hexutil.Uint64 `json:"blockNumber"`
+	TransactionIndex hexutil.Uint   `json:"transactionIndex"`
+	Status           hexutil.Uint   `json:"status"`
+	GasUsed          *hexutil.Big   `json:"gasUsed"`
+}
+
+//RPCComet is the newComets notification of a comet. Transactions are the
+//hashes of the transactions it carries.
+type RPCComet struct {
+	Hash         string        `json:"hash"`
+	Creator      string        `json:"creator"`
+	Index        hexutil.Uint  `json:"index"`
+	SelfParent   string        `json:"selfParent"`
+	OtherParent  string        `json:"otherParent"`
+	Timestamp    time.Time     `json:"timestamp"`
+	Transactions []common.Hash `json:"transactions"`
+	Self         bool          `json:"self"`
+}
+
+type wsConn struct {
+	m      *Service
+	conn   *websocket.Conn
+	send   chan []byte
+	events chan ChainEvent
+	comets chan CometEvent
+
+	eventSubs []event.Subscription
+
+	closeOnce sync.Once
+	done      chan struct{}
+
+	mu     sync.Mutex
+	subs   map[string]*wsSubscription
+	nextID uint64
+}
+
+//close disconnects the client once, telling it why
+func (c *wsConn) close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		close(c.done)
+		for _, sub := range c.eventSubs {
+			sub.Unsubscribe()
+		}
+		msg := websocket.FormatCloseMessage(code, reason)
+		c.conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(wsWriteTimeout))
+		c.conn.Close()
+	})
+}
+
+//queue hands msg to the writer, dropping the client if it is too far behind
+func (c *wsConn) queue(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling WebSocket message")
+		return
+	}
+	select {
+	case c.send <- data:
+	case <-c.done:
+	default:
+		log.Warn().Msg("WebSocket send queue full, dropping client")
+		c.close(websocket.ClosePolicyViolation, "client too slow")
+	}
+}
+
+func (c *wsConn) readLoop() {
+	c.conn.SetReadLimit(wsMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+	c.conn.SetPongHandler(func(string) error {
+		c.conn.SetReadDeadline(time.Now().Add(wsPongTimeout))
+		return nil
+	})
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		var req jsonRPCRequest
+		if err := json.Unmarshal(data, &req); err != nil {
+			c.queue(rpcErrorResponse(nil, &jsonRPCError{Code: rpcParseError, Message: err.Error()}))
+			continue
+		}
+		if res := c.handle(req); res != nil {
+			c.queue(res)
+		}
+	}
+}
+
+func (c *wsConn) writeLoop() {
+	ticker := time.NewTicker(wsPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case data := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-ticker.C:
+			c.conn.SetWriteDeadline(time.Now().Add(wsWriteTimeout))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				c.close(websocket.CloseAbnormalClosure, "")
+				return
+			}
+		case <-c.done:
+			return
+		}
+	}
+}
+
+func (c *wsConn) eventLoop() {
+	for {
+		select {
+		case ev := <-c.events:
+			c.dispatch(ev)
+		case ev := <-c.comets:
+			c.dispatchComet(ev)
+		case <-c.done:
+			return
+		}
+	}
+}
+
+//handle answers req. Subscriptions queue their response themselves, so that it
+//goes out before their first notification, and return nil.
+func (c *wsConn) handle(req jsonRPCRequest) *jsonRPCResponse {
+	switch req.Method {
+	case "eth_subscribe":
+		return c.subscribe(req)
+	case "eth_unsubscribe":
+		var id string
+		if err := parseParams(req.Params, &id); err != nil {
+			return rpcErrorResponse(req.ID, err.(*jsonRPCError))
+		}
+		c.mu.Lock()
+		_, ok := c.subs[id]
+		delete(c.subs, id)
+		c.mu.Unlock()
+		js, _ := json.Marshal(ok)
+		return &jsonRPCResponse{Version: jsonRPCVersion, ID: req.ID, Result: js}
+	default:
+		c.m.Lock()
+		defer c.m.Unlock()
+		return c.m.handleRPC(req)
+	}
+}
+
+func (c *wsConn) subscribe(req jsonRPCRequest) *jsonRPCResponse {
+	var kind string
+	var args json.RawMessage
+	if err := parseParams(req.Params, &kind, &args); err != nil {
+		return rpcErrorResponse(req.ID, err.(*jsonRPCError))
+	}
+	invalidParams := func(format string, a ...interface{}) *jsonRPCResponse {
+		return rpcErrorResponse(req.ID, &jsonRPCError{Code: rpcInvalidParams, Message: fmt.Sprintf(format, a...)})
+	}
+
+	sub := &wsSubscription{kind: kind}
+	switch kind {
+	case newHeadsSubscription, newCometsSubscription:
+	case logsSubscription:
+		var filter FilterArgs
+		if len(args) > 0 {
+			if err := json.Unmarshal(args, &filter); err != nil {
+				return invalidParams("%v", err)
+			}
+		}
+		query, err := c.m.toFilterQuery(filter)
+		if err != nil {
+			return invalidParams("%v", err)
+		}
+		sub.query = query
+	case txFinalitySubscription:
+		var hashes []common.Hash
+		if err := json.Unmarshal(args, &hashes); err != nil || len(hashes) == 0 {
+			return invalidParams("expected a list of transaction hashes")
+		}
+		if len(hashes) > wsMaxWatchedTxs {
+			return invalidParams("too many transactions, want at most %d", wsMaxWatchedTxs)
+		}
+		sub.txs = make(map[common.Hash]struct{})
+		for _, hash := range hashes {
+			sub.txs[hash] = struct{}{}
+		}
+	default:
+		return invalidParams("unknown subscription %q", kind)
+	}
+
+	//Blocks are dispatched under c.mu: holding it until the subscription is set
+	//up guarantees that no block is missed or notified twice.
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.subs) >= wsMaxSubscriptions {
+		return rpcErrorResponse(req.ID, &jsonRPCError{
+			Code:    rpcInvalidRequest,
+			Message: fmt.Sprintf("too many subscriptions, at most %d per connection", wsMaxSubscriptions),
+		})
+	}
+	c.nextID++
+	sub.id = hexutil.EncodeUint64(c.nextID)
+	c.subs[sub.id] = sub
+
+	js, _ := json.Marshal(sub.id)
+	c.queue(&jsonRPCResponse{Version: jsonRPCVersion, ID: req.ID, Result: js})
+
+	//transactions that are already final are notified right away
+	for hash := range sub.txs {
+		if finality, ok := c.m.lookupTxFinality(hash); ok {
+			delete(sub.txs, hash)
+			c.notify(sub.id, finality)
+		}
+	}
+	if kind == txFinalitySubscription && len(sub.txs) == 0 {
+		delete(c.subs, sub.id)
+	}
+	return nil
+}
+
+func (c *wsConn) notify(id string, result interface{}) {
+	c.queue(wsNotification{
+		Version: jsonRPCVersion,
+		Method:  "eth_subscription",
+		Params:  wsNotificationBody{Subscription: id, Result: result},
+	})
+}
+
+//dispatch notifies every subscription interested in the committed block
+func (c *wsConn) dispatch(ev ChainEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, sub := range c.subs {
+		switch sub.kind {
+		case newHeadsSubscription:
+			c.notify(id, newRPCHead(ev))
+		case logsSubscription:
+			for _, log := range ev.Logs {
+				if sub.query.matchLog(log) {
+					c.notify(id, newRPCLogs([]*types.Log{log})[0])
+				}
+			}
+		case txFinalitySubscription:
+			for i, tx := range ev.Transactions {
+				if _, ok := sub.txs[tx.Hash()]; !ok {
+					continue
+				}
+				delete(sub.txs, tx.Hash())
+				c.notify(id, newTxFinality(ev, i))
+			}
+			if len(sub.txs) == 0 {
+				delete(c.subs, id)
+			}
+		}
+	}
+}
+
+//dispatchComet notifies the newComets subscriptions of the inserted comet
+func (c *wsConn) dispatchComet(ev CometEvent) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var comet *RPCComet
+	for id, sub := range c.subs {
+		if sub.kind != newCometsSubscription {
+			continue
+		}
+		if comet == nil {
+			comet = newRPCComet(ev)
+		}
+		c.notify(id, comet)
+	}
+}
+
+func newRPCComet(ev CometEvent) *RPCComet {
+	comet := &RPCComet{
+		Hash:         ev.Comet.Hex(),
+		Creator:      ev.Comet.Creator(),
+		Index:        hexutil.Uint(ev.Comet.Index()),
+		SelfParent:   ev.Comet.SelfParent(),
+		OtherParent:  ev.Comet.OtherParent(),
+		Timestamp:    ev.Comet.Body.Timestamp,
+		Transactions: []common.Hash{},
+		Self:         ev.Self,
+	}
+	for _, tx := range ev.Comet.Transactions() {
+		comet.Transactions = append(comet.Transactions, crypto.Keccak256Hash(tx))
+	}
+	return comet
+}
+
+func newRPCHead(ev ChainEvent) *RPCBlock {
+	head := &RPCBlock{
+		Number:        (*hexutil.Big)(big.NewInt(int64(ev.BlockIndex))),
+		Hash:          ev.BlockHash,
+		StateRoot:     ev.StateRoot.Bytes(),
+		RoundReceived: hexutil.Uint64(ev.RoundReceived),
+		Transactions:  []interface{}{},
+	}
+	for _, tx := range ev.Transactions {
+		head.Transactions = append(head.Transactions, tx.Hash())
+	}
+	return head
+}
+
+func newTxFinality(ev ChainEvent, index int) TxFinality {
+	finality := TxFinality{
+		TransactionHash:  ev.Transactions[index].Hash(),
+		BlockHash:        ev.BlockHash,
+		BlockNumber:      hexutil.Uint64(ev.BlockIndex),
+		TransactionIndex: hexutil.Uint(index),
+	}
+	if index < len(ev.Receipts) {
+		finality.Status = hexutil.Uint(ev.Receipts[index].Status)
+		finality.GasUsed = (*hexutil.Big)(ev.Receipts[index].GasUsed)
+	}
+	return finality
+}
+
+//lookupTxFinality reports whether the transaction is already part of a
+//committed block
+func (m *Service) lookupTxFinality(hash common.Hash) (TxFinality, bool) {
+	if has, _ := m.state.db.Has(append(hash.Bytes(), txMetaSuffix...)); !has {
+		return TxFinality{}, false
+	}
+	entry, err := m.state.GetTxLookupEntry(hash)
+	if err != nil {
+		return TxFinality{}, false
+	}
+	finality := TxFinality{
+		TransactionHash:  hash,
+		BlockHash:        entry.BlockHash,
+		BlockNumber:      hexutil.Uint64(entry.BlockIndex),
+		TransactionIndex: hexutil.Uint(entry.Index),
+	}
+	if receipt, err := m.state.GetReceipt(hash); err == nil {
+		finality.Status = hexutil.Uint(receipt.Status)
+		finality.GasUsed = (*hexutil.Big)(receipt.GasUsed)
+	}
+	return finality, true
+}
@@ -0,0 +1,10 @@
+package proxy
+
+//Names of the spans recorded by the proxy, see the core package for the spans
+//recorded by the node
+const (
+	SpanTxSubmit      = "tx.submit"       //HTTP or JSON-RPC submission, checks included
+	SpanTxSubmitQueue = "tx.submit_queue" //hand-off to the node through the submit channel
+	SpanApplyTx       = "state.apply_tx"  //application of the transaction to the WAS
+	SpanReceiptWrite  = "receipt.write"   //write of the receipts of the block
+)
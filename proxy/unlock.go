@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/paradigm-network/paradigm/common"
+)
+
+const (
+	//UnlockPolicyFile is the unlock policy of the keystore accounts, in the
+	//data directory of the proxy
+	UnlockPolicyFile = "unlock.json"
+
+	//APITokenFile holds the token authenticating the lock and unlock
+	//requests, generated when missing
+	APITokenFile = "api_token"
+
+	//AuditLogFile is appended the signatures made through /tx and the lock
+	//and unlock requests, one JSON object per line
+	AuditLogFile = "audit.log"
+)
+
+//AccountPolicy is how an account is unlocked when the proxy starts
+type AccountPolicy struct {
+	PasswordFile string `json:"password_file"` //first line is the passphrase, defaults to the pwd file of the node
+	Duration     int    `json:"duration"`      //seconds the account stays unlocked, 0 until the node stops
+	Locked       bool   `json:"locked"`        //left locked, to be unlocked through the API
+}
+
+//UnlockPolicy is the policy of every account: the one of its address, or the
+//default one. Without a policy file every account is unlocked with the pwd
+//file until the node stops.
+type UnlockPolicy struct {
+	Default  AccountPolicy                    `json:"default"`
+	Accounts map[common.Address]AccountPolicy `json:"accounts"`
+}
+
+func loadUnlockPolicy(path string) (*UnlockPolicy, error) {
+	var p UnlockPolicy
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return &p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return &p, nil
+}
+
+//Of returns the policy of the account of addr
+func (p *UnlockPolicy) Of(addr common.Address) AccountPolicy {
+	if ap, ok := p.Accounts[addr]; ok {
+		return ap
+	}
+	return p.Default
+}
+
+func (ap AccountPolicy) duration() time.Duration {
+	return time.Duration(ap.Duration) * time.Second
+}
+
+//ReadPassword returns the first line of a password file, without its line
+//ending. The node key, the accounts and the HD wallets are all unlocked with
+//the passphrase read this way.
+func ReadPassword(path string) (string, error) {
+	text, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(string(text), "\n")
+	// Sanitise DOS line endings.
+	for i := range lines {
+		lines[i] = strings.TrimRight(lines[i], "\r")
+	}
+	return lines[0], nil
+}
+
+//auditEntry is a line of the audit log
+type auditEntry struct {
+	Time     time.Time       `json:"time"`
+	Action   string          `json:"action"` //sign, lock or unlock
+	Remote   string          `json:"remote"`
+	Account  common.Address  `json:"account"`
+	Tx       *common.Hash    `json:"tx,omitempty"`
+	To       *common.Address `json:"to,omitempty"`
+	Nonce    *uint64         `json:"nonce,omitempty"`
+	Value    string          `json:"value,omitempty"`
+	Duration int             `json:"duration,omitempty"` //seconds of an unlock
+	Error    string          `json:"error,omitempty"`
+}
+
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func newAuditLog(path string) (*auditLog, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: f}, nil
+}
+
+func (a *auditLog) record(e auditEntry) error {
+	e.Time = time.Now().UTC()
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	_, err = a.file.Write(append(data, '\n'))
+	return err
+}
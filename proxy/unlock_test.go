@@ -0,0 +1,236 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+)
+
+func TestUnlockPolicy(t *testing.T) {
+	var p UnlockPolicy
+	if err := json.Unmarshal([]byte(`{
+		"default": {"duration": 60},
+		"accounts": {
+			"0x629007eb99ff5c3539ada8a5800847eacfc25727": {"password_file": "/secret", "locked": true}
+		}
+	}`), &p); err != nil {
+		t.Fatal(err)
+	}
+
+	listed := p.Of(common.HexToAddress("0x629007EB99FF5C3539ADA8A5800847EACFC25727"))
+	if !listed.Locked || listed.PasswordFile != "/secret" || listed.Duration != 0 {
+		t.Fatalf("the policy of a listed account should be its own, got %+v", listed)
+	}
+	other := p.Of(common.HexToAddress("0x01"))
+	if other.Locked || other.PasswordFile != "" || other.duration().Seconds() != 60 {
+		t.Fatalf("the policy of an unlisted account should be the default one, got %+v", other)
+	}
+
+	var none UnlockPolicy
+	if ap := none.Of(common.HexToAddress("0x01")); ap.Locked || ap.Duration != 0 {
+		t.Fatalf("without a policy accounts should be unlocked until the node stops, got %+v", ap)
+	}
+}
+
+//newTestService returns a Service with an audit log and a keystore holding a
+//single locked account, and the router of its account and tx endpoints
+func newTestService(t *testing.T, dir string) (*Service, accounts.Account, *mux.Router) {
+	state, _ := newTestState(t, filepath.Join(dir, "badger"))
+	m := &Service{
+		dataDir:   dir,
+		state:     state,
+		submitCh:  make(chan []byte, 1),
+		keyStore:  keystore.NewKeyStoreWithScrypt(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP),
+		hdWallets: accounts.NewManager(),
+	}
+	if err := m.openAudit(); err != nil {
+		t.Fatal(err)
+	}
+	account, err := m.keyStore.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := mux.NewRouter()
+	r.HandleFunc("/account/{address}/unlock", m.makeAuthHandler(unlockHandler)).Methods("POST")
+	r.HandleFunc("/account/{address}/lock", m.makeAuthHandler(lockHandler)).Methods("POST")
+	r.HandleFunc("/tx", m.makeHandler(transactionHandler)).Methods("POST")
+	return m, account, r
+}
+
+func post(r http.Handler, path, auth string, body interface{}) *httptest.ResponseRecorder {
+	data, _ := json.Marshal(body)
+	req := httptest.NewRequest("POST", path, bytes.NewReader(data))
+	if auth != "" {
+		req.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+func readAudit(t *testing.T, dir string) []auditEntry {
+	f, err := os.Open(filepath.Join(dir, AuditLogFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	var entries []auditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e auditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatal(err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestUnlockHandlers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-unlock")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m, account, r := newTestService(t, dir)
+	defer m.state.db.Close()
+
+	token, err := ioutil.ReadFile(filepath.Join(dir, APITokenFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	bearer := "Bearer " + string(bytes.TrimSpace(token))
+	unlock := "/account/" + account.Address.Hex() + "/unlock"
+	lock := "/account/" + account.Address.Hex() + "/lock"
+	hash := crypto.Keccak256([]byte("message"))
+	locked := func() bool {
+		_, err := m.keyStore.SignHash(account, hash)
+		return err == keystore.ErrLocked
+	}
+
+	//rejected credentials reach neither the keystore nor the audit log
+	for _, auth := range []string{"", "Bearer wrong", string(bytes.TrimSpace(token)), "Basic " + string(bytes.TrimSpace(token))} {
+		if w := post(r, unlock, auth, JsonUnlockArgs{Passphrase: "pwd"}); w.Code != http.StatusUnauthorized {
+			t.Fatalf("authorization %q: status %d", auth, w.Code)
+		}
+		if w := post(r, lock, auth, nil); w.Code != http.StatusUnauthorized {
+			t.Fatalf("authorization %q: lock status %d", auth, w.Code)
+		}
+	}
+	if !locked() {
+		t.Fatal("unlocked without the API token")
+	}
+
+	if w := post(r, unlock, bearer, JsonUnlockArgs{Passphrase: "pwd", Duration: -1}); w.Code != http.StatusBadRequest {
+		t.Fatalf("negative duration: status %d", w.Code)
+	}
+	if w := post(r, "/account/0x01/unlock", bearer, JsonUnlockArgs{Passphrase: "pwd"}); w.Code != http.StatusNotFound {
+		t.Fatalf("unknown account: status %d", w.Code)
+	}
+	if w := post(r, unlock, bearer, JsonUnlockArgs{Passphrase: "wrong"}); w.Code != http.StatusForbidden {
+		t.Fatalf("wrong passphrase: status %d", w.Code)
+	}
+	if !locked() {
+		t.Fatal("unlocked with a wrong passphrase")
+	}
+
+	w := post(r, unlock, bearer, JsonUnlockArgs{Passphrase: "pwd", Duration: 60})
+	if w.Code != http.StatusOK {
+		t.Fatalf("unlock: status %d: %s", w.Code, w.Body)
+	}
+	var res JsonAccountLock
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+	if res != (JsonAccountLock{Address: account.Address, Duration: 60}) {
+		t.Fatalf("unlock response %+v", res)
+	}
+	if locked() {
+		t.Fatal("account still locked")
+	}
+
+	if w := post(r, lock, bearer, nil); w.Code != http.StatusOK {
+		t.Fatalf("lock: status %d: %s", w.Code, w.Body)
+	}
+	if !locked() {
+		t.Fatal("account still unlocked")
+	}
+
+	entries := readAudit(t, dir)
+	expected := []auditEntry{
+		{Action: "unlock", Account: account.Address, Error: keystore.ErrDecrypt.Error()},
+		{Action: "unlock", Account: account.Address, Duration: 60},
+		{Action: "lock", Account: account.Address},
+	}
+	if len(entries) != len(expected) {
+		t.Fatalf("audit log %+v, expected %+v", entries, expected)
+	}
+	for i, e := range entries {
+		if e.Time.IsZero() || e.Remote == "" {
+			t.Fatalf("audit entry %d without time or remote: %+v", i, e)
+		}
+		if e.Action != expected[i].Action || e.Account != expected[i].Account ||
+			e.Duration != expected[i].Duration || e.Error != expected[i].Error {
+			t.Fatalf("audit entry %d %+v, expected %+v", i, e, expected[i])
+		}
+	}
+}
+
+func TestTxAudit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-audit")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	m, account, r := newTestService(t, dir)
+	defer m.state.db.Close()
+
+	to := common.HexToAddress("0x02")
+	args := SendTxArgs{From: account.Address, To: &to, Value: big.NewInt(0)}
+	//locked
+	if w := post(r, "/tx", "", args); w.Code != http.StatusInternalServerError {
+		t.Fatalf("signed with a locked account: status %d", w.Code)
+	}
+	if err := m.keyStore.Unlock(account, "pwd"); err != nil {
+		t.Fatal(err)
+	}
+	w := post(r, "/tx", "", args)
+	if w.Code != http.StatusOK {
+		t.Fatalf("tx: status %d: %s", w.Code, w.Body)
+	}
+	var res JsonTxRes
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatal(err)
+	}
+
+	entries := readAudit(t, dir)
+	if len(entries) != 2 {
+		t.Fatalf("audit log %+v", entries)
+	}
+	failed, signed := entries[0], entries[1]
+	if failed.Action != "sign" || failed.Account != account.Address || failed.Error == "" || failed.Tx != nil {
+		t.Fatalf("audit entry of the failed signature %+v", failed)
+	}
+	if signed.Action != "sign" || signed.Account != account.Address || signed.Error != "" {
+		t.Fatalf("audit entry of the signature %+v", signed)
+	}
+	if signed.Tx == nil || signed.Tx.Hex() != res.TxHash {
+		t.Fatalf("audited tx %v, expected %s", signed.Tx, res.TxHash)
+	}
+	if signed.To == nil || *signed.To != to || signed.Nonce == nil || *signed.Nonce != 0 || signed.Value != "0" {
+		t.Fatalf("audit entry of the signature %+v", signed)
+	}
+}
@@ -0,0 +1,142 @@
+package signer
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+const clientTimeout = 10 * time.Second
+
+//Client is the Signer and TxSigner of a signer daemon
+type Client struct {
+	base   string
+	token  string
+	http   *http.Client
+	pubKey []byte
+}
+
+//NewClient connects to the signer daemon of addr, unix://<path> or
+//<host>:<port>, with the token of the daemon and fetches the public key of
+//the validator
+func NewClient(addr, token string) (*Client, error) {
+	c := &Client{token: token, http: &http.Client{Timeout: clientTimeout}}
+	if strings.HasPrefix(addr, unixScheme) {
+		path := strings.TrimPrefix(addr, unixScheme)
+		c.base = "http://signer"
+		c.http.Transport = &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", path)
+			},
+		}
+	} else {
+		c.base = "http://" + strings.TrimPrefix(addr, "http://")
+	}
+
+	var res JsonPubKey
+	if err := c.do("GET", "/pubkey", nil, &res); err != nil {
+		return nil, fmt.Errorf("signer %s: %s", addr, err)
+	}
+	c.pubKey = res.PubKey
+	return c, nil
+}
+
+func (c *Client) do(method, path string, req, res interface{}) error {
+	var body []byte
+	if req != nil {
+		var err error
+		if body, err = json.Marshal(req); err != nil {
+			return err
+		}
+	}
+	r, err := http.NewRequest(method, c.base+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", "Bearer "+c.token)
+	resp, err := c.http.Do(r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s", strings.TrimSpace(string(data)))
+	}
+	return json.Unmarshal(data, res)
+}
+
+func (c *Client) PubKey() []byte {
+	return c.pubKey
+}
+
+func (c *Client) SignComet(body types.CometBody) (string, error) {
+	var res JsonSignature
+	if err := c.do("POST", "/comet", body, &res); err != nil {
+		return "", err
+	}
+	return res.Signature, nil
+}
+
+func (c *Client) SignBlock(body types.BlockBody) (string, error) {
+	var res JsonSignature
+	if err := c.do("POST", "/block", body, &res); err != nil {
+		return "", err
+	}
+	return res.Signature, nil
+}
+
+//Accounts returns the accounts of the daemon, none when it is unreachable
+func (c *Client) Accounts() []common.Address {
+	var res JsonAccounts
+	if err := c.do("GET", "/accounts", nil, &res); err != nil {
+		return nil
+	}
+	return res.Accounts
+}
+
+//SignTx has the daemon sign tx, and checks that it signed tx with the account
+//of from
+func (c *Client) SignTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	data, err := rlp.EncodeToBytes(tx)
+	if err != nil {
+		return nil, err
+	}
+	var res JsonTx
+	if err := c.do("POST", "/tx", JsonTxRequest{From: from, Tx: data, ChainID: (*hexutil.Big)(chainID)}, &res); err != nil {
+		return nil, err
+	}
+	signed := new(types.Transaction)
+	if err := rlp.DecodeBytes(res.Tx, signed); err != nil {
+		return nil, err
+	}
+	signer := types.MakeSigner(chainID)
+	if signer.Hash(signed) != signer.Hash(tx) {
+		return nil, fmt.Errorf("signer returned another transaction")
+	}
+	sender, err := types.Sender(signer, signed)
+	if err != nil {
+		return nil, err
+	}
+	if sender != from {
+		return nil, fmt.Errorf("signer signed with %s instead of %s", sender.Hex(), from.Hex())
+	}
+	return signed, nil
+}
@@ -0,0 +1,127 @@
+package signer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sync"
+
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//signed is the last comet or block a Guard signed
+type signed struct {
+	Index int           `json:"index"`
+	Hash  hexutil.Bytes `json:"hash"`
+}
+
+type guardState struct {
+	Comet *signed `json:"comet,omitempty"`
+	Block *signed `json:"block,omitempty"`
+}
+
+//Guard refuses to sign what would let a validator equivocate: two comets with
+//the same index, or two blocks with the same index. Signing the same comet or
+//block again is allowed, a restarted node recreates its initial comet. The
+//last signed indexes are written to the state file before the signatures are
+//returned, so that the rules hold across restarts of the signer.
+type Guard struct {
+	signer Signer
+	path   string
+	state  guardState
+	mu     sync.Mutex
+}
+
+//NewGuard enforces the rules on signer, with the state of the file of path,
+//which is created when missing
+func NewGuard(signer Signer, path string) (*Guard, error) {
+	g := &Guard{signer: signer, path: path}
+	data, err := ioutil.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &g.state); err != nil {
+			return nil, fmt.Errorf("%s: %s", path, err)
+		}
+	}
+	return g, nil
+}
+
+func (g *Guard) PubKey() []byte {
+	return g.signer.PubKey()
+}
+
+func (g *Guard) SignComet(body types.CometBody) (string, error) {
+	if !bytes.Equal(body.Creator, g.signer.PubKey()) {
+		return "", fmt.Errorf("refusing to sign a comet of another creator")
+	}
+	hash, err := body.Hash()
+	if err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := check("comet", g.state.Comet, body.Index, hash); err != nil {
+		return "", err
+	}
+	if err := g.save(guardState{Comet: &signed{body.Index, hash}, Block: g.state.Block}); err != nil {
+		return "", err
+	}
+	return g.signer.SignComet(body)
+}
+
+func (g *Guard) SignBlock(body types.BlockBody) (string, error) {
+	hash, err := body.Hash()
+	if err != nil {
+		return "", err
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := check("block", g.state.Block, body.Index, hash); err != nil {
+		return "", err
+	}
+	if err := g.save(guardState{Comet: g.state.Comet, Block: &signed{body.Index, hash}}); err != nil {
+		return "", err
+	}
+	return g.signer.SignBlock(body)
+}
+
+//check allows indexes above the last signed one, and the last signed one
+//again with the same hash
+func check(kind string, last *signed, index int, hash []byte) error {
+	switch {
+	case last == nil || index > last.Index:
+		return nil
+	case index == last.Index && bytes.Equal(hash, last.Hash):
+		return nil
+	case index == last.Index:
+		return fmt.Errorf("refusing to sign another %s with index %d", kind, index)
+	default:
+		return fmt.Errorf("refusing to sign a %s with index %d, %d was signed", kind, index, last.Index)
+	}
+}
+
+//save writes the state file, then updates the state
+func (g *Guard) save(state guardState) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	tmp := g.path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, g.path); err != nil {
+		return err
+	}
+	g.state = state
+	return nil
+}
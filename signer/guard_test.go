@@ -0,0 +1,74 @@
+package signer
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//fakeSigner signs without a key, the rules do not depend on the signatures
+type fakeSigner struct{}
+
+func (fakeSigner) PubKey() []byte                                 { return []byte{4, 1} }
+func (fakeSigner) SignComet(body types.CometBody) (string, error) { return "comet", nil }
+func (fakeSigner) SignBlock(body types.BlockBody) (string, error) { return "block", nil }
+
+func TestGuard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "paradigm-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "state.json")
+
+	g, err := NewGuard(fakeSigner{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	comet := func(index int, tx string) types.CometBody {
+		return types.CometBody{Creator: []byte{4, 1}, Index: index, Transactions: [][]byte{[]byte(tx)}}
+	}
+
+	if _, err := g.SignComet(comet(0, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.SignComet(comet(0, "a")); err != nil {
+		t.Fatalf("signing the same comet again should be allowed: %s", err)
+	}
+	if _, err := g.SignComet(comet(1, "a")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.SignComet(comet(1, "b")); err == nil {
+		t.Fatal("signing two comets with the same index should be refused")
+	}
+	if _, err := g.SignComet(comet(0, "a")); err == nil {
+		t.Fatal("signing a comet below the last index should be refused")
+	}
+	other := comet(2, "a")
+	other.Creator = []byte{4, 2}
+	if _, err := g.SignComet(other); err == nil {
+		t.Fatal("signing a comet of another creator should be refused")
+	}
+
+	if _, err := g.SignBlock(types.BlockBody{Index: 3, RoundReceived: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	//The rules hold after a restart
+	g, err = NewGuard(fakeSigner{}, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.SignComet(comet(1, "b")); err == nil {
+		t.Fatal("the last comet should be remembered across restarts")
+	}
+	if _, err := g.SignBlock(types.BlockBody{Index: 3, RoundReceived: 2}); err == nil {
+		t.Fatal("the last block should be remembered across restarts")
+	}
+	if _, err := g.SignBlock(types.BlockBody{Index: 4, RoundReceived: 2}); err != nil {
+		t.Fatal(err)
+	}
+}
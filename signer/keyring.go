@@ -0,0 +1,72 @@
+package signer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sync"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+)
+
+//Keyring holds the keys a node rotates to, encrypted in a directory with the
+//password of the node key. The directory is read again when asked for a key
+//it does not hold, so that a key written while the node runs is found.
+type Keyring struct {
+	dir string
+	pwd string
+
+	mu     sync.Mutex
+	read   map[string]bool   //key files already decrypted
+	locals map[string]*Local //[PubKey] => signer
+}
+
+func NewKeyring(dir, pwd string) *Keyring {
+	return &Keyring{
+		dir:    dir,
+		pwd:    pwd,
+		read:   make(map[string]bool),
+		locals: make(map[string]*Local),
+	}
+}
+
+//Signer returns the signer of the key pub
+func (k *Keyring) Signer(pub []byte) (Signer, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	id := fmt.Sprintf("0x%X", pub)
+	if l, ok := k.locals[id]; ok {
+		return l, nil
+	}
+	if err := k.load(); err != nil {
+		return nil, err
+	}
+	if l, ok := k.locals[id]; ok {
+		return l, nil
+	}
+	return nil, fmt.Errorf("no key 0x%X in %s", pub, k.dir)
+}
+
+//load decrypts the key files not read yet
+func (k *Keyring) load() error {
+	files, err := filepath.Glob(filepath.Join(k.dir, "*.pem"))
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if k.read[f] {
+			continue
+		}
+		data, err := ioutil.ReadFile(f)
+		if err != nil {
+			return err
+		}
+		key, err := keystore.DecryptNodeKey(data, k.pwd)
+		if err != nil {
+			return fmt.Errorf("%s: %s", f, err)
+		}
+		k.read[f] = true
+		k.locals[fmt.Sprintf("0x%X", key.PubKey())] = NewLocal(key)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package signer
+
+import (
+	"math/big"
+
+	"github.com/paradigm-network/paradigm/accounts"
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+//KeyStore signs with the unlocked accounts of a keystore
+type KeyStore struct {
+	ks *keystore.KeyStore
+}
+
+func NewKeyStore(ks *keystore.KeyStore) *KeyStore {
+	return &KeyStore{ks: ks}
+}
+
+func (k *KeyStore) Accounts() []common.Address {
+	var res []common.Address
+	for _, a := range k.ks.Accounts() {
+		res = append(res, a.Address)
+	}
+	return res
+}
+
+func (k *KeyStore) SignTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error) {
+	account, err := k.ks.Find(accounts.Account{Address: from})
+	if err != nil {
+		return nil, ErrUnknownAccount
+	}
+	return k.ks.SignTx(account, tx, chainID)
+}
@@ -0,0 +1,220 @@
+package signer
+
+import (
+	"encoding/json"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/hexutil"
+	"github.com/paradigm-network/paradigm/common/rlp"
+	"github.com/paradigm-network/paradigm/types"
+	"github.com/rs/zerolog/log"
+)
+
+const unixScheme = "unix://"
+
+type JsonPubKey struct {
+	PubKey hexutil.Bytes `json:"pubKey"`
+}
+
+type JsonSignature struct {
+	Signature string `json:"signature"`
+}
+
+type JsonAccounts struct {
+	Accounts []common.Address `json:"accounts"`
+}
+
+//JsonTxRequest is an unsigned transaction, RLP encoded. The daemon hashes it
+//itself, so that it knows what it signs.
+type JsonTxRequest struct {
+	From    common.Address `json:"from"`
+	Tx      hexutil.Bytes  `json:"tx"`
+	ChainID *hexutil.Big   `json:"chainId"`
+}
+
+//JsonTx is a signed transaction, RLP encoded
+type JsonTx struct {
+	Tx hexutil.Bytes `json:"tx"`
+}
+
+//Server serves a Signer and a TxSigner to the Client of a node. Every request
+//must bear the token of the node: whoever can sign can advance the comet and
+//block indexes of the Guard and stall the validator.
+type Server struct {
+	signer   Signer
+	txSigner TxSigner
+	token    string
+	router   *mux.Router
+}
+
+//NewServer serves signer, and txSigner when it is not nil, to the requests
+//bearing token
+func NewServer(signer Signer, txSigner TxSigner, token string) *Server {
+	s := &Server{signer: signer, txSigner: txSigner, token: token, router: mux.NewRouter()}
+	s.router.HandleFunc("/pubkey", s.pubKeyHandler).Methods("GET")
+	s.router.HandleFunc("/comet", s.cometHandler).Methods("POST")
+	s.router.HandleFunc("/block", s.blockHandler).Methods("POST")
+	s.router.HandleFunc("/accounts", s.accountsHandler).Methods("GET")
+	s.router.HandleFunc("/tx", s.txHandler).Methods("POST")
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if s.token == "" || !Authorized(r, s.token) {
+		log.Warn().Str("remote", r.RemoteAddr).Str("path", r.URL.Path).Msg("Unauthorized request")
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	s.router.ServeHTTP(w, r)
+}
+
+//Serve serves on addr, unix://<path> or <host>:<port>, until it fails
+func (s *Server) Serve(addr string) error {
+	l, err := Listen(addr)
+	if err != nil {
+		return err
+	}
+	log.Info().Str("addr", addr).Msg("Serving signer")
+	return http.Serve(l, s)
+}
+
+//Listen listens on addr, unix://<path> or <host>:<port>. The socket is only
+//accessible by the user. The token is sent in clear over TCP, which should
+//only be used on the loopback or through a tunnel.
+func Listen(addr string) (net.Listener, error) {
+	if !strings.HasPrefix(addr, unixScheme) {
+		return net.Listen("tcp", addr)
+	}
+	path := strings.TrimPrefix(addr, unixScheme)
+	os.Remove(path) //left by a previous daemon
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, 0600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+/*
+GET /pubkey
+returns: JSON JsonPubKey
+*/
+func (s *Server) pubKeyHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, JsonPubKey{PubKey: s.signer.PubKey()})
+}
+
+/*
+POST /comet
+data: JSON types.CometBody
+returns: JSON JsonSignature
+*/
+func (s *Server) cometHandler(w http.ResponseWriter, r *http.Request) {
+	var body types.CometBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sig, err := s.signer.SignComet(body)
+	if err != nil {
+		log.Warn().Err(err).Int("index", body.Index).Msg("Refused to sign comet")
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	log.Debug().Int("index", body.Index).Msg("Signed comet")
+	writeJSON(w, JsonSignature{Signature: sig})
+}
+
+/*
+POST /block
+data: JSON types.BlockBody
+returns: JSON JsonSignature
+*/
+func (s *Server) blockHandler(w http.ResponseWriter, r *http.Request) {
+	var body types.BlockBody
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sig, err := s.signer.SignBlock(body)
+	if err != nil {
+		log.Warn().Err(err).Int("index", body.Index).Msg("Refused to sign block")
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	log.Info().Int("index", body.Index).Msg("Signed block")
+	writeJSON(w, JsonSignature{Signature: sig})
+}
+
+/*
+GET /accounts
+returns: JSON JsonAccounts
+*/
+func (s *Server) accountsHandler(w http.ResponseWriter, r *http.Request) {
+	res := JsonAccounts{Accounts: []common.Address{}}
+	if s.txSigner != nil {
+		res.Accounts = append(res.Accounts, s.txSigner.Accounts()...)
+	}
+	writeJSON(w, res)
+}
+
+/*
+POST /tx
+data: JSON JsonTxRequest
+returns: JSON JsonTx
+*/
+func (s *Server) txHandler(w http.ResponseWriter, r *http.Request) {
+	var req JsonTxRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if s.txSigner == nil {
+		http.Error(w, ErrUnknownAccount.Error(), http.StatusNotFound)
+		return
+	}
+	tx := new(types.Transaction)
+	if err := rlp.DecodeBytes(req.Tx, tx); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	signed, err := s.txSigner.SignTx(req.From, tx, (*big.Int)(req.ChainID))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+	data, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ev := log.Info().
+		Str("from", req.From.Hex()).
+		Str("tx", signed.Hash().Hex()).
+		Uint64("nonce", tx.Nonce()).
+		Str("value", tx.Value().String())
+	if to := tx.To(); to != nil {
+		ev = ev.Str("to", to.Hex())
+	}
+	ev.Msg("Signed transaction")
+	writeJSON(w, JsonTx{Tx: data})
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	js, err := json.Marshal(v)
+	if err != nil {
+		log.Error().Err(err).Msg("Marshaling JSON response")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(js)
+}
@@ -0,0 +1,193 @@
+package signer
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/accounts/keystore"
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+func tempDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "paradigm-signer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+//newTestDaemon serves an ed25519 validator key and a keystore with an
+//unlocked account
+func newTestDaemon(t *testing.T, dir, token string) (*Server, crypto.PrivKey, common.Address) {
+	key, err := crypto.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	guard, err := NewGuard(NewLocal(key), filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ks := keystore.NewKeyStoreWithScrypt(filepath.Join(dir, "keystore"), keystore.LightScryptN, keystore.LightScryptP)
+	account, err := ks.NewAccount("pwd")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ks.Unlock(account, "pwd"); err != nil {
+		t.Fatal(err)
+	}
+	return NewServer(guard, NewKeyStore(ks), token), key, account.Address
+}
+
+func TestClientServer(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	token, err := LoadToken(filepath.Join(dir, TokenFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server, key, from := newTestDaemon(t, dir, token)
+	ts := httptest.NewServer(server)
+	defer ts.Close()
+	addr := strings.TrimPrefix(ts.URL, "http://")
+
+	if _, err := NewClient(addr, "wrong"); err == nil {
+		t.Fatal("connected with a wrong token")
+	}
+	client, err := NewClient(addr, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(client.PubKey(), key.PubKey()) {
+		t.Fatalf("public key %X, expected %X", client.PubKey(), key.PubKey())
+	}
+
+	comet := types.Comet{Body: types.CometBody{Creator: key.PubKey(), Index: 3, Transactions: [][]byte{[]byte("abc")}}}
+	if comet.Signature, err = client.SignComet(comet.Body); err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := comet.VerifyWith(key.PubKey()); err != nil || !ok {
+		t.Fatalf("invalid comet signature: %v", err)
+	}
+	//the Guard of the daemon refuses an equivocation
+	comet.Body.Transactions = [][]byte{[]byte("def")}
+	if _, err := client.SignComet(comet.Body); err == nil {
+		t.Fatal("signed two comets with the same index")
+	}
+
+	block := types.Block{Body: types.BlockBody{Index: 1, RoundReceived: 2}}
+	sig, err := client.SignBlock(block.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok, err := block.VerifyWith(key.PubKey(), types.BlockSignature{Validator: key.PubKey(), Index: 1, Signature: sig}); err != nil || !ok {
+		t.Fatalf("invalid block signature: %v", err)
+	}
+
+	if accounts := client.Accounts(); len(accounts) != 1 || accounts[0] != from {
+		t.Fatalf("accounts %v, expected %s", accounts, from.Hex())
+	}
+	chainID := big.NewInt(7)
+	tx := types.NewTransaction(1, common.HexToAddress("0x01"), big.NewInt(10), big.NewInt(21000), big.NewInt(1), nil)
+	signed, err := client.SignTx(from, tx, chainID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sender, err := types.Sender(types.MakeSigner(chainID), signed)
+	if err != nil || sender != from {
+		t.Fatalf("sender %s, expected %s: %v", sender.Hex(), from.Hex(), err)
+	}
+	if _, err := client.SignTx(common.HexToAddress("0x02"), tx, chainID); err == nil {
+		t.Fatal("signed with an unknown account")
+	}
+}
+
+func TestServerUnauthorized(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	server, _, _ := newTestDaemon(t, dir, "token")
+	for _, auth := range []string{"", "token", "Bearer other"} {
+		r := httptest.NewRequest("POST", "/comet", strings.NewReader(`{"Index":1000000}`))
+		if auth != "" {
+			r.Header.Set("Authorization", auth)
+		}
+		w := httptest.NewRecorder()
+		server.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized {
+			t.Fatalf("%q: status %d, expected %d", auth, w.Code, http.StatusUnauthorized)
+		}
+	}
+
+	//a daemon without a token serves nobody
+	server, _, _ = newTestDaemon(t, dir, "")
+	r := httptest.NewRequest("GET", "/pubkey", nil)
+	r.Header.Set("Authorization", "Bearer ")
+	w := httptest.NewRecorder()
+	server.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, expected %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestClientServerUnix(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+
+	server, key, _ := newTestDaemon(t, dir, "token")
+	addr := unixScheme + filepath.Join(dir, "signer.sock")
+	l, err := Listen(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	go http.Serve(l, server)
+
+	fi, err := os.Stat(filepath.Join(dir, "signer.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fi.Mode().Perm() != 0600 {
+		t.Fatalf("socket mode %v", fi.Mode().Perm())
+	}
+	client, err := NewClient(addr, "token")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(client.PubKey(), key.PubKey()) {
+		t.Fatalf("public key %X, expected %X", client.PubKey(), key.PubKey())
+	}
+}
+
+func TestLoadToken(t *testing.T) {
+	dir := tempDir(t)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, TokenFile)
+
+	if _, err := ReadToken(path); !os.IsNotExist(err) {
+		t.Fatalf("read a missing token: %v", err)
+	}
+	token, err := LoadToken(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(token) != 64 {
+		t.Fatalf("token %q", token)
+	}
+	again, err := LoadToken(path)
+	if err != nil || again != token {
+		t.Fatalf("token %q reloaded as %q: %v", token, again, err)
+	}
+	if read, err := ReadToken(path); err != nil || read != token {
+		t.Fatalf("token %q read as %q: %v", token, read, err)
+	}
+}
@@ -0,0 +1,70 @@
+//Package signer abstracts the keys of a node: the validator key signing its
+//comets and blocks, and the accounts signing the transactions submitted to
+//its proxy. They are either held by the node or by a standalone signer
+//daemon, reached over a Unix socket or HTTP, so that they need not live on
+//the node.
+package signer
+
+import (
+	"errors"
+	"math/big"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+	"github.com/paradigm-network/paradigm/types"
+)
+
+var ErrUnknownAccount = errors.New("unknown account")
+
+//Signer signs with the validator key. The bodies are signed rather than their
+//hashes so that a signer can check what it signs.
+type Signer interface {
+	//PubKey is the public key of the validator, as in participants.json
+	PubKey() []byte
+
+	//SignComet returns the signature of a comet created by the validator
+	SignComet(body types.CometBody) (string, error)
+
+	//SignBlock returns the signature of a block by the validator
+	SignBlock(body types.BlockBody) (string, error)
+}
+
+//TxSigner signs the transactions of accounts
+type TxSigner interface {
+	//Accounts are the addresses of the accounts it signs for
+	Accounts() []common.Address
+
+	//SignTx signs tx for chainID with the account of from, without replay
+	//protection when chainID is nil
+	SignTx(from common.Address, tx *types.Transaction, chainID *big.Int) (*types.Transaction, error)
+}
+
+//Local signs with a key held in memory, of either type
+type Local struct {
+	key crypto.PrivKey
+}
+
+func NewLocal(key crypto.PrivKey) *Local {
+	return &Local{key: key}
+}
+
+func (l *Local) PubKey() []byte {
+	return l.key.PubKey()
+}
+
+func (l *Local) SignComet(body types.CometBody) (string, error) {
+	e := types.Comet{Body: body}
+	if err := e.SignWith(l.key); err != nil {
+		return "", err
+	}
+	return e.Signature, nil
+}
+
+func (l *Local) SignBlock(body types.BlockBody) (string, error) {
+	b := types.Block{Body: body}
+	sig, err := b.SignWith(l.key)
+	if err != nil {
+		return "", err
+	}
+	return sig.Signature, nil
+}
@@ -0,0 +1,57 @@
+package signer
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+)
+
+//TokenFile is the file of the token the node presents to the signer daemon,
+//in keystore_path by default
+const TokenFile = "signer.token"
+
+//LoadToken reads the token of the file of path, or creates the file with a
+//random token when it is missing. The file is only readable by the user.
+func LoadToken(path string) (string, error) {
+	token, err := ReadToken(path)
+	if err == nil || !os.IsNotExist(err) {
+		return token, err
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	token = hex.EncodeToString(b)
+	if err := ioutil.WriteFile(path, []byte(token+"\n"), 0600); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+//ReadToken reads the token of the file of path
+func ReadToken(path string) (string, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+	return token, nil
+}
+
+//Authorized returns whether r bears token, as "Authorization: Bearer <token>"
+func Authorized(r *http.Request, token string) bool {
+	auth := r.Header.Get("Authorization")
+	if !strings.HasPrefix(auth, "Bearer ") {
+		return false
+	}
+	given := strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	return subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
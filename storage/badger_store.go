@@ -291,12 +291,24 @@ func (s *BadgerStore) Close() error {
 	return s.db.Close()
 }
 
+//Ping reads a key that is never written, only the lookup itself can fail
+func (s *BadgerStore) Ping() error {
+	err := s.view(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte("ping"))
+		return err
+	})
+	if err != nil && !isDBKeyNotFound(err) {
+		return err
+	}
+	return nil
+}
+
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
 //DB Methods
 
 func (s *BadgerStore) dbGetEvent(key string) (types.Comet, error) {
 	var eventBytes []byte
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get([]byte(key))
 		if err != nil {
 			return err
@@ -350,13 +362,13 @@ func (s *BadgerStore) dbSetEvents(comets []types.Comet) error {
 			}
 		}
 	}
-	return tx.Commit(nil)
+	return commit(tx)
 }
 
 func (s *BadgerStore) DbTopologicalEvents() ([]types.Comet, error) {
 	var res []types.Comet
 	t := 0
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		key := topologicalEventKey(t)
 		item, errr := txn.Get(key)
 		for errr == nil {
@@ -398,7 +410,7 @@ func (s *BadgerStore) DbTopologicalEvents() ([]types.Comet, error) {
 
 func (s *BadgerStore) dbParticipantEvents(participant string, skip int) ([]string, error) {
 	res := []string{}
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		i := skip + 1
 		key := participantEventKey(participant, i)
 		item, errr := txn.Get(key)
@@ -426,7 +438,7 @@ func (s *BadgerStore) dbParticipantEvents(participant string, skip int) ([]strin
 func (s *BadgerStore) dbParticipantEvent(participant string, index int) (string, error) {
 	data := []byte{}
 	key := participantEventKey(participant, index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -455,13 +467,13 @@ func (s *BadgerStore) dbSetRoots(roots map[string]types.Root) error {
 			return err
 		}
 	}
-	return tx.Commit(nil)
+	return commit(tx)
 }
 
 func (s *BadgerStore) dbGetRoot(participant string) (types.Root, error) {
 	var rootBytes []byte
 	key := participantRootKey(participant)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -485,7 +497,7 @@ func (s *BadgerStore) dbGetRoot(participant string) (types.Root, error) {
 func (s *BadgerStore) dbGetRound(index int) (types.RoundInfo, error) {
 	var roundBytes []byte
 	key := roundKey(index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -521,12 +533,12 @@ func (s *BadgerStore) dbSetRound(index int, round types.RoundInfo) error {
 		return err
 	}
 
-	return tx.Commit(nil)
+	return commit(tx)
 }
 
 func (s *BadgerStore) dbGetParticipants() (map[string]int, error) {
 	res := make(map[string]int)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		it := txn.NewIterator(badger.DefaultIteratorOptions)
 		defer it.Close()
 		prefix := []byte(participantPrefix)
@@ -561,13 +573,13 @@ func (s *BadgerStore) dbSetParticipants(participants map[string]int) error {
 			return err
 		}
 	}
-	return tx.Commit(nil)
+	return commit(tx)
 }
 
 func (s *BadgerStore) dbGetBlock(index int) (types.Block, error) {
 	var blockBytes []byte
 	key := blockKey(index)
-	err := s.db.View(func(txn *badger.Txn) error {
+	err := s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -603,11 +615,11 @@ func (s *BadgerStore) dbSetBlock(block types.Block) error {
 		return err
 	}
 
-	return tx.Commit(nil)
+	return commit(tx)
 }
 
 func (s *BadgerStore) Get(key []byte) (value []byte, err error) {
-	err = s.db.View(func(txn *badger.Txn) error {
+	err = s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -622,7 +634,7 @@ func (s *BadgerStore) Get(key []byte) (value []byte, err error) {
 	return
 }
 func (s *BadgerStore) Has(key []byte) (has bool, err error) {
-	err = s.db.View(func(txn *badger.Txn) error {
+	err = s.view(func(txn *badger.Txn) error {
 		item, err := txn.Get(key)
 		if err != nil {
 			return err
@@ -643,7 +655,22 @@ func (s *BadgerStore) Put(key, value []byte) error {
 	if err := tx.Set(key, value); err != nil {
 		return err
 	}
-	return tx.Commit(nil)
+	return commit(tx)
+}
+
+func (s *BadgerStore) PutAll(keys, values [][]byte) error {
+	if len(keys) != len(values) {
+		return fmt.Errorf("%d keys for %d values", len(keys), len(values))
+	}
+	tx := s.db.NewTransaction(true)
+	defer tx.Discard()
+
+	for i, key := range keys {
+		if err := tx.Set(key, values[i]); err != nil {
+			return err
+		}
+	}
+	return commit(tx)
 }
 
 //++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++++
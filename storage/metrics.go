@@ -0,0 +1,30 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/dgraph-io/badger"
+	"github.com/paradigm-network/paradigm/common/metrics"
+)
+
+var (
+	//badger answers most reads from memory, hence buckets from 10µs
+	dbBuckets = metrics.ExponentialBuckets(0.00001, 4, 10)
+
+	dbReadDuration = metrics.NewHistogram("paradigm_badger_read_duration_seconds",
+		"Duration of the read transactions on the badger database.", dbBuckets)
+	dbWriteDuration = metrics.NewHistogram("paradigm_badger_write_duration_seconds",
+		"Duration of the commits of write transactions on the badger database.", dbBuckets)
+)
+
+//view runs fn in a read transaction and records its duration
+func (s *BadgerStore) view(fn func(txn *badger.Txn) error) error {
+	defer dbReadDuration.ObserveSince(time.Now())
+	return s.db.View(fn)
+}
+
+//commit commits a write transaction and records its duration
+func commit(tx *badger.Txn) error {
+	defer dbWriteDuration.ObserveSince(time.Now())
+	return tx.Commit(nil)
+}
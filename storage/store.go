@@ -25,8 +25,16 @@ type Store interface {
 	Reset(map[string]types.Root) error
 	Close() error
 
+	//Ping returns an error when the database cannot be read. It is called
+	//without the lock of the Store's user and must not touch its caches.
+	Ping() error
+
 	//tire
 	Get(key []byte) (value []byte, err error)
 	Has(key []byte) (bool, error)
 	Put(key, value []byte) error
+
+	//PutAll puts values[i] at keys[i] in a single transaction: all of them are
+	//written, or none
+	PutAll(keys, values [][]byte) error
 }
@@ -180,22 +180,24 @@ func (b *Block) Hex() string {
 }
 
 func (b *Block) Sign(privKey *ecdsa.PrivateKey) (bs BlockSignature, err error) {
+	return b.SignWith(crypto.Secp256k1PrivKey{PrivateKey: privKey})
+}
 
+//SignWith signs the block with an identity key of either type
+func (b *Block) SignWith(key crypto.PrivKey) (bs BlockSignature, err error) {
 	signBytes, err := b.Body.Hash()
 	if err != nil {
 		return bs, err
 	}
-	R, S, err := crypto.SignWithPrivKey(privKey, signBytes)
+	signature, err := key.Sign(signBytes)
 	if err != nil {
 		return bs, err
 	}
-	signature := BlockSignature{
-		Validator: crypto.FromECDSAPub(&privKey.PublicKey),
+	return BlockSignature{
+		Validator: key.PubKey(),
 		Index:     b.Index(),
-		Signature: crypto.EncodeSignature(R, S),
-	}
-
-	return signature, nil
+		Signature: signature,
+	}, nil
 }
 
 func (b *Block) SetSignature(bs BlockSignature) error {
@@ -204,18 +206,15 @@ func (b *Block) SetSignature(bs BlockSignature) error {
 }
 
 func (b *Block) Verify(sig BlockSignature) (bool, error) {
+	return b.VerifyWith(sig.Validator, sig)
+}
 
+//VerifyWith verifies sig with the key pub, which differs from the validator
+//once the validator rotated its key
+func (b *Block) VerifyWith(pub []byte, sig BlockSignature) (bool, error) {
 	signBytes, err := b.Body.Hash()
 	if err != nil {
 		return false, err
 	}
-
-	pubKey := crypto.ToECDSAPub(sig.Validator)
-
-	r, s, err := crypto.DecodeSignature(sig.Signature)
-	if err != nil {
-		return false, err
-	}
-
-	return crypto.Verify(pubKey, signBytes, r, s), nil
+	return crypto.VerifySignature(pub, signBytes, sig.Signature)
 }
@@ -60,9 +60,12 @@ func (b Bloom) Test(test *big.Int) bool {
 	return BloomLookup(b, test)
 }
 
+// TestBytes reports whether test may be in the filter. Unlike Test it keeps the
+// leading zero bytes of test, which are part of addresses and topics.
 func (b Bloom) TestBytes(test []byte) bool {
-	return b.Test(new(big.Int).SetBytes(test))
-
+	bloom := b.Big()
+	cmp := bloom9(test)
+	return bloom.And(bloom, cmp).Cmp(cmp) == 0
 }
 
 // MarshalText encodes b as a hex string with 0x prefix.
@@ -168,32 +168,31 @@ func (e *Comet) IsLoaded() bool {
 
 //ecdsa sig
 func (e *Comet) Sign(privKey *ecdsa.PrivateKey) error {
+	return e.SignWith(crypto.Secp256k1PrivKey{PrivateKey: privKey})
+}
+
+//SignWith signs the comet with an identity key of either type
+func (e *Comet) SignWith(key crypto.PrivKey) error {
 	signBytes, err := e.Body.Hash()
 	if err != nil {
 		return err
 	}
-	R, S, err := crypto.SignWithPrivKey(privKey, signBytes)
-	if err != nil {
-		return err
-	}
-	e.Signature = crypto.EncodeSignature(R, S)
+	e.Signature, err = key.Sign(signBytes)
 	return err
 }
 
 func (e *Comet) Verify() (bool, error) {
-	pubBytes := e.Body.Creator
-	pubKey := crypto.ToECDSAPub(pubBytes)
-	signBytes, err := e.Body.Hash()
-	if err != nil {
-		return false, err
-	}
+	return e.VerifyWith(e.Body.Creator)
+}
 
-	r, s, err := crypto.DecodeSignature(e.Signature)
+//VerifyWith verifies the signature with the key pub, which differs from the
+//creator once the creator rotated its key
+func (e *Comet) VerifyWith(pub []byte) (bool, error) {
+	signBytes, err := e.Body.Hash()
 	if err != nil {
 		return false, err
 	}
-
-	return crypto.Verify(pubKey, signBytes, r, s), nil
+	return crypto.VerifySignature(pub, signBytes, e.Signature)
 }
 
 //json encoding of body and signature
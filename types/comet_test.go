@@ -23,11 +23,14 @@ func createDummyCometBody() CometBody {
 }
 
 func TestSignComet(t *testing.T) {
-	privateKey, _ := crypto.GenerateECDSAKey()
-	//publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	publicKeyBytes := crypto.FromECDSAPub(&privateKey.PublicKey)
 
 	body := createDummyCometBody()
-	//body.Creator = publicKeyBytes
+	body.Creator = publicKeyBytes
 
 	comet := Comet{Body: body}
 	if err := comet.Sign(privateKey); err != nil {
@@ -42,3 +45,46 @@ func TestSignComet(t *testing.T) {
 		t.Fatalf("Verify returned false")
 	}
 }
+
+func TestSignCometEd25519(t *testing.T) {
+	key, err := crypto.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := createDummyCometBody()
+	body.Creator = key.PubKey()
+
+	comet := Comet{Body: body}
+	if err := comet.SignWith(key); err != nil {
+		t.Fatalf("Error signing Event: %s", err)
+	}
+
+	res, err := comet.Verify()
+	if err != nil {
+		t.Fatalf("Error verifying signature: %s", err)
+	}
+	if !res {
+		t.Fatalf("Verify returned false")
+	}
+
+	//the key of a rotated creator
+	rotated, err := crypto.GenerateEd25519Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := comet.SignWith(rotated); err != nil {
+		t.Fatalf("Error signing Event: %s", err)
+	}
+	if res, err := comet.VerifyWith(rotated.PubKey()); err != nil || !res {
+		t.Fatalf("VerifyWith returned %v: %v", res, err)
+	}
+	if res, _ := comet.VerifyWith(key.PubKey()); res {
+		t.Fatalf("VerifyWith returned true for the key of the creator")
+	}
+
+	comet.Body.Timestamp = comet.Body.Timestamp.Add(time.Second)
+	if res, _ := comet.VerifyWith(rotated.PubKey()); res {
+		t.Fatalf("VerifyWith returned true for a modified comet")
+	}
+}
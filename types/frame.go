@@ -3,4 +3,5 @@ package types
 type Frame struct {
 	Roots  map[string]Root
 	Comets []Comet
+	Keys   map[int][]KeyChange //[id] => keys the participants sign with, following their rotations
 }
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/paradigm-network/paradigm/common/crypto"
+)
+
+//keyRotationTag prefixes the key rotations among the transactions of the
+//comets. RLP encoded transactions never start with a zero byte.
+var keyRotationTag = []byte("\x00key-rotation:")
+
+type KeyRotationBody struct {
+	Participant []byte //key of the participant in participants.json, which identifies its slot
+	NewKey      []byte //key signing the comets and blocks of the participant once the rotation is active
+}
+
+func (kb *KeyRotationBody) Marshal() ([]byte, error) {
+	bf := bytes.NewBuffer([]byte{})
+	enc := json.NewEncoder(bf)
+	if err := enc.Encode(kb); err != nil {
+		return nil, err
+	}
+	return bf.Bytes(), nil
+}
+
+func (kb *KeyRotationBody) Hash() ([]byte, error) {
+	hashBytes, err := kb.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.SHA256(hashBytes), nil
+}
+
+//KeyRotation replaces the key of a participant without changing its slot. It
+//is signed by the last key the participant rotated to, or by its key in
+//participants.json. Since a key is never reused, a rotation cannot be
+//replayed.
+type KeyRotation struct {
+	Body      KeyRotationBody
+	Signature string
+}
+
+func NewKeyRotation(participant, newKey []byte) KeyRotation {
+	return KeyRotation{
+		Body: KeyRotationBody{
+			Participant: participant,
+			NewKey:      newKey,
+		},
+	}
+}
+
+func (k *KeyRotation) ParticipantHex() string {
+	return fmt.Sprintf("0x%X", k.Body.Participant)
+}
+
+func (k *KeyRotation) NewKeyHex() string {
+	return fmt.Sprintf("0x%X", k.Body.NewKey)
+}
+
+//Sign signs the rotation with the current key of the participant
+func (k *KeyRotation) Sign(key crypto.PrivKey) error {
+	signBytes, err := k.Body.Hash()
+	if err != nil {
+		return err
+	}
+	k.Signature, err = key.Sign(signBytes)
+	return err
+}
+
+//Verify returns whether the rotation is signed by current
+func (k *KeyRotation) Verify(current []byte) (bool, error) {
+	if _, err := crypto.PubKeyType(k.Body.NewKey); err != nil {
+		return false, fmt.Errorf("new key: %s", err)
+	}
+	signBytes, err := k.Body.Hash()
+	if err != nil {
+		return false, err
+	}
+	return crypto.VerifySignature(current, signBytes, k.Signature)
+}
+
+//Hash identifies the rotation among the transactions
+func (k *KeyRotation) Hash() ([]byte, error) {
+	data, err := k.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return crypto.SHA256(data), nil
+}
+
+//Marshal encodes the rotation as a transaction
+func (k *KeyRotation) Marshal() ([]byte, error) {
+	data, err := json.Marshal(k)
+	if err != nil {
+		return nil, err
+	}
+	return append(append([]byte{}, keyRotationTag...), data...), nil
+}
+
+func (k *KeyRotation) Unmarshal(tx []byte) error {
+	if !IsKeyRotation(tx) {
+		return fmt.Errorf("not a key rotation")
+	}
+	return json.Unmarshal(tx[len(keyRotationTag):], k)
+}
+
+//IsKeyRotation returns whether the transaction tx is a key rotation
+func IsKeyRotation(tx []byte) bool {
+	return bytes.HasPrefix(tx, keyRotationTag)
+}
+
+//KeyChange is a key of a participant and the first round it signs
+type KeyChange struct {
+	Round int
+	Key   []byte
+}
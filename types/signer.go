@@ -13,6 +13,7 @@ import (
 
 var (
 	ErrInvalidChainId = errors.New("invalid chain id for signer")
+	ErrUnprotectedTx  = errors.New("only replay-protected (EIP-155) transactions allowed")
 )
 
 // sigCache is used to cache the derived sender and contains
@@ -22,9 +23,13 @@ type sigCache struct {
 	from   common.Address
 }
 
-// MakeSigner returns a Signer based on the given chain config and block number.
-func MakeSigner() Signer {
-	return NewBasicSigner()
+// MakeSigner returns a Signer for the given chain id. A nil chain id yields a
+// signer for transactions without replay protection.
+func MakeSigner(chainId *big.Int) Signer {
+	if chainId == nil {
+		return UnprotectedSigner{}
+	}
+	return NewBasicSigner(chainId)
 }
 
 // SignTx signs the transaction using the given signer and private key
@@ -80,18 +85,27 @@ type Signer interface {
 	Equal(Signer) bool
 }
 
-// EIP155Transaction implements Signer using the EIP155 rules.
+// BasicSigner implements Signer using the EIP155 rules. The chain id is part of
+// the signed hash so a transaction signed for one chain is rejected on another.
 type BasicSigner struct {
 	chainId, chainIdMul *big.Int
 }
 
-func NewBasicSigner() BasicSigner {
+func NewBasicSigner(chainId *big.Int) BasicSigner {
+	if chainId == nil {
+		chainId = new(big.Int)
+	}
 	return BasicSigner{
-		chainId:    big.NewInt(1),
-		chainIdMul: new(big.Int).Mul(big.NewInt(1), big.NewInt(2)),
+		chainId:    chainId,
+		chainIdMul: new(big.Int).Mul(chainId, big.NewInt(2)),
 	}
 }
 
+// ChainId returns the chain id the signer signs for
+func (s BasicSigner) ChainId() *big.Int {
+	return new(big.Int).Set(s.chainId)
+}
+
 func (s BasicSigner) Equal(s2 Signer) bool {
 	eip155, ok := s2.(BasicSigner)
 	return ok && eip155.chainId.Cmp(s.chainId) == 0
@@ -100,6 +114,9 @@ func (s BasicSigner) Equal(s2 Signer) bool {
 var big8 = big.NewInt(8)
 
 func (s BasicSigner) Sender(tx *Transaction) (common.Address, error) {
+	if !tx.Protected() {
+		return UnprotectedSigner{}.Sender(tx)
+	}
 	if tx.ChainId().Cmp(s.chainId) != 0 {
 		return common.Address{}, ErrInvalidChainId
 	}
@@ -138,6 +155,44 @@ func (s BasicSigner) Hash(tx *Transaction) common.Hash {
 	})
 }
 
+// UnprotectedSigner implements Signer for transactions signed without a chain
+// id, i.e. before replay protection. Such transactions are valid on every chain.
+type UnprotectedSigner struct{}
+
+func (s UnprotectedSigner) Equal(s2 Signer) bool {
+	_, ok := s2.(UnprotectedSigner)
+	return ok
+}
+
+func (s UnprotectedSigner) Sender(tx *Transaction) (common.Address, error) {
+	return recoverPlain(s.Hash(tx), tx.data.R, tx.data.S, tx.data.V)
+}
+
+// SignatureValues returns signature values. This signature
+// needs to be in the [R || S || V] format where V is 0 or 1.
+func (s UnprotectedSigner) SignatureValues(tx *Transaction, sig []byte) (R, S, V *big.Int, err error) {
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	R = new(big.Int).SetBytes(sig[:32])
+	S = new(big.Int).SetBytes(sig[32:64])
+	V = new(big.Int).SetBytes([]byte{sig[64] + 27})
+	return R, S, V, nil
+}
+
+// Hash returns the hash to be signed by the sender.
+// It does not uniquely identify the transaction.
+func (s UnprotectedSigner) Hash(tx *Transaction) common.Hash {
+	return common.RlpHash([]interface{}{
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+	})
+}
+
 func recoverPlain(sighash common.Hash, R, S, Vb *big.Int) (common.Address, error) {
 	if Vb.BitLen() > 8 {
 		return common.Address{}, ErrInvalidSig
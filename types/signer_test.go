@@ -0,0 +1,48 @@
+package types
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/paradigm-network/paradigm/common"
+	"github.com/paradigm-network/paradigm/common/crypto"
+)
+
+func TestSignerChainId(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	tx := NewTransaction(0, common.Address{}, big.NewInt(1), big.NewInt(21000), big.NewInt(0), nil)
+
+	signer := NewBasicSigner(big.NewInt(18))
+	protected, err := SignTx(tx, signer, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !protected.Protected() || protected.ChainId().Cmp(big.NewInt(18)) != 0 {
+		t.Fatalf("expected a transaction protected for chain 18, got chain %v", protected.ChainId())
+	}
+	if _, err := Sender(NewBasicSigner(big.NewInt(1)), protected); err != ErrInvalidChainId {
+		t.Fatalf("expected %v, got %v", ErrInvalidChainId, err)
+	}
+
+	unprotected, err := SignTx(tx, MakeSigner(nil), key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if unprotected.Protected() {
+		t.Fatal("expected an unprotected transaction")
+	}
+
+	//chain id signers still recover unprotected transactions, it is up to the
+	//caller to refuse them
+	from1, err := Sender(signer, protected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	from2, err := Sender(signer, unprotected)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if from1 != from2 {
+		t.Fatalf("expected the same sender, got %x and %x", from1, from2)
+	}
+}
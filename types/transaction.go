@@ -242,7 +242,10 @@ func (tx *Transaction) String() string {
 	if tx.data.V != nil {
 		// make a best guess about the signer and use that to derive
 		// the sender.
-		signer := NewBasicSigner()
+		signer := MakeSigner(nil)
+		if tx.Protected() {
+			signer = MakeSigner(tx.ChainId())
+		}
 		if f, err := Sender(signer, tx); err != nil { // derive but don't cache
 			from = "[invalid sender: invalid sig]"
 		} else {